@@ -1,57 +1,279 @@
 package xdr
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 )
 
 // Examine may be used to determine the size of a []byte needed by Pack() (passed by value or reference).
 func Examine(objIF interface{}) (bytesNeeded uint64, err error) {
+	bytesNeeded, err = ExamineWithOptions(objIF, &PackOptions{})
+
+	return
+}
+
+// ExamineWithOptions may be used to determine the size of a []byte needed by PackWithOptions() given options.
+func ExamineWithOptions(objIF interface{}, options *PackOptions) (bytesNeeded uint64, err error) {
 	var (
 		objValueOf reflect.Value
 	)
 
+	defer recoverInternalError("Examine", &err)
+
+	if nil == options {
+		options = &PackOptions{}
+	}
+
 	objValueOf = reflect.ValueOf(objIF)
 
-	bytesNeeded, err = examineRecursive(objValueOf, 0)
+	bytesNeeded, err = examineRecursive(objValueOf, xdrTag{}, 0, &examineState{options: options, checkValues: true})
+
+	return
+}
+
+// PackOptions may be supplied to PackWithOptions() (and ExamineWithOptions()) to control how srcObjIF is encoded.
+//
+// The zero value of PackOptions yields the same behavior as Pack().
+type PackOptions struct {
+	// MaxDepth bounds the nesting depth of arrays, structs, pointers, and interfaces (or, if zero, applies
+	// DefaultMaxDepth) just as Limits.MaxDepth does when decoding. Regardless, a value referencing itself (e.g. a
+	// cyclic linked list) fails with ErrCycle.
+	MaxDepth uint64
+}
 
+// maxDepth returns the nesting depth to which encoding is limited by options.
+func (options *PackOptions) maxDepth() (maxDepth uint64) {
+	if 0 == options.MaxDepth {
+		maxDepth = DefaultMaxDepth
+	} else {
+		maxDepth = options.MaxDepth
+	}
 	return
 }
 
 // Pack is used to serialize the supplied struct (passed by value or reference).
 func Pack(srcObjIF interface{}) (dst []byte, err error) {
+	dst, err = PackWithOptions(srcObjIF, &PackOptions{})
+
+	return
+}
+
+// PackWithOptions is used to serialize the supplied struct (passed by value or reference) as directed by options.
+func PackWithOptions(srcObjIF interface{}, options *PackOptions) (dst []byte, err error) {
 	var (
 		bytesNeeded   uint64
+		bytesPacked   uint64
 		srcObjValueOf reflect.Value
 	)
 
+	defer func() {
+		if nil != err {
+			dst = nil
+		}
+	}()
+	defer recoverInternalError("Pack", &err)
+
+	if nil == options {
+		options = &PackOptions{}
+	}
+
 	srcObjValueOf = reflect.ValueOf(srcObjIF)
 
-	bytesNeeded, err = examineRecursive(srcObjValueOf, 0)
+	bytesNeeded, err = examineRecursive(srcObjValueOf, xdrTag{}, 0, &examineState{options: options, checkValues: true})
 	if nil != err {
 		return
 	}
 
 	dst = make([]byte, bytesNeeded)
 
-	_ = packRecursive(srcObjValueOf, dst, 0)
+	bytesPacked, err = packRecursive(srcObjValueOf, xdrTag{}, dst, 0)
+	if nil != err {
+		return
+	}
+
+	if bytesPacked != bytesNeeded {
+		err = newPackError(bytesPacked, ErrSizeMismatch, "packed 0x%X bytes but Examine() computed 0x%X", bytesPacked, bytesNeeded)
+		return
+	}
+
+	return
+}
+
+// UnpackOptions may be supplied to UnpackWithOptions() to control how src is decoded.
+//
+// The zero value of UnpackOptions yields the same behavior as Unpack().
+type UnpackOptions struct {
+	// AliasBytes, if true, directs decoded []byte fields (Variable-Length Opaque Data and String)
+	// to reference src directly rather than a copy of it. This avoids an allocation and copy per
+	// field, but the decoded struct then shares memory with src and will observe any subsequent
+	// modification (or reuse) of src.
+	AliasBytes bool
+
+	// Limits bounds the resources consumed decoding src (e.g. when src came from an untrusted peer).
+	Limits Limits
+
+	// Mode selects how strictly src must conform to RFC 4506.
+	Mode UnpackMode
+}
+
+// UnpackMode selects how strictly UnpackWithOptions() requires src to conform to RFC 4506.
+type UnpackMode int
+
+const (
+	// UnpackModeDefault rejects non-zero pad bytes, Boolean values other than 0 or 1, and
+	// Enumeration values not declared via RegisterEnumeration().
+	UnpackModeDefault UnpackMode = iota
+
+	// UnpackModeLenient ignores the contents of pad bytes, decodes any non-zero Boolean value as true, and
+	// accepts Enumeration values not declared via RegisterEnumeration(). This accommodates legacy peers that
+	// do not conform to RFC 4506.
+	UnpackModeLenient
+
+	// UnpackModeStrict adds to the checks of UnpackModeDefault by rejecting src containing bytes beyond
+	// the decoded value and Enumeration values outside the (signed 32-bit) range of an XDR enum.
+	UnpackModeStrict
+)
+
+// Limits bounds the resources UnpackWithOptions() will consume decoding src. A field left zero imposes no limit,
+// except that MaxDepth then defaults to DefaultMaxDepth.
+//
+// Regardless of Limits, a Variable-Length Array is only allocated once src is known to be long enough
+// to possibly hold the number of elements its length field claims. As src cannot bound the length of one
+// whose elements may encode in zero bytes (e.g. a []struct{}), that length is instead bounded by
+// MaxArrayElements or, if zero, DefaultMaxZeroSizeElements.
+type Limits struct {
+	MaxAllocation    uint64 // Maximum total bytes allocated for the contents of decoded slices and strings
+	MaxDepth         uint64 // Maximum nesting depth of arrays, structs, pointers, & interfaces (or 0 for DefaultMaxDepth)
+	MaxArrayElements uint64 // Maximum element count of any one Variable-Length Array
+	MaxStringLength  uint64 // Maximum length of any one String or Variable-Length Opaque Data
+}
+
+const (
+	// DefaultMaxDepth is the nesting depth of arrays, structs, pointers, and interfaces to which decoding is
+	// limited should Limits.MaxDepth be zero. Each level of nesting consumes goroutine stack, and exhausting the
+	// stack (e.g. by a hostile chain of Optional-Data) is fatal rather than a recoverable panic.
+	DefaultMaxDepth = 10000
+
+	// DefaultMaxZeroSizeElements bounds the length of a Variable-Length Array whose elements may encode in
+	// zero bytes should Limits.MaxArrayElements be zero.
+	DefaultMaxZeroSizeElements = 65536
+)
+
+// maxDepth returns the nesting depth to which decoding is limited by limits.
+func (limits *Limits) maxDepth() (maxDepth uint64) {
+	if 0 == limits.MaxDepth {
+		maxDepth = DefaultMaxDepth
+	} else {
+		maxDepth = limits.MaxDepth
+	}
+	return
+}
 
+// maxZeroSizeElements returns the element count to which limits bounds a Variable-Length Array whose
+// elements may encode in zero bytes.
+func (limits *Limits) maxZeroSizeElements() (maxZeroSizeElements uint64) {
+	if 0 == limits.MaxArrayElements {
+		maxZeroSizeElements = DefaultMaxZeroSizeElements
+	} else {
+		maxZeroSizeElements = limits.MaxArrayElements
+	}
 	return
 }
 
 // Unpack is used to deserialize into the supplied struct (passed by reference).
+//
+// Any []byte fields of the supplied struct receive copies of the corresponding bytes of src.
 func Unpack(src []byte, dstObjIF interface{}) (bytesConsumed uint64, err error) {
+	bytesConsumed, err = UnpackWithOptions(src, dstObjIF, &UnpackOptions{})
+
+	return
+}
+
+// UnpackWithOptions is used to deserialize into the supplied struct (passed by reference) as directed by options.
+//
+// Errors encountered decoding src are reported as an *UnpackError.
+func UnpackWithOptions(src []byte, dstObjIF interface{}, options *UnpackOptions) (bytesConsumed uint64, err error) {
 	var (
 		dstObjValueOf reflect.Value
 	)
 
+	defer recoverInternalError("Unpack", &err)
+
+	if nil == options {
+		options = &UnpackOptions{}
+	}
+
 	dstObjValueOf = reflect.ValueOf(dstObjIF)
 
-	_, err = examineRecursive(dstObjValueOf, 0)
+	if (reflect.Ptr != dstObjValueOf.Kind()) || dstObjValueOf.IsNil() {
+		err = newUnpackError(0, ErrInvalidDestination, "dstObjIF must be a non-nil pointer (not %T)", dstObjIF)
+		return
+	}
+
+	// Note: Only the tags of dstObjIF are validated (its current field values are about to be overwritten)
+
+	_, err = examineRecursive(dstObjValueOf, xdrTag{}, 0, &examineState{options: &PackOptions{}, checkValues: false})
 	if nil != err {
 		return
 	}
 
-	bytesConsumed, err = unpackRecursive(src, 0, 0, dstObjValueOf)
+	bytesConsumed, err = unpackRecursive(src, 0, xdrTag{}, dstObjValueOf.Elem(), 0, &unpackState{options: options})
+	if nil != err {
+		return
+	}
+
+	if (UnpackModeStrict == options.Mode) && (uint64(len(src)) > bytesConsumed) {
+		err = newUnpackError(bytesConsumed, ErrTrailingBytes, "0x%X bytes remain in src []byte", uint64(len(src))-bytesConsumed)
+		return
+	}
+
+	return
+}
+
+// UnpackExact is like Unpack() but fails (with ErrTrailingBytes) unless the supplied struct consumes all of src.
+func UnpackExact(src []byte, dstObjIF interface{}) (err error) {
+	var (
+		bytesConsumed uint64
+	)
+
+	bytesConsumed, err = Unpack(src, dstObjIF)
+	if nil != err {
+		return
+	}
+
+	if uint64(len(src)) > bytesConsumed {
+		err = newUnpackError(bytesConsumed, ErrTrailingBytes, "0x%X bytes remain in src []byte", uint64(len(src))-bytesConsumed)
+		return
+	}
+
+	return
+}
+
+// UnpackAll is used to deserialize a sequence of values encoded back-to-back in src (e.g. an RPC call header
+// followed by its arguments) into the supplied structs (each passed by reference), in order.
+//
+// Should unpacking dstObjIFs[i] fail, err identifies i (wrapping an *UnpackError whose Offset is relative to
+// src) and bytesConsumed is the number of bytes consumed by dstObjIFs[:i].
+func UnpackAll(src []byte, dstObjIFs ...interface{}) (bytesConsumed uint64, err error) {
+	var (
+		dstObjIF         interface{}
+		dstObjIFIndex    int
+		objBytesConsumed uint64
+		unpackError      *UnpackError
+	)
+
+	for dstObjIFIndex, dstObjIF = range dstObjIFs {
+		objBytesConsumed, err = Unpack(src[bytesConsumed:], dstObjIF)
+		if nil != err {
+			if errors.As(err, &unpackError) {
+				unpackError.Offset += bytesConsumed
+			}
+			err = fmt.Errorf("UnpackAll() failed unpacking dstObjIFs[%d] (%T): %w", dstObjIFIndex, dstObjIF, err)
+			return
+		}
+		bytesConsumed += objBytesConsumed
+	}
 
 	return
 }
@@ -2,7 +2,9 @@ package xdr
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -217,3 +219,817 @@ func TestUnpack(t *testing.T) {
 		t.Fatalf("Unpack(goodParentStructPacked, &goodParentStructReturned) received unexpected goodParentStructReturned")
 	}
 }
+
+func TestUnpackExact(t *testing.T) {
+	var (
+		err                      error
+		goodParentStructReturned ParentStruct
+		unpackError              *UnpackError
+	)
+
+	err = UnpackExact(goodParentStructPacked, &goodParentStructReturned)
+	if nil != err {
+		t.Fatalf("UnpackExact(goodParentStructPacked, &goodParentStructReturned) received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(goodParentStruct, goodParentStructReturned) {
+		t.Fatalf("UnpackExact(goodParentStructPacked, &goodParentStructReturned) received unexpected goodParentStructReturned")
+	}
+
+	err = UnpackExact(append(append([]byte{}, goodParentStructPacked...), 0x00), &goodParentStructReturned)
+	if !errors.Is(err, ErrTrailingBytes) || !errors.As(err, &unpackError) || (goodParentStructPackedLen != unpackError.Offset) {
+		t.Fatalf("UnpackExact(<trailing bytes>) should have failed with ErrTrailingBytes at offset 0x%X (got %v)", goodParentStructPackedLen, err)
+	}
+
+	err = UnpackExact(badParentStructPacked, &goodParentStructReturned)
+	if !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Fatalf("UnpackExact(badParentStructPacked) should have failed with ErrMaxSizeExceeded (got %v)", err)
+	}
+}
+
+func TestUnpackAll(t *testing.T) {
+	var (
+		bytesConsumed            uint64
+		childStructReturned      ChildStruct
+		err                      error
+		goodParentStructReturned ParentStruct
+		src                      []byte
+		u32Returned              uint32
+		unpackError              *UnpackError
+	)
+
+	src = append([]byte{0x00, 0x00, 0x00, 0x07}, goodParentStructPacked...)
+	src = append(src, 0x00, 0x00, 0x00, 0x01, 0xFF)
+
+	bytesConsumed, err = UnpackAll(src, &u32Returned, &goodParentStructReturned, &childStructReturned)
+	if nil != err {
+		t.Fatalf("UnpackAll(src, ...) received unexpected error: %v", err)
+	}
+	if uint64(len(src)-1) != bytesConsumed {
+		t.Fatalf("UnpackAll(src, ...) received unexpected bytesConsumed (0x%X) - should have been 0x%X", bytesConsumed, len(src)-1)
+	}
+	if (7 != u32Returned) || !reflect.DeepEqual(goodParentStruct, goodParentStructReturned) || !childStructReturned.BooleanInChild {
+		t.Fatalf("UnpackAll(src, ...) received unexpected values")
+	}
+
+	src[len(src)-2] = 0x02
+
+	bytesConsumed, err = UnpackAll(src, &u32Returned, &goodParentStructReturned, &childStructReturned)
+	if !errors.Is(err, ErrInvalidBoolean) || !strings.Contains(err.Error(), "dstObjIFs[2] (*xdr.ChildStruct)") {
+		t.Fatalf("UnpackAll(<invalid third value>) should have failed with ErrInvalidBoolean identifying dstObjIFs[2] (got %v)", err)
+	}
+	if !errors.As(err, &unpackError) || (uint64(len(src)-5) != unpackError.Offset) {
+		t.Fatalf("UnpackAll(<invalid third value>) should have reported offset 0x%X (got %v)", len(src)-5, err)
+	}
+	if uint64(len(src)-5) != bytesConsumed {
+		t.Fatalf("UnpackAll(<invalid third value>) received unexpected bytesConsumed (0x%X) - should have been 0x%X", bytesConsumed, len(src)-5)
+	}
+}
+
+func TestUnpackWithOptions(t *testing.T) {
+	var (
+		aliasedParentStructReturned ParentStruct
+		copiedParentStructReturned  ParentStruct
+		err                         error
+		src                         []byte
+	)
+
+	src = make([]byte, len(goodParentStructPacked))
+
+	copy(src, goodParentStructPacked)
+
+	_, err = UnpackWithOptions(src, &copiedParentStructReturned, &UnpackOptions{AliasBytes: false})
+	if nil != err {
+		t.Fatalf("UnpackWithOptions(src, &copiedParentStructReturned, &UnpackOptions{AliasBytes: false}) received unexpected error: %v", err)
+	}
+
+	_, err = UnpackWithOptions(src, &aliasedParentStructReturned, &UnpackOptions{AliasBytes: true})
+	if nil != err {
+		t.Fatalf("UnpackWithOptions(src, &aliasedParentStructReturned, &UnpackOptions{AliasBytes: true}) received unexpected error: %v", err)
+	}
+
+	// Overwrite the first byte of VariableLengthOpaqueDataNoMax (at offset 0x30) in src
+
+	src[0x30] = 0xFF
+
+	if 0x01 != copiedParentStructReturned.VariableLengthOpaqueDataNoMax[0] {
+		t.Fatalf("UnpackWithOptions(src, &copiedParentStructReturned, &UnpackOptions{AliasBytes: false}) should not have aliased src")
+	}
+	if 0xFF != aliasedParentStructReturned.VariableLengthOpaqueDataNoMax[0] {
+		t.Fatalf("UnpackWithOptions(src, &aliasedParentStructReturned, &UnpackOptions{AliasBytes: true}) should have aliased src")
+	}
+}
+
+type LimitsStruct struct {
+	Strings []string `XDR_Name:"Variable-Length Array"`
+}
+
+type DeepNode struct {
+	V    uint32    `xdr:""`
+	Next *DeepNode `xdr:"optional"`
+}
+
+// SelfPointer references itself through pointers alone (so only MaxDepth bounds unpacking into it)
+type SelfPointer *SelfPointer
+
+// deepNodeSrc is about 2 MB of repeated DeepNode encodings, each referencing the next (nested far beyond DefaultMaxDepth)
+var deepNodeSrc = bytes.Repeat([]byte{0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x01}, (2<<20)/8)
+
+func TestUnpackLimits(t *testing.T) {
+	var (
+		deepNode                    DeepNode
+		emptyStructsReturned        []struct{}
+		err                         error
+		hostileSrc                  []byte
+		limitsStruct                LimitsStruct
+		limitsStructPacked          []byte
+		selfPointer                 SelfPointer
+		selfPointers                []SelfPointer
+		unpackError                 *UnpackError
+		variableLengthArrayReturned []ArrayElementStruct
+	)
+
+	// A claimed element count of 0xFFFFFFFF must be rejected before anything is allocated
+
+	hostileSrc = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x01}
+
+	_, err = Unpack(hostileSrc, &variableLengthArrayReturned)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Unpack(hostileSrc, &variableLengthArrayReturned) should have failed with ErrTruncated (got %v)", err)
+	}
+	if !errors.As(err, &unpackError) || (0 != unpackError.Offset) {
+		t.Fatalf("Unpack(hostileSrc, &variableLengthArrayReturned) should have failed with an *UnpackError at offset 0 (got %v)", err)
+	}
+
+	limitsStructPacked, err = Pack(LimitsStruct{Strings: []string{"Hi", "Bye"}})
+	if nil != err {
+		t.Fatalf("Pack(LimitsStruct{}) received unexpected error: %v", err)
+	}
+
+	_, err = UnpackWithOptions(limitsStructPacked, &limitsStruct, &UnpackOptions{Limits: Limits{MaxAllocation: 37, MaxDepth: 2, MaxArrayElements: 2, MaxStringLength: 3}})
+	if nil != err {
+		t.Fatalf("UnpackWithOptions(limitsStructPacked, &limitsStruct, <generous Limits>) received unexpected error: %v", err)
+	}
+
+	for _, limits := range []Limits{{MaxAllocation: 4}, {MaxDepth: 1}, {MaxArrayElements: 1}, {MaxStringLength: 2}} {
+		_, err = UnpackWithOptions(limitsStructPacked, &limitsStruct, &UnpackOptions{Limits: limits})
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("UnpackWithOptions(limitsStructPacked, &limitsStruct, &UnpackOptions{Limits: %+v}) should have failed with ErrLimitExceeded (got %v)", limits, err)
+		}
+	}
+
+	// Absent Limits, nesting is bounded by DefaultMaxDepth (rather than by exhausting the goroutine stack)...
+
+	_, err = Unpack(deepNodeSrc, &deepNode)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Unpack(deepNodeSrc, &deepNode) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+	_, err = Unpack([]byte{}, &selfPointer)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Unpack([]byte{}, &selfPointer) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, &selfPointers)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Unpack(<1 element>, &selfPointers) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+
+	// ...and the claimed element count of elements possibly encoded in zero bytes by DefaultMaxZeroSizeElements
+
+	_, err = Unpack(hostileSrc, &emptyStructsReturned)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Unpack(hostileSrc, &emptyStructsReturned) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+	_, err = UnpackWithOptions([]byte{0x00, 0x00, 0x00, 0x03}, &emptyStructsReturned, &UnpackOptions{Limits: Limits{MaxArrayElements: 3}})
+	if (nil != err) || (3 != len(emptyStructsReturned)) {
+		t.Fatalf("UnpackWithOptions(<3 empty structs>, &emptyStructsReturned, <generous Limits>) returned %v or unexpected error: %v", emptyStructsReturned, err)
+	}
+}
+
+func TestUnpackModes(t *testing.T) {
+	var (
+		err                      error
+		goodParentStructReturned ParentStruct
+		src                      []byte
+	)
+
+	// Non-zero pad byte following FixedLengthOpaqueData (at offset 0x29)
+
+	src = append([]byte{}, goodParentStructPacked...)
+	src[0x29] = 0xAA
+
+	_, err = Unpack(src, &goodParentStructReturned)
+	if !errors.Is(err, ErrNonZeroPadding) {
+		t.Fatalf("Unpack(<non-zero pad byte>) should have failed with ErrNonZeroPadding (got %v)", err)
+	}
+	_, err = UnpackWithOptions(src, &goodParentStructReturned, &UnpackOptions{Mode: UnpackModeLenient})
+	if nil != err {
+		t.Fatalf("UnpackWithOptions(<non-zero pad byte>, UnpackModeLenient) received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(goodParentStruct, goodParentStructReturned) {
+		t.Fatalf("UnpackWithOptions(<non-zero pad byte>, UnpackModeLenient) received unexpected goodParentStructReturned")
+	}
+
+	// Boolean (at offset 0x10) encoded as 2
+
+	src = append([]byte{}, goodParentStructPacked...)
+	src[0x13] = 0x02
+
+	_, err = Unpack(src, &goodParentStructReturned)
+	if !errors.Is(err, ErrInvalidBoolean) {
+		t.Fatalf("Unpack(<Boolean encoded as 2>) should have failed with ErrInvalidBoolean (got %v)", err)
+	}
+	_, err = UnpackWithOptions(src, &goodParentStructReturned, &UnpackOptions{Mode: UnpackModeLenient})
+	if nil != err {
+		t.Fatalf("UnpackWithOptions(<Boolean encoded as 2>, UnpackModeLenient) received unexpected error: %v", err)
+	}
+	if !goodParentStructReturned.Boolean {
+		t.Fatalf("UnpackWithOptions(<Boolean encoded as 2>, UnpackModeLenient) should have decoded Boolean as true")
+	}
+
+	// Trailing bytes
+
+	src = append(append([]byte{}, goodParentStructPacked...), 0x00, 0x00, 0x00, 0x00)
+
+	_, err = Unpack(src, &goodParentStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(<trailing bytes>) received unexpected error: %v", err)
+	}
+	_, err = UnpackWithOptions(src, &goodParentStructReturned, &UnpackOptions{Mode: UnpackModeStrict})
+	if !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("UnpackWithOptions(<trailing bytes>, UnpackModeStrict) should have failed with ErrTrailingBytes (got %v)", err)
+	}
+
+	// EnumerationAsUint32 (at offset 0x0C) beyond the range of an XDR enum
+
+	src = append([]byte{}, goodParentStructPacked...)
+	src[0x0C] = 0x80
+
+	_, err = Unpack(src, &goodParentStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(<out-of-range Enumeration>) received unexpected error: %v", err)
+	}
+	_, err = UnpackWithOptions(src, &goodParentStructReturned, &UnpackOptions{Mode: UnpackModeStrict})
+	if !errors.Is(err, ErrInvalidEnumeration) {
+		t.Fatalf("UnpackWithOptions(<out-of-range Enumeration>, UnpackModeStrict) should have failed with ErrInvalidEnumeration (got %v)", err)
+	}
+}
+
+type NarrowIntegerStruct struct {
+	Int8                int8   `XDR_Name:"Integer"`
+	Int16               int16  `XDR_Name:"Integer"`
+	Uint8               uint8  `XDR_Name:"Unsigned Integer"`
+	Uint16              uint16 `XDR_Name:"Unsigned Integer"`
+	IntAsInteger        int    `XDR_Name:"Integer"`
+	IntAsHyperInteger   int    `XDR_Name:"Hyper Integer"`
+	UintAsUnsigned      uint   `XDR_Name:"Unsigned Integer"`
+	UintAsUnsignedHyper uint   `XDR_Name:"Unsigned Hyper Integer"`
+}
+
+func TestNarrowIntegers(t *testing.T) {
+	var (
+		err                         error
+		narrowIntegerStruct         NarrowIntegerStruct
+		narrowIntegerStructPacked   []byte
+		narrowIntegerStructReturned NarrowIntegerStruct
+		uint8Returned               uint8
+	)
+
+	narrowIntegerStruct = NarrowIntegerStruct{
+		Int8:                -100,
+		Int16:               -30000,
+		Uint8:               200,
+		Uint16:              60000,
+		IntAsInteger:        -2000000000,
+		IntAsHyperInteger:   -1 << 40,
+		UintAsUnsigned:      4000000000,
+		UintAsUnsignedHyper: 1 << 40,
+	}
+
+	narrowIntegerStructPacked, err = Pack(narrowIntegerStruct)
+	if nil != err {
+		t.Fatalf("Pack(narrowIntegerStruct) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(narrowIntegerStructPacked, []byte{
+		0xFF, 0xFF, 0xFF, 0x9C, //                         -100
+		0xFF, 0xFF, 0x8A, 0xD0, //                         -30000
+		0x00, 0x00, 0x00, 0xC8, //                         200
+		0x00, 0x00, 0xEA, 0x60, //                         60000
+		0x88, 0xCA, 0x6C, 0x00, //                         -2000000000
+		0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, // -1 << 40
+		0xEE, 0x6B, 0x28, 0x00, //                         4000000000
+		0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // 1 << 40
+	}) {
+		t.Fatalf("Pack(narrowIntegerStruct) returned unexpected narrowIntegerStructPacked: 0x%X", narrowIntegerStructPacked)
+	}
+
+	_, err = Unpack(narrowIntegerStructPacked, &narrowIntegerStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(narrowIntegerStructPacked, &narrowIntegerStructReturned) received unexpected error: %v", err)
+	}
+	if narrowIntegerStruct != narrowIntegerStructReturned {
+		t.Fatalf("Unpack(narrowIntegerStructPacked, &narrowIntegerStructReturned) received unexpected narrowIntegerStructReturned: %+v", narrowIntegerStructReturned)
+	}
+
+	_, err = Pack(NarrowIntegerStruct{IntAsInteger: 1 << 40})
+	if !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Pack(NarrowIntegerStruct{IntAsInteger: 1 << 40}) should have failed with ErrOverflow (got %v)", err)
+	}
+
+	_, err = Pack(NarrowIntegerStruct{UintAsUnsigned: 1 << 32})
+	if !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Pack(NarrowIntegerStruct{UintAsUnsigned: 1 << 32}) should have failed with ErrOverflow (got %v)", err)
+	}
+
+	_, err = Unpack([]byte{0x00, 0x00, 0x01, 0x00}, &uint8Returned)
+	if !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Unpack(<256>, &uint8Returned) should have failed with ErrOverflow (got %v)", err)
+	}
+}
+
+type InferredStruct struct {
+	Integer               int32                 `xdr:""`
+	UnsignedInteger       uint16                `xdr:""`
+	EnumerationAsInt32    int32                 `xdr:"enum"`
+	Boolean               bool                  `xdr:""`
+	HyperInteger          int                   `xdr:""`
+	UnsignedHyperInteger  uint64                `xdr:""`
+	FixedLengthOpaqueData [5]byte               `xdr:""`
+	OpaqueData            []byte                `xdr:""`
+	StringAsByteSlice     []byte                `xdr:"string,max=3"`
+	StringAsString        string                `xdr:""`
+	FixedLengthArray      [3]ArrayElementStruct `xdr:""`
+	VariableLengthArray   []ArrayElementStruct  `xdr:"max=2"`
+	Structure             ChildStruct           `xdr:""`
+	Skipped               map[string]int        `xdr:"-"`
+}
+
+type TaggedStruct struct {
+	Integer               int32                 `XDR_Name:"Integer"`
+	UnsignedInteger       uint16                `XDR_Name:"Unsigned Integer"`
+	EnumerationAsInt32    int32                 `XDR_Name:"Enumeration"`
+	Boolean               bool                  `XDR_Name:"Boolean"`
+	HyperInteger          int                   `XDR_Name:"Hyper Integer"`
+	UnsignedHyperInteger  uint64                `XDR_Name:"Unsigned Hyper Integer"`
+	FixedLengthOpaqueData [5]byte               `XDR_Name:"Fixed-Length Opaque Data"`
+	OpaqueData            []byte                `XDR_Name:"Variable-Length Opaque Data"`
+	StringAsByteSlice     []byte                `XDR_Name:"String" XDR_MaxSize:"3"`
+	StringAsString        string                `XDR_Name:"String"`
+	FixedLengthArray      [3]ArrayElementStruct `XDR_Name:"Fixed-Length Array"`
+	VariableLengthArray   []ArrayElementStruct  `XDR_Name:"Variable-Length Array" XDR_MaxSize:"2"`
+	Structure             ChildStruct           `XDR_Name:"Structure"`
+}
+
+type UntaggedStruct struct {
+	InferTags
+	Integer               int32
+	UnsignedInteger       uint16
+	EnumerationAsInt32    int32 `XDR_Name:"Enumeration"`
+	Boolean               bool
+	HyperInteger          int
+	UnsignedHyperInteger  uint64
+	FixedLengthOpaqueData [5]byte
+	OpaqueData            []byte
+	StringAsByteSlice     []byte `XDR_Name:"String" XDR_MaxSize:"3"`
+	StringAsString        string
+	FixedLengthArray      [3]ArrayElementStruct
+	VariableLengthArray   []ArrayElementStruct `XDR_MaxSize:"2"`
+	Structure             ChildStruct
+	Skipped               map[string]int `XDR_Name:"-"`
+}
+
+type inferringEmbeddedStruct struct {
+	InferTags
+}
+
+func TestInferredFields(t *testing.T) {
+	var (
+		err                    error
+		inferredStruct         InferredStruct
+		inferredStructPacked   []byte
+		inferredStructReturned InferredStruct
+		taggedStructPacked     []byte
+	)
+
+	inferredStruct = InferredStruct{
+		Integer:               -1000000,
+		UnsignedInteger:       1000,
+		EnumerationAsInt32:    -1000,
+		Boolean:               true,
+		HyperInteger:          -1000000000000,
+		UnsignedHyperInteger:  1000000000000,
+		FixedLengthOpaqueData: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+		OpaqueData:            []byte{0x01, 0x02, 0x03},
+		StringAsByteSlice:     []byte{'B', 'y', 'e'},
+		StringAsString:        "Hi",
+		FixedLengthArray:      [3]ArrayElementStruct{{BooleanInArrayElement: true}, {BooleanInArrayElement: false}, {BooleanInArrayElement: true}},
+		VariableLengthArray:   []ArrayElementStruct{{BooleanInArrayElement: true}},
+		Structure:             ChildStruct{BooleanInChild: true},
+		Skipped:               map[string]int{"Ignored": 1},
+	}
+
+	inferredStructPacked, err = Pack(inferredStruct)
+	if nil != err {
+		t.Fatalf("Pack(inferredStruct) received unexpected error: %v", err)
+	}
+
+	taggedStructPacked, err = Pack(TaggedStruct{
+		Integer:               inferredStruct.Integer,
+		UnsignedInteger:       inferredStruct.UnsignedInteger,
+		EnumerationAsInt32:    inferredStruct.EnumerationAsInt32,
+		Boolean:               inferredStruct.Boolean,
+		HyperInteger:          inferredStruct.HyperInteger,
+		UnsignedHyperInteger:  inferredStruct.UnsignedHyperInteger,
+		FixedLengthOpaqueData: inferredStruct.FixedLengthOpaqueData,
+		OpaqueData:            inferredStruct.OpaqueData,
+		StringAsByteSlice:     inferredStruct.StringAsByteSlice,
+		StringAsString:        inferredStruct.StringAsString,
+		FixedLengthArray:      inferredStruct.FixedLengthArray,
+		VariableLengthArray:   inferredStruct.VariableLengthArray,
+		Structure:             inferredStruct.Structure,
+	})
+	if nil != err {
+		t.Fatalf("Pack(TaggedStruct{}) received unexpected error: %v", err)
+	}
+
+	if 0 != bytes.Compare(taggedStructPacked, inferredStructPacked) {
+		t.Fatalf("Pack(inferredStruct) returned 0x%X - should have been 0x%X", inferredStructPacked, taggedStructPacked)
+	}
+
+	_, err = Unpack(inferredStructPacked, &inferredStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(inferredStructPacked, &inferredStructReturned) received unexpected error: %v", err)
+	}
+	inferredStruct.Skipped = nil
+	if !reflect.DeepEqual(inferredStruct, inferredStructReturned) {
+		t.Fatalf("Unpack(inferredStructPacked, &inferredStructReturned) received unexpected inferredStructReturned: %+v", inferredStructReturned)
+	}
+
+	_, err = Pack(struct {
+		Unsupported map[string]int `xdr:""`
+	}{})
+	if nil == err {
+		t.Fatalf("Pack(<map field>) should have failed")
+	}
+
+	// Verify XDR_Name is only inferred for a field that asks (so an untagged field remains an error)
+
+	_, err = Pack(struct{ Integer int32 }{})
+	if nil == err {
+		t.Fatalf("Pack(<untagged field>) should have failed")
+	}
+}
+
+func TestUntaggedFields(t *testing.T) {
+	var (
+		err                    error
+		taggedStructPacked     []byte
+		untaggedStruct         UntaggedStruct
+		untaggedStructPacked   []byte
+		untaggedStructReturned UntaggedStruct
+	)
+
+	untaggedStruct = UntaggedStruct{
+		Integer:               -1000000,
+		UnsignedInteger:       1000,
+		EnumerationAsInt32:    -1000,
+		Boolean:               true,
+		HyperInteger:          -1000000000000,
+		UnsignedHyperInteger:  1000000000000,
+		FixedLengthOpaqueData: [5]byte{0x01, 0x02, 0x03, 0x04, 0x05},
+		OpaqueData:            []byte{0x01, 0x02, 0x03},
+		StringAsByteSlice:     []byte{'B', 'y', 'e'},
+		StringAsString:        "Hi",
+		FixedLengthArray:      [3]ArrayElementStruct{{BooleanInArrayElement: true}, {BooleanInArrayElement: false}, {BooleanInArrayElement: true}},
+		VariableLengthArray:   []ArrayElementStruct{{BooleanInArrayElement: true}},
+		Structure:             ChildStruct{BooleanInChild: true},
+		Skipped:               map[string]int{"Ignored": 1},
+	}
+
+	untaggedStructPacked, err = Pack(untaggedStruct)
+	if nil != err {
+		t.Fatalf("Pack(untaggedStruct) received unexpected error: %v", err)
+	}
+
+	taggedStructPacked, err = Pack(TaggedStruct{
+		Integer:               untaggedStruct.Integer,
+		UnsignedInteger:       untaggedStruct.UnsignedInteger,
+		EnumerationAsInt32:    untaggedStruct.EnumerationAsInt32,
+		Boolean:               untaggedStruct.Boolean,
+		HyperInteger:          untaggedStruct.HyperInteger,
+		UnsignedHyperInteger:  untaggedStruct.UnsignedHyperInteger,
+		FixedLengthOpaqueData: untaggedStruct.FixedLengthOpaqueData,
+		OpaqueData:            untaggedStruct.OpaqueData,
+		StringAsByteSlice:     untaggedStruct.StringAsByteSlice,
+		StringAsString:        untaggedStruct.StringAsString,
+		FixedLengthArray:      untaggedStruct.FixedLengthArray,
+		VariableLengthArray:   untaggedStruct.VariableLengthArray,
+		Structure:             untaggedStruct.Structure,
+	})
+	if nil != err {
+		t.Fatalf("Pack(TaggedStruct{}) received unexpected error: %v", err)
+	}
+
+	if 0 != bytes.Compare(taggedStructPacked, untaggedStructPacked) {
+		t.Fatalf("Pack(untaggedStruct) returned 0x%X - should have been 0x%X", untaggedStructPacked, taggedStructPacked)
+	}
+
+	_, err = Unpack(untaggedStructPacked, &untaggedStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(untaggedStructPacked, &untaggedStructReturned) received unexpected error: %v", err)
+	}
+	untaggedStruct.Skipped = nil
+	if !reflect.DeepEqual(untaggedStruct, untaggedStructReturned) {
+		t.Fatalf("Unpack(untaggedStructPacked, &untaggedStructReturned) received unexpected untaggedStructReturned: %+v", untaggedStructReturned)
+	}
+
+	_, err = Pack(struct {
+		InferTags
+		Unsupported map[string]int
+	}{})
+	if nil == err {
+		t.Fatalf("Pack(<untagged map field>) should have failed")
+	}
+
+	// Verify InferTags also applies when embedded in a struct that is itself embedded (and flattened)
+
+	untaggedStructPacked, err = Pack(struct {
+		inferringEmbeddedStruct
+		Integer int32
+	}{Integer: -1})
+	if nil != err {
+		t.Fatalf("Pack(<untagged field beside embedded InferTags>) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare([]byte{0xFF, 0xFF, 0xFF, 0xFF}, untaggedStructPacked) {
+		t.Fatalf("Pack(<untagged field beside embedded InferTags>) returned 0x%X - should have been 0xFFFFFFFF", untaggedStructPacked)
+	}
+
+	// Verify the hint to request inference is only given where inference would succeed
+
+	_, err = Pack(struct{ Integer int32 }{})
+	if (nil == err) || !strings.Contains(err.Error(), "xdr.InferTags") {
+		t.Fatalf("Pack(<untagged int32 field>) should have failed suggesting xdr.InferTags (got %v)", err)
+	}
+
+	_, err = Pack(struct{ Float float64 }{})
+	if (nil == err) || strings.Contains(err.Error(), "xdr.InferTags") || strings.Contains(err.Error(), "`xdr:\"\"`") {
+		t.Fatalf("Pack(<untagged float64 field>) should have failed without suggesting inference (got %v)", err)
+	}
+}
+
+type EmbeddedStruct struct {
+	U32 uint32 `xdr:""`
+}
+
+type unexportedEmbeddedStruct struct {
+	U64 uint64 `xdr:""`
+}
+
+type EmbeddingStruct struct {
+	EmbeddedStruct
+	unexportedEmbeddedStruct
+	Named      EmbeddedStruct `XDR_Name:"Structure"`
+	unexported []int
+	Trailer    int32 `xdr:""`
+}
+
+func TestUnexportedAndEmbeddedFields(t *testing.T) {
+	var (
+		embeddingStruct         EmbeddingStruct
+		embeddingStructPacked   []byte
+		embeddingStructReturned EmbeddingStruct
+		err                     error
+	)
+
+	embeddingStruct = EmbeddingStruct{
+		EmbeddedStruct:           EmbeddedStruct{U32: 0x01020304},
+		unexportedEmbeddedStruct: unexportedEmbeddedStruct{U64: 0x05060708090A0B0C},
+		Named:                    EmbeddedStruct{U32: 0x0D0E0F10},
+		unexported:               []int{1, 2, 3},
+		Trailer:                  -1,
+	}
+
+	embeddingStructPacked, err = Pack(embeddingStruct)
+	if nil != err {
+		t.Fatalf("Pack(embeddingStruct) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(embeddingStructPacked, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10, 0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Fatalf("Pack(embeddingStruct) returned unexpected 0x%X", embeddingStructPacked)
+	}
+
+	_, err = Unpack(embeddingStructPacked, &embeddingStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(embeddingStructPacked, &embeddingStructReturned) received unexpected error: %v", err)
+	}
+	embeddingStruct.unexported = nil
+	if !reflect.DeepEqual(embeddingStruct, embeddingStructReturned) {
+		t.Fatalf("Unpack(embeddingStructPacked, &embeddingStructReturned) received unexpected embeddingStructReturned: %+v", embeddingStructReturned)
+	}
+
+	_, err = Pack(struct {
+		unexported uint32 `XDR_Name:"Unsigned Integer"`
+	}{})
+	if nil == err {
+		t.Fatalf("Pack(<tagged unexported field>) should have failed")
+	}
+
+	_, err = Pack(struct {
+		unexported uint32 `XDR_Name:"-"`
+	}{})
+	if nil != err {
+		t.Fatalf("Pack(<unexported field tagged XDR_Name:\"-\">) received unexpected error: %v", err)
+	}
+}
+
+type CycleNode struct {
+	Value uint32     `xdr:""`
+	Next  *CycleNode `xdr:"optional"`
+}
+
+type CycleTree struct {
+	Children []CycleTree `xdr:"array"`
+}
+
+func TestPackCyclesAndDepth(t *testing.T) {
+	var (
+		cycleNode        CycleNode
+		cycleNodeLoop    CycleNode
+		cycleTrees       []CycleTree
+		dst              []byte
+		err              error
+		listHead         *CycleNode
+		listNodeIndex    int
+		selfInterface    interface{}
+		selfPointer      SelfPointer
+		unpackedListHead CycleNode
+	)
+
+	// Verify a (long) acyclic list is packed & unpacked
+
+	for listNodeIndex = 0; listNodeIndex < 3*examineCycleDetectionDepth; listNodeIndex++ {
+		listHead = &CycleNode{Value: uint32(listNodeIndex), Next: listHead}
+	}
+
+	dst, err = Pack(listHead)
+	if (nil != err) || (uint64(len(dst)) != 8*3*examineCycleDetectionDepth) {
+		t.Fatalf("Pack(<acyclic list>) returned %d bytes or unexpected error: %v", len(dst), err)
+	}
+	err = UnpackExact(dst, &unpackedListHead)
+	if (nil != err) || !reflect.DeepEqual(*listHead, unpackedListHead) {
+		t.Fatalf("UnpackExact(Pack(<acyclic list>)) returned unexpected list or error: %v", err)
+	}
+
+	// Verify cycles (via a pointer or a slice) are detected rather than recursing without end
+
+	cycleNode.Next = &cycleNodeLoop
+	cycleNodeLoop.Next = &cycleNode
+
+	_, err = Pack(&cycleNode)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Pack(<cyclic list>) should have failed with ErrCycle (got %v)", err)
+	}
+	_, err = Examine(cycleNode)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Examine(<cyclic list>) should have failed with ErrCycle (got %v)", err)
+	}
+	_, err = Unpack(dst, &cycleNode)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Unpack(<into cyclic list>) should have failed with ErrCycle (got %v)", err)
+	}
+
+	cycleTrees = make([]CycleTree, 1)
+	cycleTrees[0].Children = cycleTrees
+
+	_, err = Pack(cycleTrees[0])
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Pack(<cyclic tree>) should have failed with ErrCycle (got %v)", err)
+	}
+
+	// Verify cycles through pointers or interfaces alone are detected as well
+
+	selfPointer = new(SelfPointer)
+	*selfPointer = selfPointer
+
+	_, err = Pack(selfPointer)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Pack(<pointer to itself>) should have failed with ErrCycle (got %v)", err)
+	}
+	_, err = Examine(selfPointer)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Examine(<pointer to itself>) should have failed with ErrCycle (got %v)", err)
+	}
+
+	selfInterface = &selfInterface
+
+	_, err = Pack(&selfInterface)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Pack(<interface holding a pointer to itself>) should have failed with ErrCycle (got %v)", err)
+	}
+
+	// Verify PackOptions.MaxDepth (where ParentStruct nests ArrayElementStruct's in arrays for a depth of 3)
+
+	_, err = PackWithOptions(goodParentStruct, &PackOptions{MaxDepth: 2})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("PackWithOptions(goodParentStruct, MaxDepth: 2) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+	_, err = ExamineWithOptions(goodParentStruct, &PackOptions{MaxDepth: 2})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("ExamineWithOptions(goodParentStruct, MaxDepth: 2) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+	dst, err = PackWithOptions(goodParentStruct, &PackOptions{MaxDepth: 3})
+	if (nil != err) || !bytes.Equal(goodParentStructPacked, dst) {
+		t.Fatalf("PackWithOptions(goodParentStruct, MaxDepth: 3) returned 0x%X or unexpected error: %v", dst, err)
+	}
+
+	// Verify a zero PackOptions.MaxDepth applies DefaultMaxDepth (where each list node nests a pointer & a struct)
+
+	for listNodeIndex = 3 * examineCycleDetectionDepth; listNodeIndex < DefaultMaxDepth; listNodeIndex++ {
+		listHead = &CycleNode{Value: uint32(listNodeIndex), Next: listHead}
+	}
+
+	_, err = Pack(listHead)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Pack(<list nested beyond DefaultMaxDepth>) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	var (
+		err           error
+		internalError *InternalError
+		malformedIF   interface{}
+		malformedIFs  []interface{}
+		packError     *PackError
+		u32           uint32
+	)
+
+	malformedIFs = []interface{}{
+		nil,
+		map[string]int{"a": 1},
+		func() {},
+		make(chan int),
+		struct {
+			P *uint32 `xdr:""`
+		}{},
+		struct{ I interface{} }{},
+		[]interface{}{nil},
+		struct{ I interface{} }{I: map[int]int{}},
+		struct{ I interface{} }{I: []interface{}{complex(1, 2)}},
+		UnionWithoutDefaultStruct{Discriminant: 9},
+	}
+
+	for _, malformedIF = range malformedIFs {
+		_, err = Examine(malformedIF)
+		if nil == err {
+			t.Fatalf("Examine(%#v) should have failed", malformedIF)
+		}
+		_, err = Pack(malformedIF)
+		if nil == err {
+			t.Fatalf("Pack(%#v) should have failed", malformedIF)
+		}
+	}
+
+	// Verify unusable destinations are reported as such (rather than as a recovered panic)
+
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, u32)
+	if !errors.Is(err, ErrInvalidDestination) || errors.As(err, &internalError) {
+		t.Fatalf("Unpack(src, <non-pointer>) should have failed with ErrInvalidDestination (got %v)", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, nil)
+	if !errors.Is(err, ErrInvalidDestination) || errors.As(err, &internalError) {
+		t.Fatalf("Unpack(src, nil) should have failed with ErrInvalidDestination (got %v)", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, (*uint32)(nil))
+	if !errors.Is(err, ErrInvalidDestination) || errors.As(err, &internalError) {
+		t.Fatalf("Unpack(src, <nil pointer>) should have failed with ErrInvalidDestination (got %v)", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, &struct{ I interface{} }{})
+	if !errors.Is(err, ErrInvalidDestination) || errors.As(err, &internalError) {
+		t.Fatalf("Unpack(src, <nil unregistered interface>) should have failed with ErrInvalidDestination (got %v)", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, &struct{ I interface{} }{I: u32})
+	if !errors.Is(err, ErrInvalidDestination) || errors.As(err, &internalError) {
+		t.Fatalf("Unpack(src, <unregistered interface holding a non-pointer>) should have failed with ErrInvalidDestination (got %v)", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x01}, &struct{ I interface{} }{I: &u32})
+	if (nil != err) || (1 != u32) {
+		t.Fatalf("Unpack(src, <unregistered interface holding a pointer>) set u32 to %v or received unexpected error: %v", u32, err)
+	}
+
+	_, err = packRecursive(reflect.ValueOf(ParentStruct{}), xdrTag{}, make([]byte, 8), 0)
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("packRecursive(<into too small dst>) returned unexpected error: %v", err)
+	}
+	if !errors.As(err, &packError) {
+		t.Fatalf("packRecursive(<into too small dst>) returned non-*PackError: %v", err)
+	}
+
+	err = func() (err error) {
+		defer recoverInternalError("Test", &err)
+		panic("Test panic")
+	}()
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("recoverInternalError() returned unexpected error: %v", err)
+	}
+	if !errors.As(err, &internalError) || ("Test panic" != internalError.Panic) {
+		t.Fatalf("recoverInternalError() returned unexpected *InternalError: %v", err)
+	}
+}
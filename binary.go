@@ -0,0 +1,96 @@
+package xdr
+
+import (
+	"encoding"
+	"io"
+)
+
+// Binary wraps a value of any type Pack() and Unpack() support such that it implements encoding.BinaryMarshaler,
+// encoding.BinaryUnmarshaler, io.WriterTo, and io.ReaderFrom (e.g. for storage in a cache or key-value store).
+//
+// As XDR is not self-delimiting, UnmarshalBinary() and ReadFrom() require their input to hold exactly one value. As
+// their input may be hostile, ReadFrom() reads at most MaxBinarySize bytes, and both decode with Limits bounding
+// MaxAllocation to four times MaxBinarySize (as a decoded value typically occupies more memory than its encoding).
+type Binary[T any] struct {
+	Value T
+}
+
+// MaxBinarySize is the most bytes Binary[T].ReadFrom() will read before failing (with ErrLimitExceeded).
+const MaxBinarySize = 64 << 20
+
+// binaryUnpackOptions directs the decoding performed by Binary[T].UnmarshalBinary() & Binary[T].ReadFrom().
+var binaryUnpackOptions = UnpackOptions{Limits: Limits{MaxAllocation: 4 * MaxBinarySize}}
+
+var (
+	_ encoding.BinaryMarshaler   = Binary[struct{}]{}
+	_ encoding.BinaryUnmarshaler = &Binary[struct{}]{}
+	_ io.WriterTo                = Binary[struct{}]{}
+	_ io.ReaderFrom              = &Binary[struct{}]{}
+)
+
+// MarshalBinary packs binary.Value.
+func (binary Binary[T]) MarshalBinary() (data []byte, err error) {
+	data, err = Pack(&binary.Value)
+	return
+}
+
+// UnmarshalBinary unpacks binary.Value from data (which is not retained), failing if data holds trailing bytes.
+func (binary *Binary[T]) UnmarshalBinary(data []byte) (err error) {
+	var (
+		bytesConsumed uint64
+	)
+
+	bytesConsumed, err = UnpackWithOptions(data, &binary.Value, &binaryUnpackOptions)
+	if nil != err {
+		return
+	}
+
+	if uint64(len(data)) > bytesConsumed {
+		err = newUnpackError(bytesConsumed, ErrTrailingBytes, "0x%X bytes remain in src []byte", uint64(len(data))-bytesConsumed)
+		return
+	}
+
+	return
+}
+
+// WriteTo packs binary.Value to w, returning the number of bytes written.
+func (binary Binary[T]) WriteTo(w io.Writer) (n int64, err error) {
+	var (
+		data    []byte
+		written int
+	)
+
+	data, err = Pack(&binary.Value)
+	if nil != err {
+		return
+	}
+
+	written, err = w.Write(data)
+	n = int64(written)
+
+	return
+}
+
+// ReadFrom reads r until EOF and unpacks binary.Value from what was read, returning the number of bytes read.
+//
+// Should r hold more than MaxBinarySize bytes, ReadFrom() fails (with ErrLimitExceeded) having read one more.
+func (binary *Binary[T]) ReadFrom(r io.Reader) (n int64, err error) {
+	var (
+		data []byte
+	)
+
+	data, err = io.ReadAll(io.LimitReader(r, MaxBinarySize+1))
+	n = int64(len(data))
+	if nil != err {
+		return
+	}
+
+	if MaxBinarySize < n {
+		err = newUnpackError(MaxBinarySize, ErrLimitExceeded, "r holds more than MaxBinarySize (%v) bytes", MaxBinarySize)
+		return
+	}
+
+	err = binary.UnmarshalBinary(data)
+
+	return
+}
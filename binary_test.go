@@ -0,0 +1,102 @@
+package xdr
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// zeroReader is an io.Reader yielding an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (n int, err error) {
+	for n = range p {
+		p[n] = 0
+	}
+	n = len(p)
+	return
+}
+
+func TestBinary(t *testing.T) {
+	var (
+		binary         Binary[ParentStruct]
+		binaryReturned Binary[ParentStruct]
+		buf            bytes.Buffer
+		data           []byte
+		err            error
+		n              int64
+	)
+
+	binary = Binary[ParentStruct]{Value: goodParentStruct}
+
+	data, err = binary.MarshalBinary()
+	if nil != err {
+		t.Fatalf("binary.MarshalBinary() received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(data, goodParentStructPacked) {
+		t.Fatalf("binary.MarshalBinary() returned unexpected 0x%X", data)
+	}
+
+	err = binaryReturned.UnmarshalBinary(data)
+	if nil != err {
+		t.Fatalf("binaryReturned.UnmarshalBinary() received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(binary, binaryReturned) {
+		t.Fatalf("binaryReturned.UnmarshalBinary() returned unexpected binaryReturned: %+v", binaryReturned)
+	}
+
+	err = binaryReturned.UnmarshalBinary(append(data, 0x00, 0x00, 0x00, 0x00))
+	if !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("binaryReturned.UnmarshalBinary(<trailing bytes>) returned unexpected error: %v", err)
+	}
+
+	n, err = binary.WriteTo(&buf)
+	if nil != err {
+		t.Fatalf("binary.WriteTo() received unexpected error: %v", err)
+	}
+	if int64(len(goodParentStructPacked)) != n {
+		t.Fatalf("binary.WriteTo() returned unexpected n: %v", n)
+	}
+
+	binaryReturned = Binary[ParentStruct]{}
+	n, err = binaryReturned.ReadFrom(&buf)
+	if nil != err {
+		t.Fatalf("binaryReturned.ReadFrom() received unexpected error: %v", err)
+	}
+	if int64(len(goodParentStructPacked)) != n {
+		t.Fatalf("binaryReturned.ReadFrom() returned unexpected n: %v", n)
+	}
+	if !reflect.DeepEqual(binary, binaryReturned) {
+		t.Fatalf("binaryReturned.ReadFrom() returned unexpected binaryReturned: %+v", binaryReturned)
+	}
+
+	_, err = binaryReturned.ReadFrom(bytes.NewReader(goodParentStructPacked[:8]))
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("binaryReturned.ReadFrom(<truncated>) returned unexpected error: %v", err)
+	}
+
+	// Verify ReadFrom() stops reading an endless r (rather than consuming all memory)
+
+	n, err = binaryReturned.ReadFrom(zeroReader{})
+	if !errors.Is(err, ErrLimitExceeded) || ((MaxBinarySize + 1) != n) {
+		t.Fatalf("binaryReturned.ReadFrom(<endless>) returned n == %v or unexpected error: %v", n, err)
+	}
+
+	// Verify encoding/gob defers to MarshalBinary() & UnmarshalBinary()
+
+	buf.Reset()
+	err = gob.NewEncoder(&buf).Encode(binary)
+	if nil != err {
+		t.Fatalf("gob.Encode(binary) received unexpected error: %v", err)
+	}
+	binaryReturned = Binary[ParentStruct]{}
+	err = gob.NewDecoder(&buf).Decode(&binaryReturned)
+	if nil != err {
+		t.Fatalf("gob.Decode(&binaryReturned) received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(binary, binaryReturned) {
+		t.Fatalf("gob.Decode(&binaryReturned) returned unexpected binaryReturned: %+v", binaryReturned)
+	}
+}
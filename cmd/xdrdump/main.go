@@ -0,0 +1,151 @@
+// Command xdrdump prints an annotated hex dump of XDR-encoded data (see xdr.Dump()).
+//
+// The data is decoded against either a type defined in an RFC 4506 (.x) specification:
+//
+//	xdrdump -x file.x -type Name [file]
+//
+// or a Go type declared in the named Go source files (all of which must belong to the same package):
+//
+//	xdrdump -go file.go[,file.go...] -type Name [file]
+//
+// If file is omitted (or is "-"), the data is read from standard input.
+//
+// Alternatively, with -diff, the values that differ between two files of data (e.g. captured before and after a
+// regression) are printed one per line (see xdr.Diff()) rather than dumped:
+//
+//	xdrdump -x file.x -type Name -diff old new
+//
+// As with diff(1), the exit status is then 0 if no values differ, 1 if some do, and 2 upon trouble.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/swiftstack/xdr"
+	"github.com/swiftstack/xdr/internal/xdrtype"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run implements xdrdump given its command line arguments (excluding the program name), returning its exit status.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (exitStatus int) {
+	var (
+		diffMode  bool
+		err       error
+		flagSet   *flag.FlagSet
+		src       []byte
+		srcPath   string
+		typ       interface{}
+		typeFlags *xdrtype.Flags
+	)
+
+	flagSet = flag.NewFlagSet("xdrdump", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	typeFlags = xdrtype.AddFlags(flagSet)
+	flagSet.BoolVar(&diffMode, "diff", false, "print the values that differ between files old and new")
+	flagSet.Usage = func() {
+		fmt.Fprintf(stderr, "usage: xdrdump %s [file]\n", xdrtype.Synopsis)
+		fmt.Fprintf(stderr, "       xdrdump %s -diff old new\n", xdrtype.Synopsis)
+		flagSet.PrintDefaults()
+	}
+
+	err = flagSet.Parse(args)
+	if nil != err {
+		exitStatus = 2
+		return
+	}
+
+	if !typeFlags.IsValid() || (!diffMode && (1 < flagSet.NArg())) || (diffMode && (2 != flagSet.NArg())) {
+		flagSet.Usage()
+		exitStatus = 2
+		return
+	}
+
+	typ, err = typeFlags.Type()
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+		if diffMode {
+			exitStatus = 2 // Note: As with diff(1), exit status 1 reports differences
+		} else {
+			exitStatus = 1
+		}
+		return
+	}
+
+	if diffMode {
+		exitStatus = runDiff(flagSet.Arg(0), flagSet.Arg(1), typ, stdout, stderr)
+		return
+	}
+
+	srcPath = flagSet.Arg(0)
+	if ("" == srcPath) || ("-" == srcPath) {
+		src, err = io.ReadAll(stdin)
+	} else {
+		src, err = os.ReadFile(srcPath)
+	}
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+		exitStatus = 1
+		return
+	}
+
+	_, err = io.WriteString(stdout, xdr.Dump(src, typ))
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+		exitStatus = 1
+		return
+	}
+
+	return
+}
+
+// runDiff implements xdrdump -diff, returning its exit status.
+func runDiff(oldPath string, newPath string, typ interface{}, stdout io.Writer, stderr io.Writer) (exitStatus int) {
+	var (
+		diffs     []xdr.FieldDiff
+		err       error
+		fieldDiff xdr.FieldDiff
+		newSrc    []byte
+		oldSrc    []byte
+	)
+
+	oldSrc, err = os.ReadFile(oldPath)
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+		exitStatus = 2
+		return
+	}
+	newSrc, err = os.ReadFile(newPath)
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+		exitStatus = 2
+		return
+	}
+
+	diffs, err = xdr.Diff(oldSrc, newSrc, typ)
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+		exitStatus = 2
+		return
+	}
+
+	for _, fieldDiff = range diffs {
+		_, err = fmt.Fprintln(stdout, fieldDiff.String())
+		if nil != err {
+			fmt.Fprintf(stderr, "xdrdump: %v\n", err)
+			exitStatus = 2
+			return
+		}
+	}
+
+	if 0 < len(diffs) {
+		exitStatus = 1
+	}
+
+	return
+}
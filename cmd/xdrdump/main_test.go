@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXText = `
+const NAME_MAX = 8;
+
+enum color { RED = 1, GREEN = 2 };
+
+struct pixel {
+	color  hue;
+	string name<NAME_MAX>;
+};
+
+struct chain {
+	unsigned int value;
+	chain        *next;
+};
+`
+
+const testGoText = `package sample
+
+type Color int32
+
+type Base struct {
+	Hue Color ` + "`xdr:\"\"`" + `
+}
+
+type Pixel struct {
+	Base
+	Name    string ` + "`xdr:\"string,max=8\"`" + `
+	private uint64
+}
+
+type Recursive struct {
+	Next *Recursive ` + "`xdr:\"optional\"`" + `
+}
+`
+
+// testPixelPacked is a pixel (equivalently, a Pixel) with hue GREEN and name "ab"
+var testPixelPacked = []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 'a', 'b', 0x00, 0x00}
+
+func TestRun(t *testing.T) {
+	var (
+		dataPath   string
+		dir        string
+		err        error
+		exitStatus int
+		goPath     string
+		stderr     bytes.Buffer
+		stdout     bytes.Buffer
+		xPath      string
+	)
+
+	dir = t.TempDir()
+	dataPath = filepath.Join(dir, "pixel.bin")
+	goPath = filepath.Join(dir, "sample.go")
+	xPath = filepath.Join(dir, "sample.x")
+
+	err = os.WriteFile(dataPath, testPixelPacked, 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", dataPath, err)
+	}
+	err = os.WriteFile(goPath, []byte(testGoText), 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", goPath, err)
+	}
+	err = os.WriteFile(xPath, []byte(testXText), 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", xPath, err)
+	}
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", dataPath}, nil, &stdout, &stderr)
+	if (0 != exitStatus) || !strings.Contains(stdout.String(), "hue: color = GREEN (2)") || !strings.Contains(stdout.String(), "name: string<8> = \"ab\" (+2 padding)") {
+		t.Fatalf("run(-x) returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+
+	exitStatus = run([]string{"-go", goPath, "-type", "Pixel"}, bytes.NewReader(testPixelPacked[:10]), &stdout, &stderr)
+	if (0 != exitStatus) || !strings.Contains(stdout.String(), "Hue: int = 2") || !strings.Contains(stdout.String(), "!! ") || strings.Contains(stdout.String(), "private") {
+		t.Fatalf("run(-go) returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+
+	exitStatus = run([]string{"-go", goPath, "-type", "Recursive"}, nil, &stdout, &stderr)
+	if (1 != exitStatus) || !strings.Contains(stderr.String(), "recursive type Recursive") {
+		t.Fatalf("run(-go <recursive type>) returned %d with unexpected stderr:\n%s", exitStatus, stderr.String())
+	}
+
+	stderr.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "missing"}, nil, &stdout, &stderr)
+	if (1 != exitStatus) || !strings.Contains(stderr.String(), "does not define type missing") {
+		t.Fatalf("run(-x <missing type>) returned %d with unexpected stderr:\n%s", exitStatus, stderr.String())
+	}
+
+	stderr.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-go", goPath, "-type", "pixel"}, nil, &stdout, &stderr)
+	if 2 != exitStatus {
+		t.Fatalf("run(-x & -go) returned %d (expected 2) with stderr:\n%s", exitStatus, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+
+	// Verify deeply nested input yields a partial dump (rather than exhausting the stack)
+
+	exitStatus = run([]string{"-x", xPath, "-type", "chain"}, bytes.NewReader(bytes.Repeat([]byte{0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x01}, 1<<18)), &stdout, &stderr)
+	if (0 != exitStatus) || !strings.Contains(stdout.String(), "!! nesting depth exceeds") || !strings.Contains(stdout.String(), "(undecoded bytes)") {
+		t.Fatalf("run(<deeply nested>) returned %d with stderr:\n%s", exitStatus, stderr.String())
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	var (
+		dataPath      string
+		dir           string
+		err           error
+		exitStatus    int
+		otherDataPath string
+		stderr        bytes.Buffer
+		stdout        bytes.Buffer
+		xPath         string
+	)
+
+	dir = t.TempDir()
+	dataPath = filepath.Join(dir, "pixel.bin")
+	otherDataPath = filepath.Join(dir, "other.bin")
+	xPath = filepath.Join(dir, "sample.x")
+
+	err = os.WriteFile(dataPath, testPixelPacked, 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", dataPath, err)
+	}
+	err = os.WriteFile(otherDataPath, []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 'a', 'b', 0x00, 0x00}, 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", otherDataPath, err)
+	}
+	err = os.WriteFile(xPath, []byte(testXText), 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", xPath, err)
+	}
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-diff", dataPath, dataPath}, nil, &stdout, &stderr)
+	if (0 != exitStatus) || (0 != stdout.Len()) {
+		t.Fatalf("run(-diff <same file>) returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-diff", dataPath, otherDataPath}, nil, &stdout, &stderr)
+	if (1 != exitStatus) || ("$.hue @0x0/0x0: GREEN (2) -> RED (1)\n" != stdout.String()) {
+		t.Fatalf("run(-diff) returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-diff", dataPath, filepath.Join(dir, "missing.bin")}, nil, &stdout, &stderr)
+	if (2 != exitStatus) || !strings.Contains(stderr.String(), "missing.bin") {
+		t.Fatalf("run(-diff <missing file>) returned %d with unexpected stderr:\n%s", exitStatus, stderr.String())
+	}
+
+	stderr.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-diff", dataPath}, nil, &stdout, &stderr)
+	if 2 != exitStatus {
+		t.Fatalf("run(-diff <one file>) returned %d (expected 2) with stderr:\n%s", exitStatus, stderr.String())
+	}
+}
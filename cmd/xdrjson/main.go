@@ -0,0 +1,108 @@
+// Command xdrjson converts an XDR-encoded value to its canonical JSON form (see xdr.ToJSON()) or, given -r, the
+// reverse (see xdr.FromJSON()).
+//
+// The value's type is either defined in an RFC 4506 (.x) specification or declared in Go source:
+//
+//	xdrjson -x file.x -type Name [-r] [-indent] [file]
+//	xdrjson -go file.go[,file.go...] -type Name [-r] [-indent] [file]
+//
+// If file is omitted (or is "-"), the input is read from standard input. The output is written to standard output.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/swiftstack/xdr"
+	"github.com/swiftstack/xdr/internal/xdrtype"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run implements xdrjson given its command line arguments (excluding the program name), returning its exit status.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (exitStatus int) {
+	var (
+		err            error
+		flagSet        *flag.FlagSet
+		indent         bool
+		indentedOutput bytes.Buffer
+		input          []byte
+		inputPath      string
+		output         []byte
+		reverse        bool
+		typ            interface{}
+		typeFlags      *xdrtype.Flags
+	)
+
+	flagSet = flag.NewFlagSet("xdrjson", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	typeFlags = xdrtype.AddFlags(flagSet)
+	flagSet.BoolVar(&reverse, "r", false, "convert JSON to XDR (rather than XDR to JSON)")
+	flagSet.BoolVar(&indent, "indent", false, "indent the JSON output")
+	flagSet.Usage = func() {
+		fmt.Fprintf(stderr, "usage: xdrjson %s [-r] [-indent] [file]\n", xdrtype.Synopsis)
+		flagSet.PrintDefaults()
+	}
+
+	err = flagSet.Parse(args)
+	if nil != err {
+		exitStatus = 2
+		return
+	}
+
+	if !typeFlags.IsValid() || (1 < flagSet.NArg()) || (reverse && indent) {
+		flagSet.Usage()
+		exitStatus = 2
+		return
+	}
+
+	typ, err = typeFlags.Type()
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrjson: %v\n", err)
+		exitStatus = 1
+		return
+	}
+
+	inputPath = flagSet.Arg(0)
+	if ("" == inputPath) || ("-" == inputPath) {
+		input, err = io.ReadAll(stdin)
+	} else {
+		input, err = os.ReadFile(inputPath)
+	}
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrjson: %v\n", err)
+		exitStatus = 1
+		return
+	}
+
+	if reverse {
+		output, err = xdr.FromJSON(input, typ)
+	} else {
+		output, err = xdr.ToJSON(input, typ)
+		if (nil == err) && indent {
+			err = json.Indent(&indentedOutput, output, "", "  ")
+			output = indentedOutput.Bytes()
+		}
+		output = append(output, '\n')
+	}
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrjson: %v\n", err)
+		exitStatus = 1
+		return
+	}
+
+	_, err = stdout.Write(output)
+	if nil != err {
+		fmt.Fprintf(stderr, "xdrjson: %v\n", err)
+		exitStatus = 1
+		return
+	}
+
+	return
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXText = `
+enum color { RED = 1, GREEN = 2 };
+
+struct pixel {
+	color  hue;
+	hyper  id;
+	string name<8>;
+};
+`
+
+// testPixelPacked is a pixel with hue GREEN, id -1, and name "ab"
+var testPixelPacked = []byte{
+	0x00, 0x00, 0x00, 0x02,
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	0x00, 0x00, 0x00, 0x02, 'a', 'b', 0x00, 0x00,
+}
+
+const testPixelJSON = `{"hue":"GREEN","id":"-1","name":"ab"}`
+
+func TestRun(t *testing.T) {
+	var (
+		err        error
+		exitStatus int
+		stderr     bytes.Buffer
+		stdout     bytes.Buffer
+		xPath      string
+	)
+
+	xPath = filepath.Join(t.TempDir(), "sample.x")
+
+	err = os.WriteFile(xPath, []byte(testXText), 0644)
+	if nil != err {
+		t.Fatalf("os.WriteFile(%s) received unexpected error: %v", xPath, err)
+	}
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel"}, bytes.NewReader(testPixelPacked), &stdout, &stderr)
+	if (0 != exitStatus) || (testPixelJSON+"\n" != stdout.String()) {
+		t.Fatalf("run() returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-indent"}, bytes.NewReader(testPixelPacked), &stdout, &stderr)
+	if (0 != exitStatus) || !strings.Contains(stdout.String(), "\n  \"hue\": \"GREEN\",\n") {
+		t.Fatalf("run(-indent) returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-r"}, strings.NewReader(testPixelJSON), &stdout, &stderr)
+	if (0 != exitStatus) || !bytes.Equal(testPixelPacked, stdout.Bytes()) {
+		t.Fatalf("run(-r) returned %d with unexpected stdout 0x%X and stderr:\n%s", exitStatus, stdout.Bytes(), stderr.String())
+	}
+
+	stdout.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel"}, bytes.NewReader(testPixelPacked[:6]), &stdout, &stderr)
+	if (1 != exitStatus) || (0 != stdout.Len()) || !strings.Contains(stderr.String(), "xdrjson: ") {
+		t.Fatalf("run(<truncated>) returned %d with unexpected stdout:\n%s\nstderr:\n%s", exitStatus, stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+
+	exitStatus = run([]string{"-x", xPath, "-type", "pixel", "-r", "-indent"}, nil, &stdout, &stderr)
+	if 2 != exitStatus {
+		t.Fatalf("run(-r -indent) returned %d (expected 2) with stderr:\n%s", exitStatus, stderr.String())
+	}
+}
@@ -0,0 +1,125 @@
+package xdr
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// FieldDiff describes a value found by Diff() to differ between two encodings of the same type.
+//
+// Old and New hold decoded values (e.g. an int64 for an int or enum, a []byte for opaque data, or a string), except
+// that an Optional-Data's presence is a bool, a Variable-Length Array's length is a uint64, and a Discriminated
+// Union's discriminant is an int64 (reported at the path of the discriminant).
+type FieldDiff struct {
+	Path      string  // Path of the value (as accepted by View.Lookup(), e.g. "$.Entries[1].Name")
+	Type      *Schema // Schema of the value
+	OldOffset uint64  // Offset of the value in the old encoding
+	NewOffset uint64  // Offset of the value in the new encoding
+	Old       interface{}
+	New       interface{}
+}
+
+// Diff decodes oldSrc and newSrc in lockstep as directed by typ (which, as for Dump(), may be a *Schema, a
+// reflect.Type, or a value of the Go type both are expected to encode) and returns the values that differ.
+//
+// Where a Variable-Length Array differs in length, its length is reported and only the elements common to both
+// are compared. Where an Optional-Data differs in presence, or a Discriminated Union in discriminant, only that
+// is reported. Decoding tolerates what Dump() does (e.g. non-zero padding), and any bytes following the decoded
+// values are ignored (as by Unpack()), but err is returned if either cannot be decoded.
+func Diff(oldSrc []byte, newSrc []byte, typ interface{}) (diffs []FieldDiff, err error) {
+	var (
+		newRoot *schemaNode
+		oldRoot *schemaNode
+		schema  *Schema
+	)
+
+	defer recoverInternalError("Diff", &err)
+
+	schema, err = schemaOfType(typ)
+	if nil != err {
+		err = fmt.Errorf("Diff() passed unusable typ: %v", err)
+		return
+	}
+
+	oldRoot, err = decodeSchema(oldSrc, schema, DefaultMaxDepth)
+	if nil != err {
+		err = fmt.Errorf("Diff() failed decoding oldSrc: %w", err)
+		return
+	}
+	newRoot, err = decodeSchema(newSrc, schema, DefaultMaxDepth)
+	if nil != err {
+		err = fmt.Errorf("Diff() failed decoding newSrc: %w", err)
+		return
+	}
+
+	diffs = diffSchemaNodes(diffs, "$", oldRoot, newRoot)
+
+	return
+}
+
+// String returns a description of fieldDiff (e.g. `$.Name @0x8/0x8: "ab" -> "abc"`) suitable for a report.
+func (fieldDiff FieldDiff) String() (s string) {
+	s = fmt.Sprintf("%s @0x%X/0x%X: %s -> %s", fieldDiff.Path, fieldDiff.OldOffset, fieldDiff.NewOffset, fieldDiff.formatValue(fieldDiff.Old), fieldDiff.formatValue(fieldDiff.New))
+	return
+}
+
+func (fieldDiff FieldDiff) formatValue(value interface{}) (s string) {
+	switch fieldDiff.Type.Kind {
+	case SchemaArray:
+		s = fmt.Sprintf("length %v", value)
+	case SchemaOptional:
+		if value.(bool) {
+			s = "present"
+		} else {
+			s = "absent"
+		}
+	default:
+		s = formatSchemaValue(fieldDiff.Type, value)
+	}
+
+	return
+}
+
+// diffSchemaNodes appends to diffsIn those values at or below path that differ between oldNode and newNode.
+func diffSchemaNodes(diffsIn []FieldDiff, path string, oldNode *schemaNode, newNode *schemaNode) (diffs []FieldDiff) {
+	var (
+		childIndex int
+		childPath  string
+		equal      bool
+	)
+
+	diffs = diffsIn
+
+	switch oldNode.schema.Kind {
+	case SchemaVoid, SchemaStruct, SchemaFixedArray:
+		equal = true
+	case SchemaFixedOpaque, SchemaOpaque:
+		equal = bytes.Equal(oldNode.value.([]byte), newNode.value.([]byte))
+	default:
+		equal = reflect.DeepEqual(oldNode.value, newNode.value)
+	}
+
+	if !equal {
+		if SchemaUnion == oldNode.schema.Kind {
+			diffs = append(diffs, FieldDiff{Path: path + "." + oldNode.schema.Discriminant.Name, Type: oldNode.schema.Discriminant.Type, OldOffset: oldNode.offset, NewOffset: newNode.offset, Old: oldNode.value, New: newNode.value})
+			return // Note: Differing arms are not comparable
+		}
+		diffs = append(diffs, FieldDiff{Path: path, Type: oldNode.schema, OldOffset: oldNode.offset, NewOffset: newNode.offset, Old: oldNode.value, New: newNode.value})
+		if SchemaOptional == oldNode.schema.Kind {
+			return // Note: Only one of oldNode & newNode has a referent
+		}
+	}
+
+	for childIndex = 0; (childIndex < len(oldNode.children)) && (childIndex < len(newNode.children)); childIndex++ {
+		switch oldNode.schema.Kind {
+		case SchemaFixedArray, SchemaArray, SchemaOptional:
+			childPath = path + oldNode.children[childIndex].name // Note: "[<index>]" or "*"
+		default:
+			childPath = path + "." + oldNode.children[childIndex].name
+		}
+		diffs = diffSchemaNodes(diffs, childPath, oldNode.children[childIndex], newNode.children[childIndex])
+	}
+
+	return
+}
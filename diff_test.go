@@ -0,0 +1,88 @@
+package xdr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var (
+		diffStrings []string
+		diffs       []FieldDiff
+		err         error
+		fieldDiff   FieldDiff
+		i32         int32
+		newListing  ViewListing
+		newPacked   []byte
+		oldListing  ViewListing
+		oldPacked   []byte
+		s           string
+		u32         uint32
+	)
+
+	u32 = 9
+
+	oldListing = ViewListing{Count: 2, Entries: []ViewEntry{{Size: 1, Name: "a", Tags: []uint32{1}}, {Size: 2, Name: "b"}}}
+	newListing = ViewListing{Count: 2, Entries: []ViewEntry{{Size: 1, Name: "a", Tags: []uint32{1}}, {Size: 3, Name: "bb"}, {}}, Trailer: &u32}
+
+	oldPacked = mustPack(t, oldListing)
+	newPacked = mustPack(t, newListing)
+
+	// Verify identical encodings yield no differences
+
+	diffs, err = Diff(oldPacked, oldPacked, ViewListing{})
+	if (nil != err) || (0 != len(diffs)) {
+		t.Fatalf("Diff(oldPacked, oldPacked) returned %v or unexpected error: %v", diffs, err)
+	}
+
+	// Verify each differing value is reported (with its path, offsets, and values) in the order encoded
+
+	diffs, err = Diff(oldPacked, newPacked, &ViewListing{})
+	if nil != err {
+		t.Fatalf("Diff(oldPacked, newPacked) received unexpected error: %v", err)
+	}
+
+	for _, fieldDiff = range diffs {
+		diffStrings = append(diffStrings, fieldDiff.String())
+	}
+
+	if !reflect.DeepEqual(diffStrings, []string{
+		"$.Entries @0x4/0x4: length 2 -> length 3",
+		"$.Entries[1].Size @0x20/0x20: 2 -> 3",
+		"$.Entries[1].Name @0x28/0x28: \"b\" -> \"bb\"",
+		"$.Trailer @0x34/0x44: absent -> present",
+	}) {
+		t.Fatalf("Diff(oldPacked, newPacked) returned unexpected diffs:\n%q", diffStrings)
+	}
+
+	if (uint64(2) != diffs[1].Old) || (uint64(3) != diffs[1].New) || (SchemaUhyper != diffs[1].Type.Kind) {
+		t.Fatalf("Diff(oldPacked, newPacked) returned unexpected diffs[1]: %+v", diffs[1])
+	}
+
+	s, err = NewView(newPacked, ViewListing{}).Lookup(diffs[2].Path).String()
+	if (nil != err) || ("bb" != s) || (diffs[2].NewOffset != NewView(newPacked, ViewListing{}).Lookup(diffs[2].Path).Offset()) {
+		t.Fatalf("View.Lookup(diffs[2].Path) returned %q or unexpected error: %v", s, err)
+	}
+
+	// Verify a union discriminant change is reported (rather than its incomparable arms)
+
+	i32 = 1
+	s = "abc"
+
+	diffs, err = Diff(mustPack(t, UnionStruct{Discriminant: 1, Integer: &i32}), mustPack(t, UnionStruct{Discriminant: 2, String: &s}), reflect.TypeOf(UnionStruct{}))
+	if (nil != err) || (1 != len(diffs)) || ("$.Discriminant @0x0/0x0: 1 -> 2" != diffs[0].String()) {
+		t.Fatalf("Diff(<differing discriminants>) returned %v or unexpected error: %v", diffs, err)
+	}
+
+	// Verify undecodable encodings & types are reported
+
+	_, err = Diff(oldPacked, newPacked[:len(newPacked)-1], ViewListing{})
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Diff(oldPacked, <truncated>) returned unexpected error: %v", err)
+	}
+	_, err = Diff(oldPacked, newPacked, nil)
+	if nil == err {
+		t.Fatalf("Diff(..., nil) should have failed")
+	}
+}
@@ -0,0 +1,216 @@
+package xdr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	dumpBytesPerLine = 8
+	dumpIndent       = "  "
+	dumpMaxDepth     = 256 // Bounds the nesting (and so the indentation) of the values dumped
+)
+
+// Dump returns an annotated hex dump of src decoded as directed by typ, which may be a *Schema (e.g. as returned by
+// ParseSchema()), a reflect.Type, or a value (or pointer to a value) of the Go type src is expected to encode.
+//
+// Each line gives the offset and raw bytes (including any padding) of a field followed by its name, type, and
+// decoded value. Problems that need not stop decoding (e.g. non-zero padding) are annotated with "!" and decoding
+// continues, while one that must (e.g. truncation) is annotated with "!!" and the remaining bytes are dumped
+// undecoded. Any trailing bytes are likewise dumped following the decoded value. Decoding also stops (with
+// ErrLimitExceeded) upon nesting deeper than 256 arrays, structs, unions, and Optional-Data.
+func Dump(src []byte, typ interface{}) (dump string) {
+	var (
+		builder     strings.Builder
+		err         error
+		root        *schemaNode
+		schema      *Schema
+		stopOffset  uint64
+		unpackError *UnpackError
+	)
+
+	defer func() {
+		var (
+			panicValue interface{}
+		)
+
+		panicValue = recover()
+		if nil != panicValue {
+			dump = builder.String() + fmt.Sprintf("!! %v\n", &InternalError{Op: "Dump", Panic: panicValue})
+		}
+	}()
+
+	schema, err = schemaOfType(typ)
+	if nil != err {
+		dump = fmt.Sprintf("!! %v\n", err)
+		return
+	}
+
+	root, err = decodeSchema(src, schema, dumpMaxDepth)
+
+	builder.WriteString(fmt.Sprintf("%-8s  %-*s  %s\n", "Offset", dumpBytesPerLine*3-1, "Bytes", "Field: Type = Value"))
+
+	stopOffset = dumpSchemaNode(&builder, src, root, 0)
+
+	if nil == err {
+		if uint64(len(src)) > root.size {
+			dumpLines(&builder, src, root.size, uint64(len(src)), "(trailing bytes)")
+		}
+	} else {
+		if errors.As(err, &unpackError) && (unpackError.Offset > stopOffset) {
+			stopOffset = unpackError.Offset
+		}
+		if uint64(len(src)) > stopOffset {
+			dumpLines(&builder, src, stopOffset, uint64(len(src)), "(undecoded bytes)")
+		}
+	}
+
+	dump = builder.String()
+
+	return
+}
+
+// dumpSchemaNode appends the lines describing node (and its children) to builder.
+//
+// If decoding stopped within node, the offset in src of the first byte not yet dumped is returned.
+func dumpSchemaNode(builder *strings.Builder, src []byte, node *schemaNode, depth int) (stopOffset uint64) {
+	var (
+		child            *schemaNode
+		description      string
+		endOffset        uint64
+		isInnermostError bool
+		note             *UnpackError
+		unpackError      *UnpackError
+	)
+
+	description = strings.Repeat(dumpIndent, depth)
+	if "" != node.name {
+		description += node.name + ": "
+	}
+	description += node.schema.TypeName()
+
+	switch node.schema.Kind {
+	case SchemaVoid, SchemaStruct, SchemaFixedArray:
+		// Nothing to add
+	case SchemaArray:
+		if nil != node.value {
+			description += fmt.Sprintf(" length %d", node.value)
+		}
+	case SchemaUnion:
+		if nil != node.value {
+			description += fmt.Sprintf(" switch (%s = %s)", node.schema.Discriminant.Name, formatSchemaValue(node.schema.Discriminant.Type, node.value))
+		}
+	case SchemaOptional:
+		if nil != node.value {
+			if node.value.(bool) {
+				description += " = present"
+			} else {
+				description += " = absent"
+			}
+		}
+	default:
+		if nil != node.value {
+			description += " = " + formatSchemaValue(node.schema, node.value)
+		}
+	}
+
+	if 0 < node.padding {
+		description += fmt.Sprintf(" (+%d padding)", node.padding)
+	}
+
+	for _, note = range node.notes {
+		description += "  ! " + note.Detail
+	}
+
+	isInnermostError = (nil != node.err) && ((0 == len(node.children)) || (nil == node.children[len(node.children)-1].err))
+
+	if isInnermostError {
+		stopOffset = node.offset + node.header
+		if errors.As(node.err, &unpackError) && (unpackError.Offset > stopOffset) {
+			stopOffset = unpackError.Offset
+		}
+		if uint64(len(src)) < stopOffset {
+			stopOffset = uint64(len(src))
+		}
+		dumpLines(builder, src, node.offset, stopOffset, description)
+		builder.WriteString(fmt.Sprintf("%*s%s!! %v\n", 8+2+dumpBytesPerLine*3-1+2, "", strings.Repeat(dumpIndent, depth), node.err))
+		return
+	}
+
+	if 0 == len(node.children) {
+		endOffset = node.offset + node.header + node.data + node.padding
+	} else {
+		endOffset = node.offset + node.header
+	}
+
+	dumpLines(builder, src, node.offset, endOffset, description)
+
+	for _, child = range node.children {
+		stopOffset = dumpSchemaNode(builder, src, child, depth+1)
+	}
+
+	return
+}
+
+// dumpLines appends lines dumping src[startOffset:endOffset] to builder, with description following the first.
+func dumpLines(builder *strings.Builder, src []byte, startOffset uint64, endOffset uint64, description string) {
+	var (
+		hexBytes   []string
+		lineOffset uint64
+		offset     uint64
+	)
+
+	lineOffset = startOffset
+
+	for {
+		hexBytes = hexBytes[:0]
+		for offset = lineOffset; (offset < endOffset) && (offset < lineOffset+dumpBytesPerLine); offset++ {
+			hexBytes = append(hexBytes, fmt.Sprintf("%02X", src[offset]))
+		}
+		builder.WriteString(strings.TrimRight(fmt.Sprintf("%08X  %-*s  %s", lineOffset, dumpBytesPerLine*3-1, strings.Join(hexBytes, " "), description), " "))
+		builder.WriteString("\n")
+		description = ""
+		lineOffset += dumpBytesPerLine
+		if lineOffset >= endOffset {
+			return
+		}
+	}
+}
+
+// formatSchemaValue formats value (as decoded by decodeSchemaNode()) of a leaf described by schema.
+func formatSchemaValue(schema *Schema, value interface{}) (s string) {
+	var (
+		name string
+		ok   bool
+	)
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			s = "TRUE"
+		} else {
+			s = "FALSE"
+		}
+	case int64:
+		s = fmt.Sprintf("%d", v)
+		if SchemaEnum == schema.Kind {
+			name, ok = schema.Enumerators[int32(v)]
+			if ok {
+				s = fmt.Sprintf("%s (%d)", name, v)
+			}
+		}
+	case []byte:
+		if 0 == len(v) {
+			s = "(empty)"
+		} else {
+			s = fmt.Sprintf("0x%X", v)
+		}
+	case string:
+		s = fmt.Sprintf("%q", v)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+
+	return
+}
@@ -0,0 +1,97 @@
+package xdr
+
+import (
+	"strings"
+	"testing"
+)
+
+type DumpStruct struct {
+	Count uint32  `xdr:""`
+	Name  string  `xdr:"string,max=8"`
+	Next  *uint32 `xdr:"optional"`
+}
+
+func TestDump(t *testing.T) {
+	var (
+		dump         string
+		dumpExpected string
+		err          error
+		packed       []byte
+		schemas      map[string]*Schema
+	)
+
+	packed = []byte{
+		0x00, 0x00, 0x00, 0x07, //                         Count: 7
+		0x00, 0x00, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o', // Name: "hello"
+		0x00, 0x00, 0x00, //                               (padding)
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x09, // Next: present, 9
+	}
+
+	dumpExpected = "" +
+		"Offset    Bytes                    Field: Type = Value\n" +
+		"00000000                           DumpStruct\n" +
+		"00000000  00 00 00 07                Count: unsigned int = 7\n" +
+		"00000004  00 00 00 05 68 65 6C 6C    Name: string<8> = \"hello\" (+3 padding)\n" +
+		"0000000C  6F 00 00 00\n" +
+		"00000010  00 00 00 01                Next: unsigned int* = present\n" +
+		"00000014  00 00 00 09                  *: unsigned int = 9\n"
+
+	dump = Dump(packed, DumpStruct{})
+	if dumpExpected != dump {
+		t.Fatalf("Dump(packed, DumpStruct{}) returned unexpected dump:\n%s\nexpected:\n%s", dump, dumpExpected)
+	}
+
+	// Verify trailing bytes, truncation, and non-zero padding are each annotated
+
+	dump = Dump(append(packed, 0xAB), &DumpStruct{})
+	if !strings.HasSuffix(dump, "00000018  AB                       (trailing bytes)\n") {
+		t.Fatalf("Dump(<trailing bytes>) returned unexpected dump:\n%s", dump)
+	}
+
+	dump = Dump(packed[:10], DumpStruct{})
+	if !strings.Contains(dump, "Name: string<8>\n") || !strings.Contains(dump, "!! No room for 5 bytes") || !strings.HasSuffix(dump, "00000008  68 65                    (undecoded bytes)\n") {
+		t.Fatalf("Dump(<truncated>) returned unexpected dump:\n%s", dump)
+	}
+
+	packed[14] = 0xFF
+	dump = Dump(packed, DumpStruct{})
+	if !strings.Contains(dump, "(+3 padding)  ! "+ErrNonZeroPadding.Error()) || !strings.Contains(dump, "*: unsigned int = 9") {
+		t.Fatalf("Dump(<non-zero padding>) returned unexpected dump:\n%s", dump)
+	}
+
+	// Verify an unselectable union arm stops decoding
+
+	dump = Dump([]byte{0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x00}, UnionWithoutDefaultStruct{})
+	if !strings.Contains(dump, "UnionWithoutDefaultStruct switch (Discriminant = 9)") || !strings.Contains(dump, "!! no arm of UnionWithoutDefaultStruct selected by discriminant 9") || !strings.Contains(dump, "(undecoded bytes)") {
+		t.Fatalf("Dump(<invalid discriminant>) returned unexpected dump:\n%s", dump)
+	}
+
+	// Verify deeply nested input is dumped up to the nesting limit (rather than exhausting the stack)
+
+	dump = Dump(deepNodeSrc[:4096], DeepNode{})
+	if !strings.Contains(dump, "!! nesting depth exceeds 256 at offset 0x400") || !strings.Contains(dump, "00000400  00 00 00 07 00 00 00 01  (undecoded bytes)\n") {
+		t.Fatalf("Dump(<deeply nested>) returned unexpected dump:\n%s", dump)
+	}
+
+	// Verify a .x Schema may be used in lieu of a Go type
+
+	schemas, err = ParseSchema("enum color { RED = 1, GREEN = 2 };\nstruct pixel { color hue; bool lit; };")
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+
+	dump = Dump([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02}, schemas["pixel"])
+	if !strings.Contains(dump, "hue: color = GREEN (2)") || !strings.Contains(dump, "lit: bool = TRUE  ! "+ErrInvalidBoolean.Error()) {
+		t.Fatalf("Dump(<pixel>) returned unexpected dump:\n%s", dump)
+	}
+
+	dump = Dump(packed, &Schema{Kind: SchemaArray})
+	if !strings.Contains(dump, "!! Dump() recovered from panic") {
+		t.Fatalf("Dump(<malformed *Schema>) returned unexpected dump:\n%s", dump)
+	}
+
+	dump = Dump(packed, map[int]int{})
+	if !strings.HasPrefix(dump, "!! ") {
+		t.Fatalf("Dump(<unsupported type>) returned unexpected dump:\n%s", dump)
+	}
+}
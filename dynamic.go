@@ -0,0 +1,467 @@
+package xdr
+
+import (
+	"fmt"
+	"math"
+)
+
+// Value is a dynamically typed XDR value (e.g. as decoded by DecodeDynamic() for a type known only at run time).
+//
+// Each XDR type is represented by one of the following (the Schema of the value distinguishing fixed from variable
+// length opaque data and arrays):
+//
+//	void                    Void
+//	int                     Int
+//	unsigned int            Uint
+//	enum                    Enum
+//	bool                    Bool
+//	hyper                   Hyper
+//	unsigned hyper          Uhyper
+//	float                   Float
+//	double                  Double
+//	opaque[n], opaque<n>    Opaque
+//	string<n>               String
+//	T[n], T<n>              Array
+//	struct                  Struct
+//	union                   Union
+//	T*                      Optional
+type Value interface {
+	isXDRValue()
+}
+
+// Void is the Value of void (e.g. the arm of a Union selected by a discriminant for which no data is encoded).
+type Void struct{}
+
+// Int is the Value of an int.
+type Int int32
+
+// Uint is the Value of an unsigned int.
+type Uint uint32
+
+// Enum is the Value of an enum.
+type Enum int32
+
+// Bool is the Value of a bool.
+type Bool bool
+
+// Hyper is the Value of a hyper.
+type Hyper int64
+
+// Uhyper is the Value of an unsigned hyper.
+type Uhyper uint64
+
+// Float is the Value of a float.
+type Float float32
+
+// Double is the Value of a double.
+type Double float64
+
+// Opaque is the Value of fixed or variable length opaque data.
+type Opaque []byte
+
+// String is the Value of a string.
+type String string
+
+// Array is the Value of a fixed or variable length array.
+type Array []Value
+
+// Struct is the Value of a struct, holding a Field per field (in declaration order).
+type Struct []Field
+
+// Field is the Value of a field of a Struct.
+//
+// EncodeDynamic() identifies fields by position, so Name may be left empty (but, if set, must match the Schema).
+type Field struct {
+	Name  string
+	Value Value
+}
+
+// Union is the Value of a union.
+//
+// EncodeDynamic() selects the arm by Discriminant, so Arm may be left empty (but, if set, must match the Schema).
+type Union struct {
+	Discriminant Value // Int, Uint, Enum, or Bool (as required by the Schema of the discriminant)
+	Arm          string
+	Value        Value // Void (or, for EncodeDynamic(), nil) for a void arm
+}
+
+// Optional is the Value of optional data, holding the referent (or nil if absent).
+type Optional struct {
+	Value Value
+}
+
+func (Void) isXDRValue()     {}
+func (Int) isXDRValue()      {}
+func (Uint) isXDRValue()     {}
+func (Enum) isXDRValue()     {}
+func (Bool) isXDRValue()     {}
+func (Hyper) isXDRValue()    {}
+func (Uhyper) isXDRValue()   {}
+func (Float) isXDRValue()    {}
+func (Double) isXDRValue()   {}
+func (Opaque) isXDRValue()   {}
+func (String) isXDRValue()   {}
+func (Array) isXDRValue()    {}
+func (Struct) isXDRValue()   {}
+func (Union) isXDRValue()    {}
+func (Optional) isXDRValue() {}
+
+// DecodeDynamic decodes the value described by schema (e.g. as returned by ParseSchema()) from src.
+//
+// src is decoded as by Unpack() in UnpackModeDefault (so any trailing bytes are ignored, but reported by
+// bytesConsumed), with Opaque values receiving copies of the corresponding bytes of src.
+func DecodeDynamic(src []byte, schema *Schema) (value Value, bytesConsumed uint64, err error) {
+	var (
+		root *schemaNode
+	)
+
+	defer recoverInternalError("DecodeDynamic", &err)
+
+	if nil == schema {
+		err = fmt.Errorf("nil *Schema")
+		return
+	}
+
+	root, err = decodeSchema(src, schema, DefaultMaxDepth)
+	if nil != err {
+		return
+	}
+
+	value, err = dynamicValueOf(root)
+	if nil != err {
+		value = nil
+		return
+	}
+
+	bytesConsumed = root.size
+
+	return
+}
+
+// dynamicValueOf returns the Value decoded into node.
+func dynamicValueOf(node *schemaNode) (value Value, err error) {
+	var (
+		array       Array
+		child       *schemaNode
+		referent    Value
+		structValue Struct
+		union       Union
+	)
+
+	if 0 < len(node.notes) {
+		err = node.notes[0]
+		return
+	}
+
+	switch node.schema.Kind {
+	case SchemaVoid:
+		value = Void{}
+	case SchemaInt:
+		value = Int(node.value.(int64))
+	case SchemaUint:
+		value = Uint(node.value.(uint64))
+	case SchemaEnum:
+		value = Enum(node.value.(int64))
+	case SchemaBool:
+		value = Bool(node.value.(bool))
+	case SchemaHyper:
+		value = Hyper(node.value.(int64))
+	case SchemaUhyper:
+		value = Uhyper(node.value.(uint64))
+	case SchemaFloat:
+		value = Float(node.value.(float64))
+	case SchemaDouble:
+		value = Double(node.value.(float64))
+	case SchemaFixedOpaque, SchemaOpaque:
+		value = Opaque(append([]byte{}, node.value.([]byte)...))
+	case SchemaString:
+		value = String(node.value.(string))
+	case SchemaFixedArray, SchemaArray:
+		array = make(Array, 0, len(node.children))
+		for _, child = range node.children {
+			referent, err = dynamicValueOf(child)
+			if nil != err {
+				return
+			}
+			array = append(array, referent)
+		}
+		value = array
+	case SchemaStruct:
+		structValue = make(Struct, 0, len(node.children))
+		for _, child = range node.children {
+			referent, err = dynamicValueOf(child)
+			if nil != err {
+				return
+			}
+			structValue = append(structValue, Field{Name: child.name, Value: referent})
+		}
+		value = structValue
+	case SchemaUnion:
+		union.Discriminant = dynamicDiscriminantOf(node.schema.Discriminant.Type, node.value.(int64))
+		union.Arm = node.children[0].name
+		union.Value, err = dynamicValueOf(node.children[0])
+		if nil != err {
+			return
+		}
+		value = union
+	case SchemaOptional:
+		if node.value.(bool) {
+			referent, err = dynamicValueOf(node.children[0])
+			if nil != err {
+				return
+			}
+		}
+		value = Optional{Value: referent}
+	default:
+		err = newUnpackError(node.offset, ErrInternal, "unsupported %v", node.schema.Kind)
+	}
+
+	return
+}
+
+// dynamicDiscriminantOf returns the Value of the discriminant of a union (as decoded by decodeSchemaNode()).
+func dynamicDiscriminantOf(discriminantSchema *Schema, discriminant int64) (value Value) {
+	switch discriminantSchema.Kind {
+	case SchemaUint:
+		value = Uint(uint32(discriminant))
+	case SchemaEnum:
+		value = Enum(int32(discriminant))
+	case SchemaBool:
+		value = Bool(0 != discriminant)
+	default:
+		value = Int(int32(discriminant))
+	}
+
+	return
+}
+
+// EncodeDynamic encodes value as described by schema (e.g. as returned by ParseSchema()).
+//
+// Each Value must be of the type representing its Schema (see Value) and respect its sizes (e.g. an Opaque for
+// opaque[n] must hold exactly n bytes). Errors identify the offending Value by path (e.g. "$.entries[2].name").
+func EncodeDynamic(value Value, schema *Schema) (dst []byte, err error) {
+	defer func() {
+		if nil != err {
+			dst = nil
+		}
+	}()
+	defer recoverInternalError("EncodeDynamic", &err)
+
+	if nil == schema {
+		err = fmt.Errorf("nil *Schema")
+		return
+	}
+
+	dst, err = appendDynamicValue(nil, schema, value, "$")
+
+	return
+}
+
+// appendDynamicValue appends the encoding of value, found at path, as described by schema to dst.
+func appendDynamicValue(dstIn []byte, schema *Schema, value Value, path string) (dst []byte, err error) {
+	var (
+		arm          *SchemaArm
+		array        Array
+		data         []byte
+		discriminant int64
+		element      Value
+		elementIndex int
+		fieldIndex   int
+		lengthOffset int
+		ok           bool
+		optional     Optional
+		size         uint64
+		structValue  Struct
+		u64          uint64
+		union        Union
+	)
+
+	dst = dstIn
+
+	switch schema.Kind {
+	case SchemaVoid:
+		_, ok = value.(Void)
+		if !ok && (nil != value) {
+			err = dynamicTypeError(path, schema, value)
+		}
+	case SchemaInt, SchemaUint, SchemaEnum, SchemaBool, SchemaHyper, SchemaUhyper, SchemaFloat, SchemaDouble:
+		u64, size, ok = dynamicScalarWord(schema.Kind, value)
+		if !ok {
+			err = dynamicTypeError(path, schema, value)
+			return
+		}
+		if (SchemaEnum == schema.Kind) && (nil != schema.Enumerators) {
+			_, ok = schema.Enumerators[int32(uint32(u64))]
+			if !ok {
+				err = fmt.Errorf("%s: %w: %d is not declared by %s", path, ErrInvalidEnumeration, int32(uint32(u64)), schema.TypeName())
+				return
+			}
+		}
+		dst = appendSchemaWord(dst, u64, size)
+	case SchemaFixedOpaque, SchemaOpaque, SchemaString:
+		switch v := value.(type) {
+		case Opaque:
+			ok = (SchemaString != schema.Kind)
+			data = v
+		case String:
+			ok = (SchemaString == schema.Kind)
+			data = []byte(v)
+		}
+		if !ok {
+			err = dynamicTypeError(path, schema, value)
+			return
+		}
+		dst, err = appendDynamicLength(dst, schema, len(data), "bytes", path)
+		if nil != err {
+			return
+		}
+		dst = append(dst, data...)
+		dst = append(dst, make([]byte, (4-(len(data)%4))%4)...)
+	case SchemaFixedArray, SchemaArray:
+		array, ok = value.(Array)
+		if !ok {
+			err = dynamicTypeError(path, schema, value)
+			return
+		}
+		dst, err = appendDynamicLength(dst, schema, len(array), "elements", path)
+		if nil != err {
+			return
+		}
+		for elementIndex, element = range array {
+			dst, err = appendDynamicValue(dst, schema.Elem, element, fmt.Sprintf("%s[%d]", path, elementIndex))
+			if nil != err {
+				return
+			}
+		}
+	case SchemaStruct:
+		structValue, ok = value.(Struct)
+		if !ok {
+			err = dynamicTypeError(path, schema, value)
+			return
+		}
+		if len(schema.Fields) != len(structValue) {
+			err = fmt.Errorf("%s: expected %d fields (for %s) but found %d", path, len(schema.Fields), schema.TypeName(), len(structValue))
+			return
+		}
+		for fieldIndex = range structValue {
+			if ("" != structValue[fieldIndex].Name) && (schema.Fields[fieldIndex].Name != structValue[fieldIndex].Name) {
+				err = fmt.Errorf("%s: expected field %q but found %q", path, schema.Fields[fieldIndex].Name, structValue[fieldIndex].Name)
+				return
+			}
+			dst, err = appendDynamicValue(dst, schema.Fields[fieldIndex].Type, structValue[fieldIndex].Value, path+"."+schema.Fields[fieldIndex].Name)
+			if nil != err {
+				return
+			}
+		}
+	case SchemaUnion:
+		union, ok = value.(Union)
+		if !ok {
+			err = dynamicTypeError(path, schema, value)
+			return
+		}
+		dst, err = appendDynamicValue(dst, schema.Discriminant.Type, union.Discriminant, path+"."+schema.Discriminant.Name)
+		if nil != err {
+			return
+		}
+		u64, _, _ = dynamicScalarWord(schema.Discriminant.Type.Kind, union.Discriminant)
+		if SchemaUint == schema.Discriminant.Type.Kind {
+			discriminant = int64(u64)
+		} else {
+			discriminant = int64(int32(uint32(u64)))
+		}
+		arm, ok = schema.selectArm(discriminant)
+		if !ok {
+			err = fmt.Errorf("%s: %w: no arm of %s selected by discriminant %d", path, ErrInvalidDiscriminant, schema.TypeName(), discriminant)
+			return
+		}
+		if ("" != union.Arm) && (arm.Field.Name != union.Arm) {
+			err = fmt.Errorf("%s: discriminant %d selects arm %q but found %q", path, discriminant, arm.Field.Name, union.Arm)
+			return
+		}
+		if "" != arm.Field.Name {
+			path += "." + arm.Field.Name
+		}
+		if arm.IsWrapped {
+			lengthOffset = len(dst)
+			dst = appendSchemaWord(dst, 0, 4)
+		}
+		dst, err = appendDynamicValue(dst, arm.Field.Type, union.Value, path)
+		if (nil == err) && arm.IsWrapped {
+			putSchemaArmLength(dst, lengthOffset)
+		}
+	case SchemaOptional:
+		optional, ok = value.(Optional)
+		if !ok {
+			err = dynamicTypeError(path, schema, value)
+			return
+		}
+		if nil == optional.Value {
+			dst = appendSchemaWord(dst, 0, 4)
+		} else {
+			dst = appendSchemaWord(dst, 1, 4)
+			dst, err = appendDynamicValue(dst, schema.Elem, optional.Value, path)
+		}
+	default:
+		err = fmt.Errorf("%s: %w: unsupported %v", path, ErrInternal, schema.Kind)
+	}
+
+	return
+}
+
+// dynamicScalarWord returns the encoding (as a 4 or 8 byte word) of value if it represents a scalar of kind.
+func dynamicScalarWord(kind SchemaKind, value Value) (u64 uint64, size uint64, ok bool) {
+	switch v := value.(type) {
+	case Int:
+		u64, size, ok = uint64(uint32(v)), 4, (SchemaInt == kind)
+	case Uint:
+		u64, size, ok = uint64(v), 4, (SchemaUint == kind)
+	case Enum:
+		u64, size, ok = uint64(uint32(v)), 4, (SchemaEnum == kind)
+	case Bool:
+		if v {
+			u64 = 1
+		}
+		size, ok = 4, (SchemaBool == kind)
+	case Hyper:
+		u64, size, ok = uint64(v), 8, (SchemaHyper == kind)
+	case Uhyper:
+		u64, size, ok = uint64(v), 8, (SchemaUhyper == kind)
+	case Float:
+		u64, size, ok = uint64(math.Float32bits(float32(v))), 4, (SchemaFloat == kind)
+	case Double:
+		u64, size, ok = math.Float64bits(float64(v)), 8, (SchemaDouble == kind)
+	}
+
+	return
+}
+
+// appendDynamicLength checks length (of units, e.g. "bytes") against the size of schema, appending it to dst
+// unless schema is of fixed length.
+func appendDynamicLength(dstIn []byte, schema *Schema, length int, units string, path string) (dst []byte, err error) {
+	dst = dstIn
+
+	switch schema.Kind {
+	case SchemaFixedOpaque, SchemaFixedArray:
+		if uint64(schema.Size) != uint64(length) {
+			err = fmt.Errorf("%s: expected %d %s (for %s) but found %d", path, schema.Size, units, schema.TypeName(), length)
+		}
+	default:
+		if (0 != schema.Size) && (uint64(schema.Size) < uint64(length)) {
+			err = fmt.Errorf("%s: %w: length %d exceeds maximum %d", path, ErrMaxSizeExceeded, length, schema.Size)
+			return
+		}
+		if uint64(math.MaxUint32) < uint64(length) {
+			err = fmt.Errorf("%s: %w: length %d exceeds 32 bits", path, ErrOverflow, length)
+			return
+		}
+		dst = appendSchemaWord(dst, uint64(length), 4)
+	}
+
+	return
+}
+
+// dynamicTypeError returns an error reporting that the Value at path was not of the type representing schema.
+func dynamicTypeError(path string, schema *Schema, value Value) (err error) {
+	err = fmt.Errorf("%s: %T cannot represent %s", path, value, schema.TypeName())
+	return
+}
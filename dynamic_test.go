@@ -0,0 +1,238 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDynamicSchemaText = `
+enum kind { KIND_FILE = 1, KIND_DIR = 2 };
+
+union node switch (kind k) {
+case KIND_FILE:
+	opaque handle[3];
+case KIND_DIR:
+	void;
+};
+
+struct entry {
+	unsigned int id;
+	string       name<8>;
+	bool         hidden;
+	hyper        size;
+	node         n;
+	entry        *next;
+};
+
+typedef entry listing<2>;
+`
+
+func TestDynamic(t *testing.T) {
+	var (
+		bytesConsumed uint64
+		err           error
+		packed        []byte
+		packedGood    []byte
+		repacked      []byte
+		schema        *Schema
+		schemas       map[string]*Schema
+		value         Value
+		valueExpected Value
+	)
+
+	schemas, err = ParseSchema(testDynamicSchemaText)
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+	schema = schemas["listing"]
+
+	packed = []byte{
+		0x00, 0x00, 0x00, 0x01, //                                 listing length 1
+		0x00, 0x00, 0x00, 0x07, //                                 id: 7
+		0x00, 0x00, 0x00, 0x02, 'h', 'i', 0x00, 0x00, //           name: "hi"
+		0x00, 0x00, 0x00, 0x00, //                                 hidden: FALSE
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE, //         size: -2
+		0x00, 0x00, 0x00, 0x01, 0x0A, 0x0B, 0x0C, 0x00, //         n: KIND_FILE, handle
+		0x00, 0x00, 0x00, 0x01, //                                 next: present
+		0x00, 0x00, 0x00, 0x08, //                                 id: 8
+		0x00, 0x00, 0x00, 0x00, //                                 name: ""
+		0x00, 0x00, 0x00, 0x01, //                                 hidden: TRUE
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, //         size: 0
+		0x00, 0x00, 0x00, 0x02, //                                 n: KIND_DIR
+		0x00, 0x00, 0x00, 0x00, //                                 next: absent
+	}
+
+	valueExpected = Array{
+		Struct{
+			{Name: "id", Value: Uint(7)},
+			{Name: "name", Value: String("hi")},
+			{Name: "hidden", Value: Bool(false)},
+			{Name: "size", Value: Hyper(-2)},
+			{Name: "n", Value: Union{Discriminant: Enum(1), Arm: "handle", Value: Opaque{0x0A, 0x0B, 0x0C}}},
+			{Name: "next", Value: Optional{Value: Struct{
+				{Name: "id", Value: Uint(8)},
+				{Name: "name", Value: String("")},
+				{Name: "hidden", Value: Bool(true)},
+				{Name: "size", Value: Hyper(0)},
+				{Name: "n", Value: Union{Discriminant: Enum(2), Arm: "", Value: Void{}}},
+				{Name: "next", Value: Optional{}},
+			}}},
+		},
+	}
+
+	value, bytesConsumed, err = DecodeDynamic(append(packed, 0xFF), schema)
+	if nil != err {
+		t.Fatalf("DecodeDynamic() received unexpected error: %v", err)
+	}
+	if uint64(len(packed)) != bytesConsumed {
+		t.Fatalf("DecodeDynamic() returned bytesConsumed == %d (expected %d)", bytesConsumed, len(packed))
+	}
+	if !reflect.DeepEqual(valueExpected, value) {
+		t.Fatalf("DecodeDynamic() returned unexpected value:\n%#v", value)
+	}
+
+	repacked, err = EncodeDynamic(value, schema)
+	if (nil != err) || !bytes.Equal(packed, repacked) {
+		t.Fatalf("EncodeDynamic(DecodeDynamic()) returned 0x%X (expected 0x%X) or unexpected error: %v", repacked, packed, err)
+	}
+
+	// Verify names are optional (and nil may stand in for a void arm) when encoding
+
+	repacked, err = EncodeDynamic(Struct{{Value: Uint(8)}, {Value: String("")}, {Value: Bool(true)}, {Value: Hyper(0)}, {Value: Union{Discriminant: Enum(2)}}, {Value: Optional{}}}, schemas["entry"])
+	if (nil != err) || !bytes.Equal(packed[len(packed)-28:], repacked) {
+		t.Fatalf("EncodeDynamic(<unnamed fields>) returned 0x%X or unexpected error: %v", repacked, err)
+	}
+
+	// Verify a Schema derived from a Go type decodes what Pack() encoded
+
+	schema, err = schemaOfType(ParentStruct{})
+	if nil != err {
+		t.Fatalf("schemaOfType(ParentStruct{}) received unexpected error: %v", err)
+	}
+	value, _, err = DecodeDynamic(goodParentStructPacked, schema)
+	if nil != err {
+		t.Fatalf("DecodeDynamic(goodParentStructPacked) received unexpected error: %v", err)
+	}
+	if (Int(-1000000) != value.(Struct)[0].Value) || (Hyper(-1000000000000) != value.(Struct)[5].Value) || !reflect.DeepEqual(Opaque{0x01, 0x02, 0x03, 0x04, 0x05}, value.(Struct)[7].Value) {
+		t.Fatalf("DecodeDynamic(goodParentStructPacked) returned unexpected value:\n%#v", value)
+	}
+	packedGood, err = EncodeDynamic(value, schema)
+	if (nil != err) || !bytes.Equal(goodParentStructPacked, packedGood) {
+		t.Fatalf("EncodeDynamic(<ParentStruct>) returned 0x%X or unexpected error: %v", packedGood, err)
+	}
+
+	// Verify DecodeDynamic() is as strict as Unpack()
+
+	_, _, err = DecodeDynamic(badParentStructPacked, schema)
+	if !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Fatalf("DecodeDynamic(badParentStructPacked) returned unexpected error: %v", err)
+	}
+	packed[15] = 0x01
+	_, _, err = DecodeDynamic(packed, schemas["listing"])
+	if !errors.Is(err, ErrNonZeroPadding) {
+		t.Fatalf("DecodeDynamic(<non-zero padding>) returned unexpected error: %v", err)
+	}
+	_, _, err = DecodeDynamic(packed[:20], schemas["listing"])
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("DecodeDynamic(<truncated>) returned unexpected error: %v", err)
+	}
+	_, _, err = DecodeDynamic(packed, nil)
+	if nil == err {
+		t.Fatalf("DecodeDynamic(<nil *Schema>) should have failed")
+	}
+	_, _, err = DecodeDynamic(packed, &Schema{Kind: SchemaArray})
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("DecodeDynamic(<malformed *Schema>) returned unexpected error: %v", err)
+	}
+
+	// Verify hostile src is bounded in the nesting depth and the number of values decoded
+
+	schema, err = schemaOfType(DeepNode{})
+	if nil != err {
+		t.Fatalf("schemaOfType(DeepNode{}) received unexpected error: %v", err)
+	}
+	_, _, err = DecodeDynamic(deepNodeSrc, schema)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("DecodeDynamic(deepNodeSrc) returned unexpected error: %v", err)
+	}
+	_, err = ToJSON(deepNodeSrc, DeepNode{})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("ToJSON(deepNodeSrc) returned unexpected error: %v", err)
+	}
+	_, err = Diff(deepNodeSrc, deepNodeSrc, DeepNode{})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Diff(deepNodeSrc, deepNodeSrc) returned unexpected error: %v", err)
+	}
+	_, err = NewView(deepNodeSrc, DeepNode{}).Size()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("NewView(deepNodeSrc).Size() returned unexpected error: %v", err)
+	}
+	_, err = PeekField(deepNodeSrc, DeepNode{}, "$", &DeepNode{})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("PeekField(deepNodeSrc, \"$\") returned unexpected error: %v", err)
+	}
+
+	schema, err = schemaOfType([][]struct{}{})
+	if nil != err {
+		t.Fatalf("schemaOfType([][]struct{}{}) received unexpected error: %v", err)
+	}
+	packed = append([]byte{0x00, 0x00, 0x00, 0xFF}, bytes.Repeat([]byte{0x00, 0x00, 0x04, 0x00}, 0xFF)...) // 0xFF []struct{}'s of 0x400 elements
+	_, _, err = DecodeDynamic(packed, schema)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("DecodeDynamic(<too many zero-size elements>) returned unexpected error: %v", err)
+	}
+}
+
+func TestEncodeDynamicErrors(t *testing.T) {
+	var (
+		err            error
+		errorsExpected = []struct {
+			value             Value
+			schemaName        string
+			expectedSubstring string
+			expectedCause     error
+		}{
+			{Array{Struct{}, Struct{}, Struct{}}, "listing", "$: XDR_MaxSize exceeded", ErrMaxSizeExceeded},
+			{Uint(1), "listing", "$: xdr.Uint cannot represent listing", nil},
+			{Struct{{Value: Int(1)}}, "entry", "$: expected 6 fields (for entry) but found 1", nil},
+			{Struct{{Name: "ID"}, {}, {}, {}, {}, {}}, "entry", `$: expected field "id" but found "ID"`, nil},
+			{Struct{{Value: Int(1)}, {}, {}, {}, {}, {}}, "entry", "$.id: xdr.Int cannot represent unsigned int", nil},
+			{Struct{{Value: Uint(1)}, {Value: Opaque("hi")}, {}, {}, {}, {}}, "entry", "$.name: xdr.Opaque cannot represent string<8>", nil},
+			{Union{Discriminant: Int(1)}, "node", "$.k: xdr.Int cannot represent kind", nil},
+			{Union{Discriminant: Enum(3)}, "node", "$.k: invalid Enumeration value: 3 is not declared by kind", ErrInvalidEnumeration},
+			{Union{Discriminant: Enum(1), Value: Opaque{0x01}}, "node", "$.handle: expected 3 bytes (for opaque[3]) but found 1", nil},
+			{Union{Discriminant: Enum(1), Arm: "h", Value: Opaque{0x01, 0x02, 0x03}}, "node", `$: discriminant 1 selects arm "handle" but found "h"`, nil},
+			{Union{Discriminant: Enum(2), Value: Int(0)}, "node", "$: xdr.Int cannot represent void", nil},
+		}
+		i       int
+		schemas map[string]*Schema
+	)
+
+	schemas, err = ParseSchema(testDynamicSchemaText)
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+
+	for i = range errorsExpected {
+		_, err = EncodeDynamic(errorsExpected[i].value, schemas[errorsExpected[i].schemaName])
+		if (nil == err) || !strings.Contains(err.Error(), errorsExpected[i].expectedSubstring) {
+			t.Fatalf("EncodeDynamic(%#v) returned unexpected error (expected to contain %q): %v", errorsExpected[i].value, errorsExpected[i].expectedSubstring, err)
+		}
+		if (nil != errorsExpected[i].expectedCause) && !errors.Is(err, errorsExpected[i].expectedCause) {
+			t.Fatalf("EncodeDynamic(%#v) returned error not wrapping %v: %v", errorsExpected[i].value, errorsExpected[i].expectedCause, err)
+		}
+	}
+
+	_, err = EncodeDynamic(Uint(1), nil)
+	if nil == err {
+		t.Fatalf("EncodeDynamic(<nil *Schema>) should have failed")
+	}
+
+	_, err = EncodeDynamic(Union{Discriminant: Uint(9)}, &Schema{Kind: SchemaUnion, Discriminant: SchemaField{Name: "d", Type: &Schema{Kind: SchemaUint}}})
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("EncodeDynamic(<invalid discriminant>) returned unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package xdr
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	enumerationRegistryLock sync.RWMutex
+	enumerationRegistry     = make(map[reflect.Type]map[int32]string)
+)
+
+// RegisterEnumeration declares the values (and their names) that the named Go type of enumIF may take.
+//
+// The Kind() of that type must be a signed or unsigned integer of (at most) 32 bits, int, or uint. Once
+// registered, Pack() will reject a value of that type not found in names, as will Unpack() (unless
+// UnpackModeLenient is specified).
+func RegisterEnumeration(enumIF interface{}, names map[int32]string) (err error) {
+	var (
+		enumTypeOf reflect.Type
+		name       string
+		namesCopy  map[int32]string
+		value      int32
+	)
+
+	enumTypeOf = reflect.TypeOf(enumIF)
+
+	if nil == enumTypeOf {
+		err = fmt.Errorf("RegisterEnumeration() passed nil enumIF")
+		return
+	}
+	if "" == enumTypeOf.PkgPath() {
+		err = fmt.Errorf("RegisterEnumeration() passed predeclared or unnamed type %v", enumTypeOf)
+		return
+	}
+	if !isEnumerationKind(enumTypeOf.Kind()) {
+		err = fmt.Errorf("RegisterEnumeration() passed type %v with unsupported Kind() == %v", enumTypeOf, enumTypeOf.Kind())
+		return
+	}
+	if 0 == len(names) {
+		err = fmt.Errorf("RegisterEnumeration() passed no values for type %v", enumTypeOf)
+		return
+	}
+
+	namesCopy = make(map[int32]string, len(names))
+	for value, name = range names {
+		namesCopy[value] = name
+	}
+
+	enumerationRegistryLock.Lock()
+	enumerationRegistry[enumTypeOf] = namesCopy
+	enumerationRegistryLock.Unlock()
+
+	return
+}
+
+// EnumerationString returns the name registered for the value of enumIF, suitable for use by a String() method.
+//
+// If the value (or its type) was not registered, a string of the form "<type name>(<value>)" is returned instead.
+func EnumerationString(enumIF interface{}) (s string) {
+	var (
+		enumValueOf reflect.Value
+		names       map[int32]string
+		ok          bool
+		value       int32
+	)
+
+	enumValueOf = reflect.ValueOf(enumIF)
+
+	if !enumValueOf.IsValid() || !isEnumerationKind(enumValueOf.Kind()) {
+		s = fmt.Sprintf("%v", enumIF)
+		return
+	}
+
+	value = enumerationValue(enumValueOf)
+
+	names, ok = lookupEnumeration(enumValueOf.Type())
+	if ok {
+		s, ok = names[value]
+		if ok {
+			return
+		}
+	}
+
+	s = fmt.Sprintf("%s(%d)", enumValueOf.Type().Name(), value)
+
+	return
+}
+
+func isEnumerationKind(kind reflect.Kind) (ok bool) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		ok = true
+	default:
+		ok = false
+	}
+	return
+}
+
+func enumerationValue(enumValueOf reflect.Value) (value int32) {
+	switch enumValueOf.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = int32(uint32(enumValueOf.Uint()))
+	default:
+		value = int32(enumValueOf.Int())
+	}
+	return
+}
+
+func lookupEnumeration(enumTypeOf reflect.Type) (names map[int32]string, ok bool) {
+	enumerationRegistryLock.RLock()
+	names, ok = enumerationRegistry[enumTypeOf]
+	enumerationRegistryLock.RUnlock()
+	return
+}
+
+// isDeclaredEnumeration returns false only if enumValueOf is of a registered type but holds an undeclared value.
+func isDeclaredEnumeration(enumValueOf reflect.Value) (ok bool) {
+	ok = isDeclaredEnumerationValue(enumValueOf.Type(), enumerationValue(enumValueOf))
+	return
+}
+
+// isDeclaredEnumerationValue returns false only if enumTypeOf is registered but value is not one it declares.
+func isDeclaredEnumerationValue(enumTypeOf reflect.Type, value int32) (ok bool) {
+	var (
+		names map[int32]string
+	)
+
+	names, ok = lookupEnumeration(enumTypeOf)
+	if !ok {
+		ok = true
+		return
+	}
+
+	_, ok = names[value]
+
+	return
+}
@@ -0,0 +1,105 @@
+package xdr
+
+import (
+	"errors"
+	"testing"
+)
+
+type Color int32
+
+const (
+	ColorRed   Color = 1
+	ColorGreen Color = 2
+	ColorBlue  Color = 4
+)
+
+func (color Color) String() string {
+	return EnumerationString(color)
+}
+
+type ColorStruct struct {
+	Color   Color   `XDR_Name:"Enumeration"`
+	Palette []Color `XDR_Name:"Variable-Length Array"`
+}
+
+func init() {
+	var (
+		err error
+	)
+
+	err = RegisterEnumeration(ColorRed, map[int32]string{1: "RED", 2: "GREEN", 4: "BLUE"})
+	if nil != err {
+		panic(err)
+	}
+}
+
+func TestRegisterEnumeration(t *testing.T) {
+	var (
+		err error
+	)
+
+	err = RegisterEnumeration(int32(0), map[int32]string{0: "ZERO"})
+	if nil == err {
+		t.Fatalf("RegisterEnumeration(int32(0), ...) should have failed")
+	}
+
+	err = RegisterEnumeration(ChildStruct{}, map[int32]string{0: "ZERO"})
+	if nil == err {
+		t.Fatalf("RegisterEnumeration(ChildStruct{}, ...) should have failed")
+	}
+
+	if "GREEN" != ColorGreen.String() {
+		t.Fatalf("ColorGreen.String() returned \"%s\" - should have been \"GREEN\"", ColorGreen.String())
+	}
+	if "Color(3)" != Color(3).String() {
+		t.Fatalf("Color(3).String() returned \"%s\" - should have been \"Color(3)\"", Color(3).String())
+	}
+}
+
+func TestEnumerationValidation(t *testing.T) {
+	var (
+		colorStructReturned ColorStruct
+		err                 error
+		packed              []byte
+	)
+
+	packed, err = Pack(ColorStruct{Color: ColorBlue, Palette: []Color{ColorRed, ColorGreen}})
+	if nil != err {
+		t.Fatalf("Pack(<declared Colors>) received unexpected error: %v", err)
+	}
+
+	_, err = Unpack(packed, &colorStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(<declared Colors>) received unexpected error: %v", err)
+	}
+	if (ColorBlue != colorStructReturned.Color) || (2 != len(colorStructReturned.Palette)) || (ColorGreen != colorStructReturned.Palette[1]) {
+		t.Fatalf("Unpack(<declared Colors>) received unexpected colorStructReturned: %+v", colorStructReturned)
+	}
+
+	_, err = Pack(ColorStruct{Color: Color(3)})
+	if !errors.Is(err, ErrInvalidEnumeration) {
+		t.Fatalf("Pack(<undeclared Color>) should have failed with ErrInvalidEnumeration (got %v)", err)
+	}
+
+	_, err = Pack(ColorStruct{Color: ColorRed, Palette: []Color{Color(0)}})
+	if !errors.Is(err, ErrInvalidEnumeration) {
+		t.Fatalf("Pack(<undeclared Color in Palette>) should have failed with ErrInvalidEnumeration (got %v)", err)
+	}
+
+	// Replace ColorBlue with 3
+
+	packed[3] = 0x03
+
+	_, err = Unpack(packed, &colorStructReturned)
+	if !errors.Is(err, ErrInvalidEnumeration) {
+		t.Fatalf("Unpack(<undeclared Color>) should have failed with ErrInvalidEnumeration (got %v)", err)
+	}
+
+	_, err = UnpackWithOptions(packed, &colorStructReturned, &UnpackOptions{Mode: UnpackModeLenient})
+	if nil != err {
+		t.Fatalf("UnpackWithOptions(<undeclared Color>, UnpackModeLenient) received unexpected error: %v", err)
+	}
+	if Color(3) != colorStructReturned.Color {
+		t.Fatalf("UnpackWithOptions(<undeclared Color>, UnpackModeLenient) received unexpected colorStructReturned.Color: %v", colorStructReturned.Color)
+	}
+}
@@ -0,0 +1,134 @@
+package xdr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The following errors are reported (as the Cause of an *UnpackError) when Unpack() fails.
+var (
+	// ErrTruncated indicates src ended before the value being decoded.
+	ErrTruncated = errors.New("src []byte truncated")
+
+	// ErrInvalidBoolean indicates a Boolean was encoded as something other than 0 or 1.
+	ErrInvalidBoolean = errors.New("invalid Boolean encoding")
+
+	// ErrNonZeroPadding indicates a pad byte following opaque or string data was not zero.
+	ErrNonZeroPadding = errors.New("non-zero pad bytes")
+
+	// ErrMaxSizeExceeded indicates a variable-length element count exceeded its XDR_MaxSize.
+	ErrMaxSizeExceeded = errors.New("XDR_MaxSize exceeded")
+
+	// ErrLimitExceeded indicates decoding would have exceeded one of the supplied Limits (or, reported by Pack(),
+	// encoding would have exceeded PackOptions.MaxDepth).
+	ErrLimitExceeded = errors.New("decoding limit exceeded")
+
+	// ErrOverflow indicates a value that does not fit in the Go (or, reported by Pack(), XDR) type receiving it.
+	ErrOverflow = errors.New("value overflows type")
+
+	// ErrInvalidEnumeration indicates an Enumeration value outside those permitted (also reported by Pack()).
+	ErrInvalidEnumeration = errors.New("invalid Enumeration value")
+
+	// ErrInvalidDiscriminant indicates a Discriminated Union discriminant selecting none of its arms (also reported by Pack()).
+	ErrInvalidDiscriminant = errors.New("invalid Discriminated Union discriminant")
+
+	// ErrInvalidDestination indicates the value supplied to receive the decoding was not a non-nil pointer, or that
+	// an interface (not registered with RegisterInterface()) within it did not hold a non-nil pointer to decode into.
+	ErrInvalidDestination = errors.New("invalid destination")
+
+	// ErrTrailingBytes indicates src held bytes beyond the decoded value (e.g. in UnpackModeStrict or by UnpackExact())
+	// or that an arm of a Discriminated Union with an unknown arm did not fill the opaque<> wrapping it.
+	ErrTrailingBytes = errors.New("trailing bytes in src []byte")
+)
+
+// The following errors are reported (wrapped, as are ErrOverflow and the like) when Examine() or Pack() rejects a value.
+var (
+	// ErrCycle indicates a value referencing itself (e.g. via a pointer or slice), which would never finish encoding.
+	ErrCycle = errors.New("value references itself")
+)
+
+// The following errors are reported (as the Cause of a *PackError) when Pack() fails after Examine() succeeded.
+var (
+	// ErrSizeMismatch indicates the packed size of a value differed from that computed by Examine().
+	ErrSizeMismatch = errors.New("packed size differs from Examine()")
+
+	// ErrInternal indicates an unanticipated condition (including a recovered panic reported as an *InternalError).
+	ErrInternal = errors.New("internal error")
+)
+
+// UnpackError describes why and where in src a call to Unpack() failed.
+type UnpackError struct {
+	Offset uint64 // Offset in src at which the failure was detected
+	Cause  error  // One of the Err* values above
+	Detail string // Human readable description of the failure
+}
+
+func (unpackError *UnpackError) Error() string {
+	return fmt.Sprintf("%s at offset 0x%X", unpackError.Detail, unpackError.Offset)
+}
+
+// Unwrap enables errors.Is(err, ErrTruncated) and the like on errors returned by Unpack().
+func (unpackError *UnpackError) Unwrap() error {
+	return unpackError.Cause
+}
+
+func newUnpackError(offset uint64, cause error, format string, args ...interface{}) (err error) {
+	err = &UnpackError{
+		Offset: offset,
+		Cause:  cause,
+		Detail: fmt.Sprintf(format, args...),
+	}
+	return
+}
+
+// PackError describes why and where in dst a call to Pack() failed.
+type PackError struct {
+	Offset uint64 // Offset in dst at which the failure was detected
+	Cause  error  // One of the Err* values above
+	Detail string // Human readable description of the failure
+}
+
+func (packError *PackError) Error() string {
+	return fmt.Sprintf("%s at offset 0x%X", packError.Detail, packError.Offset)
+}
+
+// Unwrap enables errors.Is(err, ErrSizeMismatch) and the like on errors returned by Pack().
+func (packError *PackError) Unwrap() error {
+	return packError.Cause
+}
+
+func newPackError(offset uint64, cause error, format string, args ...interface{}) (err error) {
+	err = &PackError{
+		Offset: offset,
+		Cause:  cause,
+		Detail: fmt.Sprintf(format, args...),
+	}
+	return
+}
+
+// InternalError reports a panic recovered by Examine(), Pack(), or Unpack() (rather than crashing the caller).
+type InternalError struct {
+	Op    string      // Name of the function that recovered the panic
+	Panic interface{} // Value passed to panic()
+}
+
+func (internalError *InternalError) Error() string {
+	return fmt.Sprintf("%s() recovered from panic: %v", internalError.Op, internalError.Panic)
+}
+
+// Unwrap enables errors.Is(err, ErrInternal) on errors reporting a recovered panic.
+func (internalError *InternalError) Unwrap() error {
+	return ErrInternal
+}
+
+// recoverInternalError, when deferred by op, converts a panic into an *InternalError returned via errPtr.
+func recoverInternalError(op string, errPtr *error) {
+	var (
+		panicValue interface{}
+	)
+
+	panicValue = recover()
+	if nil != panicValue {
+		*errPtr = &InternalError{Op: op, Panic: panicValue}
+	}
+}
@@ -0,0 +1,189 @@
+package xdr
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+)
+
+type FuzzStruct struct {
+	Hypers   [2]int64             `XDR_Name:"Fixed-Length Array"`
+	Names    []string             `XDR_Name:"Variable-Length Array" XDR_MaxSize:"4"`
+	Blobs    [][]byte             `XDR_Name:"Variable-Length Array"`
+	Children []ChildStruct        `XDR_Name:"Variable-Length Array" XDR_MaxSize:"3"`
+	Nested   [2][]uint32          `XDR_Name:"Fixed-Length Array"`
+	Opaque   [3]byte              `XDR_Name:"Fixed-Length Opaque Data"`
+	Parents  []ParentStruct       `XDR_Name:"Variable-Length Array" XDR_MaxSize:"1"`
+	Flag     bool                 `XDR_Name:"Boolean"`
+	Elements []ArrayElementStruct `XDR_Name:"Variable-Length Array"`
+}
+
+// fuzzTypes are the types FuzzUnpack() decodes into; a seed corpus entry selects one via its typeIndex.
+var fuzzTypes = []reflect.Type{
+	reflect.TypeOf(ParentStruct{}),
+	reflect.TypeOf(LimitsStruct{}),
+	reflect.TypeOf(ArrayElementStruct{}),
+	reflect.TypeOf(FuzzStruct{}),
+}
+
+// FuzzUnpack asserts Unpack() never panics, that Validate() agrees with it, and that anything it successfully
+// decodes re-Packs to identical bytes.
+func FuzzUnpack(f *testing.F) {
+	f.Add(uint8(0), goodParentStructPacked)
+	f.Add(uint8(0), badParentStructPacked)
+
+	f.Fuzz(func(t *testing.T, typeIndex uint8, src []byte) {
+		var (
+			bytesConsumed uint64
+			dstValueOf    reflect.Value
+			err           error
+			repacked      []byte
+		)
+
+		dstValueOf = reflect.New(fuzzTypes[int(typeIndex)%len(fuzzTypes)])
+
+		validateAgreesWithUnpack(t, src, dstValueOf.Type().Elem())
+
+		bytesConsumed, err = UnpackWithOptions(src, dstValueOf.Interface(), &UnpackOptions{Limits: Limits{MaxAllocation: 1 << 20}})
+		if nil != err {
+			return
+		}
+
+		repacked, err = Pack(dstValueOf.Interface())
+		if nil != err {
+			t.Fatalf("Pack() of successfully Unpack()'d %v received unexpected error: %v", dstValueOf.Type(), err)
+		}
+		if 0 != bytes.Compare(src[:bytesConsumed], repacked) {
+			t.Fatalf("Pack() of successfully Unpack()'d %v returned 0x%X - should have been 0x%X", dstValueOf.Type(), repacked, src[:bytesConsumed])
+		}
+	})
+}
+
+// TestRoundTripProperty asserts Unpack(Pack(v)) == v for randomly generated values of each of fuzzTypes.
+func TestRoundTripProperty(t *testing.T) {
+	var (
+		err      error
+		typeOf   reflect.Type
+		typesIdx int
+	)
+
+	for typesIdx = range fuzzTypes {
+		typeOf = fuzzTypes[typesIdx]
+
+		err = quick.Check(
+			func(v interface{}) bool { return roundTrips(t, v) },
+			&quick.Config{
+				MaxCount: 200,
+				Values: func(args []reflect.Value, r *rand.Rand) {
+					args[0] = randomValueRecursive(r, typeOf, 0)
+				},
+			})
+		if nil != err {
+			t.Fatalf("Unpack(Pack(v)) != v for %v: %v", typeOf, err)
+		}
+	}
+}
+
+func roundTrips(t *testing.T, v interface{}) bool {
+	var (
+		bytesConsumed uint64
+		bytesNeeded   uint64
+		dstValueOf    reflect.Value
+		err           error
+		packed        []byte
+	)
+
+	bytesNeeded, err = Examine(v)
+	if nil != err {
+		t.Logf("Examine(%#v) received unexpected error: %v", v, err)
+		return false
+	}
+
+	packed, err = Pack(v)
+	if nil != err {
+		t.Logf("Pack(%#v) received unexpected error: %v", v, err)
+		return false
+	}
+	if bytesNeeded != uint64(len(packed)) {
+		t.Logf("Examine(%#v) returned 0x%X - but Pack() returned 0x%X bytes", v, bytesNeeded, len(packed))
+		return false
+	}
+
+	dstValueOf = reflect.New(reflect.TypeOf(v))
+
+	bytesConsumed, err = Unpack(packed, dstValueOf.Interface())
+	if nil != err {
+		t.Logf("Unpack(Pack(%#v)) received unexpected error: %v", v, err)
+		return false
+	}
+	if bytesConsumed != uint64(len(packed)) {
+		t.Logf("Unpack(Pack(%#v)) consumed 0x%X of 0x%X bytes", v, bytesConsumed, len(packed))
+		return false
+	}
+
+	return reflect.DeepEqual(v, dstValueOf.Elem().Interface())
+}
+
+// randomValueRecursive generates a random value of typeOf honoring maxSize (and, for structs, XDR_MaxSize tags).
+func randomValueRecursive(r *rand.Rand, typeOf reflect.Type, maxSize uint64) (valueOf reflect.Value) {
+	var (
+		err        error
+		fieldMax   uint64
+		i          int
+		length     int
+		maxSizeTag string
+	)
+
+	valueOf = reflect.New(typeOf).Elem()
+
+	length = 4
+	if (0 != maxSize) && (uint64(length) > maxSize) {
+		length = int(maxSize)
+	}
+	length = r.Intn(length + 1)
+
+	switch typeOf.Kind() {
+	case reflect.Bool:
+		valueOf.SetBool(0 == r.Intn(2))
+	case reflect.Int32:
+		valueOf.SetInt(int64(int32(r.Uint32())))
+	case reflect.Int64:
+		valueOf.SetInt(int64(r.Uint64()))
+	case reflect.Uint8:
+		valueOf.SetUint(uint64(r.Intn(0x100)))
+	case reflect.Uint32:
+		valueOf.SetUint(uint64(r.Uint32()))
+	case reflect.Uint64:
+		valueOf.SetUint(r.Uint64())
+	case reflect.String:
+		valueOf.SetString(string(randomValueRecursive(r, reflect.TypeOf([]byte{}), maxSize).Bytes()))
+	case reflect.Array:
+		for i = 0; i < typeOf.Len(); i++ {
+			valueOf.Index(i).Set(randomValueRecursive(r, typeOf.Elem(), 0))
+		}
+	case reflect.Slice:
+		valueOf.Set(reflect.MakeSlice(typeOf, length, length))
+		for i = 0; i < length; i++ {
+			valueOf.Index(i).Set(randomValueRecursive(r, typeOf.Elem(), 0))
+		}
+	case reflect.Struct:
+		for i = 0; i < typeOf.NumField(); i++ {
+			fieldMax = 0
+			maxSizeTag = typeOf.Field(i).Tag.Get("XDR_MaxSize")
+			if "" != maxSizeTag {
+				fieldMax, err = strconv.ParseUint(maxSizeTag, 10, 64)
+				if nil != err {
+					panic(err)
+				}
+			}
+			valueOf.Field(i).Set(randomValueRecursive(r, typeOf.Field(i).Type, fieldMax))
+		}
+	default:
+		panic("randomValueRecursive() passed unsupported type " + typeOf.String())
+	}
+
+	return
+}
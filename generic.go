@@ -0,0 +1,86 @@
+package xdr
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Marshal is the type-safe equivalent of Pack().
+func Marshal[T any](v T) (dst []byte, err error) {
+	dst, err = Pack(&v)
+	return
+}
+
+// Unmarshal is the type-safe equivalent of Unpack() returning the decoded value rather than filling one in.
+func Unmarshal[T any](src []byte) (v T, bytesConsumed uint64, err error) {
+	bytesConsumed, err = Unpack(src, &v)
+	return
+}
+
+// Codec packs and unpacks values of type T (just as do Pack() and UnpackWithOptions()), except that NewCodec() has
+// reported any tag error in T (and every type reachable from it) when the Codec was constructed.
+type Codec[T any] struct {
+	options UnpackOptions
+}
+
+// NewCodec returns a Codec for type T that unpacks as directed by options (which may be nil).
+//
+// Any tag error in T (including in the element types of slices that might be empty) is reported now.
+func NewCodec[T any](options *UnpackOptions) (codec *Codec[T], err error) {
+	var (
+		typeOf reflect.Type
+	)
+
+	typeOf = reflect.TypeOf((*T)(nil)).Elem()
+
+	err = checkTypeRecursive(typeOf, xdrTag{}, make(map[reflect.Type]bool))
+	if nil != err {
+		err = fmt.Errorf("NewCodec[%v]() found tag error: %w", typeOf, err)
+		return
+	}
+
+	codec = &Codec[T]{}
+	if nil != options {
+		codec.options = *options
+	}
+
+	return
+}
+
+// MustNewCodec is like NewCodec() but panics upon finding a tag error (e.g. for use initializing a package variable).
+func MustNewCodec[T any](options *UnpackOptions) (codec *Codec[T]) {
+	var (
+		err error
+	)
+
+	codec, err = NewCodec[T](options)
+	if nil != err {
+		panic(err)
+	}
+
+	return
+}
+
+// Examine returns the size of the []byte needed by Marshal() to pack v.
+func (codec *Codec[T]) Examine(v T) (bytesNeeded uint64, err error) {
+	bytesNeeded, err = Examine(&v)
+	return
+}
+
+// Marshal packs v.
+func (codec *Codec[T]) Marshal(v T) (dst []byte, err error) {
+	dst, err = Pack(&v)
+	return
+}
+
+// Unmarshal unpacks a value of type T from src.
+func (codec *Codec[T]) Unmarshal(src []byte) (v T, bytesConsumed uint64, err error) {
+	bytesConsumed, err = UnpackWithOptions(src, &v, &codec.options)
+	return
+}
+
+// UnmarshalInto unpacks from src into *dst (reusing whatever non-nil pointers *dst already holds).
+func (codec *Codec[T]) UnmarshalInto(src []byte, dst *T) (bytesConsumed uint64, err error) {
+	bytesConsumed, err = UnpackWithOptions(src, dst, &codec.options)
+	return
+}
@@ -0,0 +1,123 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	var (
+		bytesConsumed        uint64
+		err                  error
+		parentStructPacked   []byte
+		parentStructReturned ParentStruct
+		u32                  uint32
+	)
+
+	parentStructPacked, err = Marshal(goodParentStruct)
+	if nil != err {
+		t.Fatalf("Marshal(goodParentStruct) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(parentStructPacked, goodParentStructPacked) {
+		t.Fatalf("Marshal(goodParentStruct) returned unexpected 0x%X", parentStructPacked)
+	}
+
+	parentStructReturned, bytesConsumed, err = Unmarshal[ParentStruct](parentStructPacked)
+	if nil != err {
+		t.Fatalf("Unmarshal[ParentStruct]() received unexpected error: %v", err)
+	}
+	if uint64(len(parentStructPacked)) != bytesConsumed {
+		t.Fatalf("Unmarshal[ParentStruct]() returned unexpected bytesConsumed: %v", bytesConsumed)
+	}
+	if !reflect.DeepEqual(goodParentStruct, parentStructReturned) {
+		t.Fatalf("Unmarshal[ParentStruct]() returned unexpected parentStructReturned: %+v", parentStructReturned)
+	}
+
+	u32, _, err = Unmarshal[uint32]([]byte{0x01, 0x02, 0x03, 0x04})
+	if nil != err {
+		t.Fatalf("Unmarshal[uint32]() received unexpected error: %v", err)
+	}
+	if 0x01020304 != u32 {
+		t.Fatalf("Unmarshal[uint32]() returned unexpected u32: 0x%X", u32)
+	}
+
+	_, _, err = Unmarshal[ParentStruct](parentStructPacked[:8])
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Unmarshal[ParentStruct](<truncated>) returned unexpected error: %v", err)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	var (
+		bytesNeeded          uint64
+		codec                *Codec[ParentStruct]
+		err                  error
+		panicValue           interface{}
+		parentStructPacked   []byte
+		parentStructReturned ParentStruct
+	)
+
+	codec, err = NewCodec[ParentStruct](nil)
+	if nil != err {
+		t.Fatalf("NewCodec[ParentStruct](nil) received unexpected error: %v", err)
+	}
+
+	bytesNeeded, err = codec.Examine(goodParentStruct)
+	if nil != err {
+		t.Fatalf("codec.Examine(goodParentStruct) received unexpected error: %v", err)
+	}
+	if uint64(len(goodParentStructPacked)) != bytesNeeded {
+		t.Fatalf("codec.Examine(goodParentStruct) returned unexpected bytesNeeded: %v", bytesNeeded)
+	}
+
+	parentStructPacked, err = codec.Marshal(goodParentStruct)
+	if nil != err {
+		t.Fatalf("codec.Marshal(goodParentStruct) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(parentStructPacked, goodParentStructPacked) {
+		t.Fatalf("codec.Marshal(goodParentStruct) returned unexpected 0x%X", parentStructPacked)
+	}
+
+	parentStructReturned, _, err = codec.Unmarshal(parentStructPacked)
+	if nil != err {
+		t.Fatalf("codec.Unmarshal() received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(goodParentStruct, parentStructReturned) {
+		t.Fatalf("codec.Unmarshal() returned unexpected parentStructReturned: %+v", parentStructReturned)
+	}
+
+	parentStructReturned = ParentStruct{}
+	_, err = codec.UnmarshalInto(parentStructPacked, &parentStructReturned)
+	if nil != err {
+		t.Fatalf("codec.UnmarshalInto() received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(goodParentStruct, parentStructReturned) {
+		t.Fatalf("codec.UnmarshalInto() returned unexpected parentStructReturned: %+v", parentStructReturned)
+	}
+
+	codec, err = NewCodec[ParentStruct](&UnpackOptions{Mode: UnpackModeStrict})
+	if nil != err {
+		t.Fatalf("NewCodec[ParentStruct](<UnpackModeStrict>) received unexpected error: %v", err)
+	}
+	_, _, err = codec.Unmarshal(append(parentStructPacked, 0x00, 0x00, 0x00, 0x00))
+	if !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("codec.Unmarshal(<trailing bytes>) returned unexpected error: %v", err)
+	}
+
+	_, err = NewCodec[EmptySliceOfBadlyTaggedStruct](nil)
+	if nil == err {
+		t.Fatalf("NewCodec[EmptySliceOfBadlyTaggedStruct](nil) should have failed")
+	}
+
+	func() {
+		defer func() {
+			panicValue = recover()
+		}()
+		_ = MustNewCodec[map[string]int](nil)
+	}()
+	if nil == panicValue {
+		t.Fatalf("MustNewCodec[map[string]int](nil) should have panicked")
+	}
+}
@@ -0,0 +1,393 @@
+package xdr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"hash"
+	"io"
+	"reflect"
+)
+
+// packStreamBufferSize is the size of the buffer through which a packStream writes to its io.Writer.
+const packStreamBufferSize = 4096
+
+// packStream accumulates the encoding of a value (byte for byte that of Pack()) as it is written to an io.Writer.
+type packStream struct {
+	writer       *bufio.Writer
+	bytesWritten uint64
+	armLengths   []uint64 // The length of each wrapped union arm (as recorded by examineRecursive()) yet to be written
+	scratch      [8]byte  // Holds the encoding of a scalar (as produced by packRecursive()) on its way to writer
+}
+
+// errEncodingsDiffer is returned by an encodingComparator upon finding the two encodings differ.
+var errEncodingsDiffer = errors.New("encodings differ")
+
+// encodingComparator is an io.Writer comparing the bytes written to it with those read from reader.
+type encodingComparator struct {
+	reader io.Reader
+	buf    [packStreamBufferSize]byte
+}
+
+// Hash writes the encoding of the supplied struct (passed by value or reference) to hasher (e.g. sha256.New())
+// without building a []byte, so the hash of even a value too large to materialize may be computed. As XDR is
+// canonical, hasher then yields the same sum as would hashing the result of Pack() (which fails in the same way).
+//
+// Hash() neither resets hasher beforehand nor calls hasher.Sum() afterwards (and, should it fail after Examine()
+// succeeded, hasher may have been written a portion of the encoding).
+func Hash(srcObjIF interface{}, hasher hash.Hash) (err error) {
+	err = HashWithOptions(srcObjIF, hasher, &PackOptions{})
+
+	return
+}
+
+// HashWithOptions is like Hash() but writes the encoding of the supplied struct as would PackWithOptions() given
+// options (which may be nil).
+func HashWithOptions(srcObjIF interface{}, hasher hash.Hash, options *PackOptions) (err error) {
+	err = packToWriter("Hash", srcObjIF, hasher, options)
+
+	return
+}
+
+// Equal reports whether the supplied structs (passed by value or reference) have identical encodings (i.e.
+// whether Pack() of each would return the same []byte), comparing them as they are encoded rather than building
+// a []byte for either. An error is returned if either could not be packed.
+func Equal(aObjIF interface{}, bObjIF interface{}) (equal bool, err error) {
+	equal, err = EqualWithOptions(aObjIF, bObjIF, &PackOptions{})
+
+	return
+}
+
+// EqualWithOptions is like Equal() but compares the encodings of the supplied structs as would PackWithOptions()
+// given options (which may be nil), so an error is returned if either could not be packed as directed by options.
+func EqualWithOptions(aObjIF interface{}, bObjIF interface{}, options *PackOptions) (equal bool, err error) {
+	var (
+		aBytesNeeded uint64
+		aErr         error
+		aErrChan     chan error
+		bBytesNeeded uint64
+		comparator   *encodingComparator
+		pipeReader   *io.PipeReader
+		pipeWriter   *io.PipeWriter
+	)
+
+	aBytesNeeded, err = ExamineWithOptions(aObjIF, options)
+	if nil != err {
+		return
+	}
+	bBytesNeeded, err = ExamineWithOptions(bObjIF, options)
+	if nil != err {
+		return
+	}
+
+	if aBytesNeeded != bBytesNeeded {
+		equal = false
+		return
+	}
+
+	// Stream the encoding of aObjIF through a pipe, comparing it to that of bObjIF as the latter is produced
+
+	pipeReader, pipeWriter = io.Pipe()
+	aErrChan = make(chan error, 1)
+
+	go func() {
+		var (
+			err error
+		)
+
+		err = packToWriter("Equal", aObjIF, pipeWriter, options)
+		_ = pipeWriter.CloseWithError(err)
+		aErrChan <- err
+	}()
+
+	comparator = &encodingComparator{reader: pipeReader}
+
+	err = packToWriter("Equal", bObjIF, comparator, options)
+
+	_ = pipeReader.CloseWithError(errEncodingsDiffer) // Note: Unblocks the packing of aObjIF should it be incomplete
+	aErr = <-aErrChan
+
+	switch {
+	case errors.Is(err, errEncodingsDiffer):
+		equal = false
+		err = nil
+	case nil != err:
+		equal = false
+	case (nil != aErr) && !errors.Is(aErr, errEncodingsDiffer) && !errors.Is(aErr, io.ErrClosedPipe):
+		equal = false
+		err = aErr
+	default:
+		equal = true
+	}
+
+	return
+}
+
+// packToWriter writes the encoding of srcObjIF (byte for byte that of PackWithOptions() given options) to writer.
+//
+// The single examineRecursive() pass sizing srcObjIF also records the length of each wrapped union arm, as the
+// length preceding an arm must be written before the arm itself (rather than filled in afterwards as by Pack()).
+func packToWriter(op string, srcObjIF interface{}, writer io.Writer, options *PackOptions) (err error) {
+	var (
+		bytesNeeded   uint64
+		srcObjValueOf reflect.Value
+		state         *examineState
+		stream        *packStream
+	)
+
+	defer recoverInternalError(op, &err)
+
+	if nil == options {
+		options = &PackOptions{}
+	}
+
+	srcObjValueOf = reflect.ValueOf(srcObjIF)
+
+	state = &examineState{options: options, checkValues: true, recordArmLengths: true}
+
+	bytesNeeded, err = examineRecursive(srcObjValueOf, xdrTag{}, 0, state)
+	if nil != err {
+		return
+	}
+
+	stream = &packStream{writer: bufio.NewWriterSize(writer, packStreamBufferSize), armLengths: state.armLengths}
+
+	err = stream.packRecursive(srcObjValueOf, xdrTag{})
+	if nil != err {
+		return
+	}
+
+	err = stream.writer.Flush()
+	if nil != err {
+		return
+	}
+
+	if stream.bytesWritten != bytesNeeded {
+		err = newPackError(stream.bytesWritten, ErrSizeMismatch, "packed 0x%X bytes but Examine() computed 0x%X", stream.bytesWritten, bytesNeeded)
+		return
+	}
+
+	return
+}
+
+// packRecursive mirrors the function of the same name, but writes (rather than stores) the encoding of srcObjValueOf.
+//
+// Scalars are encoded by the latter function itself (via stream.scratch), while Variable-Length Opaque Data and
+// Strings are written directly from the value holding them (rather than copied). The length wrapping each wrapped
+// union arm is taken, in turn, from stream.armLengths.
+func (stream *packStream) packRecursive(srcObjValueOf reflect.Value, tag xdrTag) (err error) {
+	var (
+		armField     *xdrField
+		discriminant int32
+		field        xdrField
+		i            int
+		n            uint64
+		ok           bool
+		registration *interfaceRegistration
+		structLayout *xdrStructLayout
+	)
+
+	switch srcObjValueOf.Kind() {
+	case reflect.Interface:
+		if srcObjValueOf.IsNil() {
+			err = newPackError(stream.bytesWritten, ErrInternal, "srcObjValueOf is a nil %v", srcObjValueOf.Type())
+			return
+		}
+		registration, ok = lookupInterface(srcObjValueOf.Type())
+		if !ok {
+			err = stream.packRecursive(srcObjValueOf.Elem(), tag)
+			return
+		}
+		discriminant, ok = registration.discriminants[srcObjValueOf.Elem().Type()]
+		if !ok {
+			err = newPackError(stream.bytesWritten, ErrInternal, "srcObjValueOf %v holds unregistered %v", srcObjValueOf.Type(), srcObjValueOf.Elem().Type())
+			return
+		}
+		err = stream.writeWord(uint64(uint32(discriminant)))
+		if nil != err {
+			return
+		}
+		err = stream.packRecursive(srcObjValueOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize})
+	case reflect.Ptr:
+		if tag.optional {
+			if srcObjValueOf.IsNil() {
+				err = stream.writeWord(0)
+				return
+			}
+			err = stream.writeWord(1)
+			if nil != err {
+				return
+			}
+			err = stream.packRecursive(srcObjValueOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize})
+		} else {
+			if srcObjValueOf.IsNil() {
+				err = newPackError(stream.bytesWritten, ErrInternal, "srcObjValueOf is a nil %v (only Optional-Data may be nil)", srcObjValueOf.Type())
+				return
+			}
+			err = stream.packRecursive(srcObjValueOf.Elem(), tag)
+		}
+	case reflect.Array:
+		if reflect.Uint8 == srcObjValueOf.Type().Elem().Kind() {
+			for i = 0; i < srcObjValueOf.Len(); i++ {
+				err = stream.writer.WriteByte(byte(srcObjValueOf.Index(i).Uint()))
+				if nil != err {
+					return
+				}
+			}
+			stream.bytesWritten += uint64(srcObjValueOf.Len())
+			err = stream.writePadding(uint64(srcObjValueOf.Len()))
+		} else {
+			for i = 0; i < srcObjValueOf.Len(); i++ {
+				err = stream.packRecursive(srcObjValueOf.Index(i), xdrTag{})
+				if nil != err {
+					return
+				}
+			}
+		}
+	case reflect.Slice:
+		err = stream.writeWord(uint64(srcObjValueOf.Len()))
+		if nil != err {
+			return
+		}
+		if reflect.Uint8 == srcObjValueOf.Type().Elem().Kind() {
+			_, err = stream.writer.Write(srcObjValueOf.Bytes())
+			if nil != err {
+				return
+			}
+			stream.bytesWritten += uint64(srcObjValueOf.Len())
+			err = stream.writePadding(uint64(srcObjValueOf.Len()))
+		} else {
+			for i = 0; i < srcObjValueOf.Len(); i++ {
+				err = stream.packRecursive(srcObjValueOf.Index(i), xdrTag{})
+				if nil != err {
+					return
+				}
+			}
+		}
+	case reflect.String:
+		err = stream.writeWord(uint64(srcObjValueOf.Len()))
+		if nil != err {
+			return
+		}
+		_, err = stream.writer.WriteString(srcObjValueOf.String())
+		if nil != err {
+			return
+		}
+		stream.bytesWritten += uint64(srcObjValueOf.Len())
+		err = stream.writePadding(uint64(srcObjValueOf.Len()))
+	case reflect.Struct:
+		structLayout, err = xdrStructLayoutOf(srcObjValueOf.Type())
+		if nil != err {
+			return
+		}
+		if structLayout.isUnion {
+			field = structLayout.fields[0]
+			err = stream.packRecursive(srcObjValueOf.FieldByIndex(field.index), field.tag)
+			if nil != err {
+				return
+			}
+			armField, ok = selectUnionArm(structLayout, discriminantOf(srcObjValueOf.FieldByIndex(field.index)))
+			if !ok {
+				err = newPackError(stream.bytesWritten, ErrInvalidDiscriminant, "no arm of %v selected by discriminant %v", srcObjValueOf.Type(), discriminantOf(srcObjValueOf.FieldByIndex(field.index)))
+				return
+			}
+			if structLayout.isWrapped && !armField.tag.isUnknown {
+				if 0 == len(stream.armLengths) {
+					err = newPackError(stream.bytesWritten, ErrInternal, "no length recorded for the arm of %v", srcObjValueOf.Type())
+					return
+				}
+				err = stream.writeWord(stream.armLengths[0])
+				if nil != err {
+					return
+				}
+				stream.armLengths = stream.armLengths[1:]
+			}
+			err = stream.packRecursive(srcObjValueOf.FieldByIndex(armField.index), armField.tag)
+		} else {
+			for _, field = range structLayout.fields {
+				err = stream.packRecursive(srcObjValueOf.FieldByIndex(field.index), field.tag)
+				if nil != err {
+					return
+				}
+			}
+		}
+	default:
+		n, err = packRecursive(srcObjValueOf, tag, stream.scratch[:], 0)
+		if nil != err {
+			return
+		}
+		_, err = stream.writer.Write(stream.scratch[:n])
+		if nil != err {
+			return
+		}
+		stream.bytesWritten += n
+	}
+
+	return
+}
+
+// writeWord writes u64 (which must fit in 32 bits) as an Unsigned Integer.
+func (stream *packStream) writeWord(u64 uint64) (err error) {
+	stream.scratch[0] = byte(u64 >> 24)
+	stream.scratch[1] = byte(u64 >> 16)
+	stream.scratch[2] = byte(u64 >> 8)
+	stream.scratch[3] = byte(u64)
+
+	_, err = stream.writer.Write(stream.scratch[:4])
+	if nil != err {
+		return
+	}
+
+	stream.bytesWritten += 4
+
+	return
+}
+
+// writePadding writes the zero pad bytes following length bytes of opaque or string data.
+func (stream *packStream) writePadding(length uint64) (err error) {
+	var (
+		padLength uint64
+	)
+
+	padLength = (4 - (length % 4)) % 4
+
+	stream.scratch = [8]byte{}
+
+	_, err = stream.writer.Write(stream.scratch[:padLength])
+	if nil != err {
+		return
+	}
+
+	stream.bytesWritten += padLength
+
+	return
+}
+
+// Write compares p with the next len(p) bytes read from comparator.reader (failing with errEncodingsDiffer if they
+// differ).
+func (comparator *encodingComparator) Write(p []byte) (n int, err error) {
+	var (
+		chunkLength int
+	)
+
+	for n < len(p) {
+		chunkLength = len(p) - n
+		if len(comparator.buf) < chunkLength {
+			chunkLength = len(comparator.buf)
+		}
+		_, err = io.ReadFull(comparator.reader, comparator.buf[:chunkLength])
+		if nil != err {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				err = errEncodingsDiffer
+			}
+			return
+		}
+		if !bytes.Equal(p[n:n+chunkLength], comparator.buf[:chunkLength]) {
+			err = errEncodingsDiffer
+			return
+		}
+		n += chunkLength
+	}
+
+	return
+}
@@ -0,0 +1,165 @@
+package xdr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"testing"
+)
+
+type HashStruct struct {
+	Name   string      `xdr:"string"`
+	Blob   []byte      `xdr:""`
+	Digest [5]byte     `xdr:""`
+	Values []int32     `xdr:""`
+	Next   *HashStruct `xdr:"optional"`
+}
+
+type NestedExtensionStruct struct {
+	Type     int32                   `xdr:""`
+	Elements []NestedExtensionStruct `xdr:"case=1"`
+	Flags    *uint32                 `xdr:"case=2"`
+	Unknown  []byte                  `xdr:"unknown"`
+}
+
+func TestHash(t *testing.T) {
+	var (
+		err       error
+		hasher    hash.Hash
+		packed    []byte
+		s         string
+		srcObjIF  interface{}
+		srcObjIFs []interface{}
+		u32       uint32
+	)
+
+	hasher = sha256.New()
+	s = "abc"
+	u32 = 7
+
+	srcObjIFs = []interface{}{
+		goodParentStruct,
+		goodParentStructPtr,
+		UnionStruct{Discriminant: 2, String: &s},
+		ExtensionStruct{Type: 2, Flags: &u32},
+		ExtensionStruct{Type: 9, Unknown: []byte("xyz")},
+		CallHeader{XID: 5, Cred: &AuthSys{Stamp: 7, MachineName: "host", UID: 1000}, Verf: AuthNone{}},
+		HashStruct{Name: "a", Blob: bytes.Repeat([]byte{0x5A}, 3*packStreamBufferSize+1), Values: []int32{-1, 2}, Next: &HashStruct{Digest: [5]byte{1, 2, 3, 4, 5}}},
+		NestedExtensionStruct{Type: 1, Elements: []NestedExtensionStruct{
+			{Type: 1, Elements: []NestedExtensionStruct{{Type: 2, Flags: &u32}, {Type: 9, Unknown: []byte("xyz")}}},
+			{Type: 2, Flags: &u32},
+			{Type: 1, Elements: []NestedExtensionStruct{}},
+		}},
+	}
+
+	// Verify Hash() writes exactly the bytes returned by Pack()
+
+	for _, srcObjIF = range srcObjIFs {
+		packed = mustPack(t, srcObjIF)
+		hasher.Reset()
+		err = Hash(srcObjIF, hasher)
+		if (nil != err) || !bytes.Equal(hasher.Sum(nil), sha256Sum(packed)) {
+			t.Fatalf("Hash(%T) disagreed with Pack() or received unexpected error: %v", srcObjIF, err)
+		}
+	}
+
+	// Verify Hash() fails as Pack() does
+
+	err = Hash(UnionWithoutDefaultStruct{Discriminant: 9}, hasher)
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("Hash(<unselected discriminant>) returned unexpected error: %v", err)
+	}
+	err = Hash(nil, hasher)
+	if nil == err {
+		t.Fatalf("Hash(nil) should have failed")
+	}
+
+	// Verify HashWithOptions() applies options as PackWithOptions() does
+
+	err = HashWithOptions(goodParentStruct, hasher, &PackOptions{MaxDepth: 1})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("HashWithOptions(<too deep>, MaxDepth: 1) returned unexpected error: %v", err)
+	}
+	hasher.Reset()
+	err = HashWithOptions(goodParentStruct, hasher, nil)
+	if (nil != err) || !bytes.Equal(hasher.Sum(nil), sha256Sum(mustPack(t, goodParentStruct))) {
+		t.Fatalf("HashWithOptions(goodParentStruct, nil) disagreed with Pack() or received unexpected error: %v", err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	var (
+		a     HashStruct
+		b     HashStruct
+		err   error
+		equal bool
+	)
+
+	a = HashStruct{Name: "a", Blob: bytes.Repeat([]byte{0x5A}, 3*packStreamBufferSize+1), Values: []int32{-1, 2}}
+	b = HashStruct{Name: "a", Blob: bytes.Repeat([]byte{0x5A}, 3*packStreamBufferSize+1), Values: []int32{-1, 2}}
+
+	equal, err = Equal(a, &b)
+	if (nil != err) || !equal {
+		t.Fatalf("Equal(a, &b) returned %v (expected true) or unexpected error: %v", equal, err)
+	}
+
+	// Verify a difference is found wherever it lies (including after the first buffer's worth of the encoding)
+
+	b.Values[1] = 3
+
+	equal, err = Equal(a, b)
+	if (nil != err) || equal {
+		t.Fatalf("Equal(a, <b with different trailing Values>) returned %v (expected false) or unexpected error: %v", equal, err)
+	}
+
+	b.Values[1] = 2
+	b.Name = "b"
+
+	equal, err = Equal(a, b)
+	if (nil != err) || equal {
+		t.Fatalf("Equal(a, <b with different Name>) returned %v (expected false) or unexpected error: %v", equal, err)
+	}
+
+	b.Name = "a"
+	b.Next = &HashStruct{}
+
+	equal, err = Equal(a, b)
+	if (nil != err) || equal {
+		t.Fatalf("Equal(a, <b with longer encoding>) returned %v (expected false) or unexpected error: %v", equal, err)
+	}
+
+	// Verify distinct Go types with identical encodings are equal, and that unpackable values fail
+
+	equal, err = Equal(uint32(1), true)
+	if (nil != err) || !equal {
+		t.Fatalf("Equal(uint32(1), true) returned %v (expected true) or unexpected error: %v", equal, err)
+	}
+
+	_, err = Equal(a, UnionWithoutDefaultStruct{Discriminant: 9})
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("Equal(a, <unselected discriminant>) returned unexpected error: %v", err)
+	}
+
+	// Verify EqualWithOptions() applies options as PackWithOptions() does
+
+	_, err = EqualWithOptions(a, a, &PackOptions{MaxDepth: 1})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("EqualWithOptions(<too deep>, MaxDepth: 1) returned unexpected error: %v", err)
+	}
+	equal, err = EqualWithOptions(a, a, &PackOptions{MaxDepth: 2})
+	if (nil != err) || !equal {
+		t.Fatalf("EqualWithOptions(a, a, MaxDepth: 2) returned %v (expected true) or unexpected error: %v", equal, err)
+	}
+}
+
+func sha256Sum(b []byte) (sum []byte) {
+	var (
+		sum256 [sha256.Size]byte
+	)
+
+	sum256 = sha256.Sum256(b)
+	sum = sum256[:]
+
+	return
+}
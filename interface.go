@@ -0,0 +1,128 @@
+package xdr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// interfaceRegistration records the concrete types declared by RegisterInterface() for an interface type.
+type interfaceRegistration struct {
+	concreteTypes map[int32]reflect.Type // Keyed by the discriminant encoded ahead of a value of the concrete type
+	discriminants map[reflect.Type]int32 // The inverse of concreteTypes
+}
+
+var (
+	interfaceRegistryLock sync.RWMutex
+	interfaceRegistry     = make(map[reflect.Type]*interfaceRegistration)
+)
+
+// RegisterInterface declares the concrete types (keyed by discriminant) that a value of the interface type
+// pointed to by interfacePtrIF (e.g. (*Credential)(nil)) may hold, making a field of that interface type an
+// open Discriminated Union (e.g. of RPC authentication flavors) encoded as an Integer discriminant followed by
+// the value held:
+//
+//	err = xdr.RegisterInterface((*Credential)(nil), map[int32]interface{}{0: AuthNone{}, 1: &AuthSys{}})
+//
+// Pack() then rejects (with ErrInvalidDiscriminant) a value of a type not found in concreteTypes, as does
+// Unpack() a discriminant not found in concreteTypes. Otherwise, Unpack() replaces the value of the field with a
+// newly allocated one of the concrete type selected.
+func RegisterInterface(interfacePtrIF interface{}, concreteTypes map[int32]interface{}) (err error) {
+	var (
+		concreteIF        interface{}
+		concreteTypeOf    reflect.Type
+		discriminant      int32
+		interfaceTypeOf   reflect.Type
+		priorDiscriminant int32
+		registration      *interfaceRegistration
+		seen              bool
+	)
+
+	interfaceTypeOf = reflect.TypeOf(interfacePtrIF)
+
+	if (nil == interfaceTypeOf) || (reflect.Ptr != interfaceTypeOf.Kind()) || (reflect.Interface != interfaceTypeOf.Elem().Kind()) {
+		err = fmt.Errorf("RegisterInterface() passed %v (rather than a pointer to an interface type)", interfaceTypeOf)
+		return
+	}
+
+	interfaceTypeOf = interfaceTypeOf.Elem()
+
+	if 0 == len(concreteTypes) {
+		err = fmt.Errorf("RegisterInterface() passed no concrete types for interface type %v", interfaceTypeOf)
+		return
+	}
+
+	registration = &interfaceRegistration{
+		concreteTypes: make(map[int32]reflect.Type, len(concreteTypes)),
+		discriminants: make(map[reflect.Type]int32, len(concreteTypes)),
+	}
+
+	for discriminant, concreteIF = range concreteTypes {
+		concreteTypeOf = reflect.TypeOf(concreteIF)
+		if nil == concreteTypeOf {
+			err = fmt.Errorf("RegisterInterface() passed nil concrete type for discriminant %d of interface type %v", discriminant, interfaceTypeOf)
+			return
+		}
+		if !concreteTypeOf.Implements(interfaceTypeOf) {
+			err = fmt.Errorf("RegisterInterface() passed concrete type %v (for discriminant %d) not implementing interface type %v", concreteTypeOf, discriminant, interfaceTypeOf)
+			return
+		}
+		priorDiscriminant, seen = registration.discriminants[concreteTypeOf]
+		if seen {
+			err = fmt.Errorf("RegisterInterface() passed concrete type %v for both discriminants %d and %d of interface type %v", concreteTypeOf, priorDiscriminant, discriminant, interfaceTypeOf)
+			return
+		}
+		err = checkTypeRecursive(concreteTypeOf, xdrTag{}, make(map[reflect.Type]bool))
+		if nil != err {
+			err = fmt.Errorf("RegisterInterface() passed concrete type %v (for discriminant %d) of interface type %v: %v", concreteTypeOf, discriminant, interfaceTypeOf, err)
+			return
+		}
+		registration.concreteTypes[discriminant] = concreteTypeOf
+		registration.discriminants[concreteTypeOf] = discriminant
+	}
+
+	interfaceRegistryLock.Lock()
+	interfaceRegistry[interfaceTypeOf] = registration
+	interfaceRegistryLock.Unlock()
+
+	return
+}
+
+// sortedDiscriminants returns the discriminants of registration in ascending order.
+func (registration *interfaceRegistration) sortedDiscriminants() (discriminants []int32) {
+	var (
+		discriminant int32
+	)
+
+	discriminants = make([]int32, 0, len(registration.concreteTypes))
+	for discriminant = range registration.concreteTypes {
+		discriminants = append(discriminants, discriminant)
+	}
+
+	sort.Slice(discriminants, func(i, j int) bool { return discriminants[i] < discriminants[j] })
+
+	return
+}
+
+// concreteTypeName returns the name by which a Schema refers to the arm for concreteTypeOf (e.g. "AuthSys" for
+// *AuthSys).
+func concreteTypeName(concreteTypeOf reflect.Type) (name string) {
+	if reflect.Ptr == concreteTypeOf.Kind() {
+		concreteTypeOf = concreteTypeOf.Elem()
+	}
+
+	name = concreteTypeOf.Name()
+	if "" == name {
+		name = concreteTypeOf.String()
+	}
+
+	return
+}
+
+func lookupInterface(interfaceTypeOf reflect.Type) (registration *interfaceRegistration, ok bool) {
+	interfaceRegistryLock.RLock()
+	registration, ok = interfaceRegistry[interfaceTypeOf]
+	interfaceRegistryLock.RUnlock()
+	return
+}
@@ -0,0 +1,167 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Credential interface {
+	isCredential()
+}
+
+type AuthNone struct{}
+
+func (AuthNone) isCredential() {}
+
+type AuthSys struct {
+	Stamp       uint32 `xdr:""`
+	MachineName string `xdr:"string,max=255"`
+	UID         uint32 `xdr:""`
+}
+
+func (*AuthSys) isCredential() {}
+
+type AuthUnregistered struct{}
+
+func (AuthUnregistered) isCredential() {}
+
+type CallHeader struct {
+	XID  uint32     `xdr:""`
+	Cred Credential `xdr:""`
+	Verf Credential `xdr:""`
+}
+
+func init() {
+	var (
+		err error
+	)
+
+	err = RegisterInterface((*Credential)(nil), map[int32]interface{}{0: AuthNone{}, 1: &AuthSys{}})
+	if nil != err {
+		panic(err)
+	}
+}
+
+func TestRegisterInterface(t *testing.T) {
+	var (
+		err error
+	)
+
+	err = RegisterInterface(Credential(AuthNone{}), map[int32]interface{}{0: AuthNone{}})
+	if nil == err {
+		t.Fatalf("RegisterInterface(<not a pointer to an interface>, ...) should have failed")
+	}
+
+	err = RegisterInterface((*Credential)(nil), map[int32]interface{}{})
+	if nil == err {
+		t.Fatalf("RegisterInterface(..., <no concrete types>) should have failed")
+	}
+
+	err = RegisterInterface((*Credential)(nil), map[int32]interface{}{0: AuthSys{}})
+	if nil == err {
+		t.Fatalf("RegisterInterface(..., <type not implementing interface>) should have failed")
+	}
+
+	err = RegisterInterface((*Credential)(nil), map[int32]interface{}{0: AuthNone{}, 1: AuthNone{}})
+	if nil == err {
+		t.Fatalf("RegisterInterface(..., <type registered twice>) should have failed")
+	}
+
+	err = RegisterInterface((*Credential)(nil), map[int32]interface{}{0: nil})
+	if nil == err {
+		t.Fatalf("RegisterInterface(..., <nil concrete type>) should have failed")
+	}
+}
+
+func TestInterfaceFields(t *testing.T) {
+	var (
+		callHeader         CallHeader
+		callHeaderPacked   []byte
+		callHeaderReturned CallHeader
+		err                error
+		i                  int
+		jsonBytes          []byte
+		packed             []byte
+		schema             *Schema
+	)
+
+	callHeader = CallHeader{
+		XID:  5,
+		Cred: &AuthSys{Stamp: 7, MachineName: "host", UID: 1000},
+		Verf: AuthNone{},
+	}
+
+	callHeaderPacked = []byte{
+		0x00, 0x00, 0x00, 0x05, //                         XID: 5
+		0x00, 0x00, 0x00, 0x01, //                         Cred: discriminant 1 (*AuthSys)
+		0x00, 0x00, 0x00, 0x07, //                           Stamp: 7
+		0x00, 0x00, 0x00, 0x04, 'h', 'o', 's', 't', //       MachineName: "host"
+		0x00, 0x00, 0x03, 0xE8, //                           UID: 1000
+		0x00, 0x00, 0x00, 0x00, //                         Verf: discriminant 0 (AuthNone)
+	}
+
+	packed, err = Pack(callHeader)
+	if (nil != err) || !bytes.Equal(callHeaderPacked, packed) {
+		t.Fatalf("Pack(callHeader) returned 0x%X or unexpected error: %v", packed, err)
+	}
+
+	err = UnpackExact(packed, &callHeaderReturned)
+	if (nil != err) || !reflect.DeepEqual(callHeader, callHeaderReturned) {
+		t.Fatalf("UnpackExact(Pack(callHeader)) returned %#v or unexpected error: %v", callHeaderReturned, err)
+	}
+
+	// Verify Unpack() replaces (rather than reuses) a value of a concrete type other than that selected
+
+	callHeaderReturned.Verf = &AuthSys{}
+
+	err = UnpackExact(packed, &callHeaderReturned)
+	if (nil != err) || !reflect.DeepEqual(callHeader, callHeaderReturned) {
+		t.Fatalf("UnpackExact(Pack(callHeader)) into non-zero CallHeader returned %#v or unexpected error: %v", callHeaderReturned, err)
+	}
+
+	// Verify unregistered concrete types, nil values, and unregistered discriminants are rejected
+
+	_, err = Pack(CallHeader{Cred: AuthUnregistered{}, Verf: AuthNone{}})
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("Pack(<unregistered concrete type>) should have failed with ErrInvalidDiscriminant (got %v)", err)
+	}
+	_, err = Pack(CallHeader{Verf: AuthNone{}})
+	if nil == err {
+		t.Fatalf("Pack(<nil interface>) should have failed")
+	}
+
+	packed[7] = 0x02
+
+	_, err = Unpack(packed, &callHeaderReturned)
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("Unpack(<unregistered discriminant>) should have failed with ErrInvalidDiscriminant (got %v)", err)
+	}
+
+	// Verify Validate() & CheckTags() follow registered interfaces
+
+	err = CheckTags(CallHeader{})
+	if nil != err {
+		t.Fatalf("CheckTags(CallHeader{}) received unexpected error: %v", err)
+	}
+
+	for i = 0; i < len(callHeaderPacked); i++ {
+		validateAgreesWithUnpack(t, callHeaderPacked[:i], reflect.TypeOf(CallHeader{}))
+		packed = append([]byte{}, callHeaderPacked...)
+		packed[i] = 0x02
+		validateAgreesWithUnpack(t, packed, reflect.TypeOf(CallHeader{}))
+	}
+
+	// Verify a registered interface is described by a Schema (and so may be dumped or transcoded to JSON)
+
+	schema, err = schemaOfType(CallHeader{})
+	if (nil != err) || (SchemaUnion != schema.Fields[1].Type.Kind) || (2 != len(schema.Fields[1].Type.Arms)) || ("AuthSys" != schema.Fields[1].Type.Arms[1].Field.Name) {
+		t.Fatalf("schemaOfType(CallHeader{}) returned unexpected schema or error: %v", err)
+	}
+
+	jsonBytes, err = ToJSON(callHeaderPacked, CallHeader{})
+	if (nil != err) || (`{"XID":5,"Cred":{"tag":1,"value":{"Stamp":7,"MachineName":"host","UID":1000}},"Verf":{"tag":0,"value":{}}}` != string(jsonBytes)) {
+		t.Fatalf("ToJSON(callHeaderPacked) returned %s or unexpected error: %v", jsonBytes, err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package xdrtype implements the command line flags by which the commands of package xdr select the type of the
+// XDR data they process: either a type defined in an RFC 4506 (.x) specification or a Go type declared in Go source.
+package xdrtype
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/swiftstack/xdr"
+)
+
+// Synopsis describes the flags registered by AddFlags() for use in a usage message.
+const Synopsis = "{-x file.x | -go file.go[,file.go...]} -type Name"
+
+// Flags holds the values of the flags registered by AddFlags().
+type Flags struct {
+	XPath    string // RFC 4506 (.x) file defining TypeName
+	GoPaths  string // Comma separated Go source files (of a single package) declaring TypeName
+	TypeName string
+}
+
+// AddFlags registers the -x, -go, and -type flags with flagSet.
+func AddFlags(flagSet *flag.FlagSet) (flags *Flags) {
+	flags = &Flags{}
+
+	flagSet.StringVar(&flags.XPath, "x", "", "RFC 4506 (.x) `file` defining -type")
+	flagSet.StringVar(&flags.GoPaths, "go", "", "comma separated Go source `files` declaring -type")
+	flagSet.StringVar(&flags.TypeName, "type", "", "`name` of the type of the data")
+
+	return
+}
+
+// IsValid reports whether exactly one of -x and -go (along with -type) was specified.
+func (flags *Flags) IsValid() (isValid bool) {
+	isValid = (("" == flags.XPath) != ("" == flags.GoPaths)) && ("" != flags.TypeName)
+	return
+}
+
+// Type returns the type selected by flags as either a *xdr.Schema or a reflect.Type (as accepted by xdr.Dump()).
+func (flags *Flags) Type() (typ interface{}, err error) {
+	var (
+		ok      bool
+		schema  *xdr.Schema
+		schemas map[string]*xdr.Schema
+		xText   []byte
+	)
+
+	if "" == flags.XPath {
+		typ, err = GoSourceType(strings.Split(flags.GoPaths, ","), flags.TypeName)
+		return
+	}
+
+	xText, err = os.ReadFile(flags.XPath)
+	if nil != err {
+		return
+	}
+
+	schemas, err = xdr.ParseSchema(string(xText))
+	if nil != err {
+		err = fmt.Errorf("%s: %v", flags.XPath, err)
+		return
+	}
+
+	schema, ok = schemas[flags.TypeName]
+	if !ok {
+		err = fmt.Errorf("%s does not define type %s", flags.XPath, flags.TypeName)
+		return
+	}
+
+	typ = schema
+
+	return
+}
@@ -0,0 +1,228 @@
+package xdrtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+)
+
+// GoSourceType returns a reflect.Type encoded identically to the Go type typeName declared in the Go source files
+// at paths (all of which must belong to the same package).
+//
+// As types cannot be declared at run time, the returned reflect.Type is assembled from the type's structure (with
+// struct tags preserved, unexported fields omitted, and untagged embedded structs inlined). Recursive types,
+// and types lacking an XDR encoding (e.g. maps and floating point types), are not supported.
+func GoSourceType(paths []string, typeName string) (typeOf reflect.Type, err error) {
+	var (
+		config   types.Config
+		file     *ast.File
+		files    []*ast.File
+		fileSet  *token.FileSet
+		object   types.Object
+		ok       bool
+		path     string
+		pkg      *types.Package
+		typeErrs []error
+	)
+
+	fileSet = token.NewFileSet()
+
+	for _, path = range paths {
+		file, err = parser.ParseFile(fileSet, path, nil, 0)
+		if nil != err {
+			return
+		}
+		files = append(files, file)
+	}
+
+	// Type check leniently so that problems unrelated to typeName (e.g. an unresolvable import) are tolerated
+
+	config = types.Config{
+		Importer: importer.ForCompiler(fileSet, "source", nil),
+		Error:    func(err error) { typeErrs = append(typeErrs, err) },
+	}
+
+	pkg, _ = config.Check(files[0].Name.Name, fileSet, files, nil)
+
+	object = pkg.Scope().Lookup(typeName)
+	if nil == object {
+		err = fmt.Errorf("type %s is not declared in %v", typeName, paths)
+		if 0 < len(typeErrs) {
+			err = fmt.Errorf("%v (first type checking error: %v)", err, typeErrs[0])
+		}
+		return
+	}
+	_, ok = object.(*types.TypeName)
+	if !ok {
+		err = fmt.Errorf("%s is not a type", typeName)
+		return
+	}
+
+	typeOf, err = reflectTypeOf(object.Type(), make(map[*types.Named]bool))
+
+	return
+}
+
+// reflectTypeOf returns a reflect.Type equivalent to goType (see GoSourceType()).
+func reflectTypeOf(goType types.Type, inProgress map[*types.Named]bool) (typeOf reflect.Type, err error) {
+	var (
+		elemTypeOf reflect.Type
+		fields     []reflect.StructField
+		named      *types.Named
+		ok         bool
+	)
+
+	named, ok = goType.(*types.Named)
+	if ok {
+		if inProgress[named] {
+			err = fmt.Errorf("recursive type %s is not supported", named.Obj().Name())
+			return
+		}
+		inProgress[named] = true
+		typeOf, err = reflectTypeOf(named.Underlying(), inProgress)
+		delete(inProgress, named)
+		if nil != err {
+			err = fmt.Errorf("%s: %v", named.Obj().Name(), err)
+		}
+		return
+	}
+
+	switch t := goType.(type) {
+	case *types.Basic:
+		typeOf, ok = reflectBasicTypes[t.Kind()]
+		if !ok {
+			err = fmt.Errorf("%s is not supported", t.Name())
+		}
+	case *types.Array:
+		elemTypeOf, err = reflectTypeOf(t.Elem(), inProgress)
+		if nil == err {
+			typeOf = reflect.ArrayOf(int(t.Len()), elemTypeOf)
+		}
+	case *types.Slice:
+		elemTypeOf, err = reflectTypeOf(t.Elem(), inProgress)
+		if nil == err {
+			typeOf = reflect.SliceOf(elemTypeOf)
+		}
+	case *types.Pointer:
+		elemTypeOf, err = reflectTypeOf(t.Elem(), inProgress)
+		if nil == err {
+			typeOf = reflect.PointerTo(elemTypeOf)
+		}
+	case *types.Struct:
+		fields, err = appendReflectStructFields(nil, t, inProgress)
+		if nil != err {
+			return
+		}
+		typeOf, err = reflectStructOf(fields)
+	default:
+		err = fmt.Errorf("%s is not supported", goType)
+	}
+
+	return
+}
+
+// appendReflectStructFields appends the fields of structType as they would be encoded (see GoSourceType()) to fields.
+func appendReflectStructFields(fieldsIn []reflect.StructField, structType *types.Struct, inProgress map[*types.Named]bool) (fields []reflect.StructField, err error) {
+	var (
+		embeddedStruct *types.Struct
+		field          *types.Var
+		fieldIndex     int
+		fieldTypeOf    reflect.Type
+		ok             bool
+		tag            string
+	)
+
+	fields = fieldsIn
+
+	for fieldIndex = 0; fieldIndex < structType.NumFields(); fieldIndex++ {
+		field = structType.Field(fieldIndex)
+		tag = structType.Tag(fieldIndex)
+
+		if !field.Exported() && !field.Embedded() {
+			continue
+		}
+
+		if field.Embedded() && !hasXDRTag(reflect.StructTag(tag)) {
+			embeddedStruct, ok = field.Type().Underlying().(*types.Struct)
+			if ok {
+				fields, err = appendReflectStructFields(fields, embeddedStruct, inProgress)
+				if nil != err {
+					return
+				}
+				continue
+			}
+		}
+
+		if !field.Exported() {
+			continue
+		}
+
+		fieldTypeOf, err = reflectTypeOf(field.Type(), inProgress)
+		if nil != err {
+			err = fmt.Errorf("field %s: %v", field.Name(), err)
+			return
+		}
+
+		fields = append(fields, reflect.StructField{
+			Name: field.Name(),
+			Type: fieldTypeOf,
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+
+	return
+}
+
+// hasXDRTag reports whether tag includes any of the tags interpreted by package xdr (which prevent an embedded
+// struct from being flattened).
+func hasXDRTag(tag reflect.StructTag) (hasTag bool) {
+	var (
+		key string
+	)
+
+	for _, key = range []string{"xdr", "XDR_Name", "XDR_MaxSize", "XDR_Case"} {
+		_, hasTag = tag.Lookup(key)
+		if hasTag {
+			return
+		}
+	}
+
+	return
+}
+
+// reflectStructOf wraps reflect.StructOf() to return (rather than panic with) an error (e.g. for a duplicate field).
+func reflectStructOf(fields []reflect.StructField) (typeOf reflect.Type, err error) {
+	defer func() {
+		var (
+			panicValue interface{}
+		)
+
+		panicValue = recover()
+		if nil != panicValue {
+			err = fmt.Errorf("%v", panicValue)
+		}
+	}()
+
+	typeOf = reflect.StructOf(fields)
+
+	return
+}
+
+var reflectBasicTypes = map[types.BasicKind]reflect.Type{
+	types.Bool:   reflect.TypeOf(false),
+	types.Int:    reflect.TypeOf(int(0)),
+	types.Int8:   reflect.TypeOf(int8(0)),
+	types.Int16:  reflect.TypeOf(int16(0)),
+	types.Int32:  reflect.TypeOf(int32(0)),
+	types.Int64:  reflect.TypeOf(int64(0)),
+	types.Uint:   reflect.TypeOf(uint(0)),
+	types.Uint8:  reflect.TypeOf(uint8(0)),
+	types.Uint16: reflect.TypeOf(uint16(0)),
+	types.Uint32: reflect.TypeOf(uint32(0)),
+	types.Uint64: reflect.TypeOf(uint64(0)),
+	types.String: reflect.TypeOf(""),
+}
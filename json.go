@@ -0,0 +1,696 @@
+package xdr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ToJSON returns the canonical JSON form of the single value src holds, decoded as directed by typ (which, as for
+// Dump(), may be a *Schema, a reflect.Type, or a value of the Go type src is expected to encode):
+//
+//	int, unsigned int:       number
+//	hyper, unsigned hyper:   string of decimal digits (as not every JSON decoder holds 64 bits precisely)
+//	float, double:           number (or "NaN", "Infinity", or "-Infinity")
+//	enum:                    string (the declared name) or number (if no names are known)
+//	bool:                    true or false
+//	opaque:                  string (standard base64 encoding, including padding)
+//	string:                  string (with invalid UTF-8 replaced by U+FFFD)
+//	fixed or variable array: array
+//	struct:                  object with a member per field (in declaration order)
+//	union:                   object {"tag": discriminant, "value": arm} (omitting "value" for a void arm)
+//	optional:                null (if absent) or the referent
+//	void:                    null
+//
+// src is decoded as by Unpack() in UnpackModeDefault, except that any trailing bytes fail with ErrTrailingBytes.
+func ToJSON(src []byte, typ interface{}) (jsonBytes []byte, err error) {
+	var (
+		root   *schemaNode
+		schema *Schema
+	)
+
+	defer recoverInternalError("ToJSON", &err)
+
+	schema, err = schemaOfType(typ)
+	if nil != err {
+		return
+	}
+
+	root, err = decodeSchema(src, schema, DefaultMaxDepth)
+	if nil != err {
+		return
+	}
+
+	jsonBytes, err = appendSchemaNodeJSON(nil, root)
+	if nil != err {
+		jsonBytes = nil
+		return
+	}
+
+	if uint64(len(src)) > root.size {
+		jsonBytes = nil
+		err = newUnpackError(root.size, ErrTrailingBytes, "%d byte(s) follow the decoded value", uint64(len(src))-root.size)
+		return
+	}
+
+	return
+}
+
+// FromJSON packs the value whose canonical JSON form (see ToJSON()) is jsonBytes as directed by typ.
+//
+// Every struct field must be present (and no others), while a union's "value" may be omitted (or null) for a void
+// arm. Besides the canonical forms, hyper and unsigned hyper values may be given as numbers and enum values as
+// numbers. Errors identify the offending JSON value by path (e.g. "$.Entries[2].Name").
+func FromJSON(jsonBytes []byte, typ interface{}) (dst []byte, err error) {
+	var (
+		decoder   *json.Decoder
+		jsonValue interface{}
+		schema    *Schema
+	)
+
+	defer recoverInternalError("FromJSON", &err)
+
+	schema, err = schemaOfType(typ)
+	if nil != err {
+		return
+	}
+
+	decoder = json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+
+	err = decoder.Decode(&jsonValue)
+	if nil != err {
+		err = fmt.Errorf("invalid JSON: %v", err)
+		return
+	}
+	_, err = decoder.Token()
+	if io.EOF != err {
+		err = fmt.Errorf("invalid JSON: data follows the value")
+		return
+	}
+
+	dst, err = appendSchemaJSON(make([]byte, 0, len(jsonBytes)), schema, jsonValue, "$")
+	if nil != err {
+		dst = nil
+	}
+
+	return
+}
+
+// appendSchemaNodeJSON appends the canonical JSON form of the value decoded into node to jsonBytes.
+func appendSchemaNodeJSON(jsonBytesIn []byte, node *schemaNode) (jsonBytes []byte, err error) {
+	var (
+		child      *schemaNode
+		childIndex int
+	)
+
+	if 0 < len(node.notes) {
+		err = node.notes[0]
+		return
+	}
+
+	jsonBytes = jsonBytesIn
+
+	switch node.schema.Kind {
+	case SchemaVoid:
+		jsonBytes = append(jsonBytes, "null"...)
+	case SchemaFixedArray, SchemaArray:
+		jsonBytes = append(jsonBytes, '[')
+		for childIndex, child = range node.children {
+			if 0 < childIndex {
+				jsonBytes = append(jsonBytes, ',')
+			}
+			jsonBytes, err = appendSchemaNodeJSON(jsonBytes, child)
+			if nil != err {
+				return
+			}
+		}
+		jsonBytes = append(jsonBytes, ']')
+	case SchemaStruct:
+		jsonBytes = append(jsonBytes, '{')
+		for childIndex, child = range node.children {
+			if 0 < childIndex {
+				jsonBytes = append(jsonBytes, ',')
+			}
+			jsonBytes = appendJSONString(jsonBytes, child.name)
+			jsonBytes = append(jsonBytes, ':')
+			jsonBytes, err = appendSchemaNodeJSON(jsonBytes, child)
+			if nil != err {
+				return
+			}
+		}
+		jsonBytes = append(jsonBytes, '}')
+	case SchemaUnion:
+		jsonBytes = append(jsonBytes, `{"tag":`...)
+		jsonBytes = appendSchemaScalarJSON(jsonBytes, node.schema.Discriminant.Type, schemaDiscriminantValue(node.schema.Discriminant.Type, node.value.(int64)))
+		child = node.children[0]
+		if SchemaVoid != child.schema.Kind {
+			jsonBytes = append(jsonBytes, `,"value":`...)
+			jsonBytes, err = appendSchemaNodeJSON(jsonBytes, child)
+			if nil != err {
+				return
+			}
+		}
+		jsonBytes = append(jsonBytes, '}')
+	case SchemaOptional:
+		if node.value.(bool) {
+			jsonBytes, err = appendSchemaNodeJSON(jsonBytes, node.children[0])
+		} else {
+			jsonBytes = append(jsonBytes, "null"...)
+		}
+	default:
+		jsonBytes = appendSchemaScalarJSON(jsonBytes, node.schema, node.value)
+	}
+
+	return
+}
+
+// schemaDiscriminantValue converts the discriminant of a union (as decoded by decodeSchemaNode()) to the value
+// decodeSchemaNode() would have decoded for discriminantSchema.
+func schemaDiscriminantValue(discriminantSchema *Schema, discriminant int64) (value interface{}) {
+	switch discriminantSchema.Kind {
+	case SchemaUint:
+		value = uint64(discriminant)
+	case SchemaBool:
+		value = (0 != discriminant)
+	default:
+		value = discriminant
+	}
+
+	return
+}
+
+// appendSchemaScalarJSON appends the canonical JSON form of value (as decoded by decodeSchemaNode()) to jsonBytes.
+func appendSchemaScalarJSON(jsonBytesIn []byte, schema *Schema, value interface{}) (jsonBytes []byte) {
+	var (
+		bitSize int
+		name    string
+		ok      bool
+	)
+
+	jsonBytes = jsonBytesIn
+
+	switch schema.Kind {
+	case SchemaInt:
+		jsonBytes = strconv.AppendInt(jsonBytes, value.(int64), 10)
+	case SchemaUint:
+		jsonBytes = strconv.AppendUint(jsonBytes, value.(uint64), 10)
+	case SchemaEnum:
+		name, ok = schema.Enumerators[int32(value.(int64))]
+		if ok {
+			jsonBytes = appendJSONString(jsonBytes, name)
+		} else {
+			jsonBytes = strconv.AppendInt(jsonBytes, value.(int64), 10)
+		}
+	case SchemaBool:
+		jsonBytes = strconv.AppendBool(jsonBytes, value.(bool))
+	case SchemaHyper:
+		jsonBytes = append(jsonBytes, '"')
+		jsonBytes = strconv.AppendInt(jsonBytes, value.(int64), 10)
+		jsonBytes = append(jsonBytes, '"')
+	case SchemaUhyper:
+		jsonBytes = append(jsonBytes, '"')
+		jsonBytes = strconv.AppendUint(jsonBytes, value.(uint64), 10)
+		jsonBytes = append(jsonBytes, '"')
+	case SchemaFloat, SchemaDouble:
+		switch {
+		case math.IsNaN(value.(float64)):
+			jsonBytes = append(jsonBytes, `"NaN"`...)
+		case math.IsInf(value.(float64), 1):
+			jsonBytes = append(jsonBytes, `"Infinity"`...)
+		case math.IsInf(value.(float64), -1):
+			jsonBytes = append(jsonBytes, `"-Infinity"`...)
+		default:
+			if SchemaFloat == schema.Kind {
+				bitSize = 32
+			} else {
+				bitSize = 64
+			}
+			jsonBytes = strconv.AppendFloat(jsonBytes, value.(float64), 'g', -1, bitSize)
+		}
+	case SchemaFixedOpaque, SchemaOpaque:
+		jsonBytes = append(jsonBytes, '"')
+		jsonBytes = base64.StdEncoding.AppendEncode(jsonBytes, value.([]byte))
+		jsonBytes = append(jsonBytes, '"')
+	case SchemaString:
+		jsonBytes = appendJSONString(jsonBytes, value.(string))
+	}
+
+	return
+}
+
+// appendJSONString appends s as a JSON string (without escaping HTML characters as json.Marshal() would).
+func appendJSONString(jsonBytesIn []byte, s string) (jsonBytes []byte) {
+	var (
+		buffer  bytes.Buffer
+		encoder *json.Encoder
+	)
+
+	encoder = json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s) // Note: Encoding a string cannot fail
+
+	jsonBytes = append(jsonBytesIn, bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'})...)
+
+	return
+}
+
+// appendSchemaJSON appends the encoding of jsonValue (as decoded by json.Decoder.UseNumber()), found at path in
+// the JSON passed to FromJSON(), as described by schema to dst.
+func appendSchemaJSON(dstIn []byte, schema *Schema, jsonValue interface{}, path string) (dst []byte, err error) {
+	var (
+		arm           *SchemaArm
+		armValue      interface{}
+		discriminant  int64
+		elementIndex  int
+		elements      []interface{}
+		field         SchemaField
+		fieldValue    interface{}
+		i64           int64
+		jsonBytes     []byte
+		lengthOffset  int
+		members       map[string]interface{}
+		memberName    string
+		ok            bool
+		s             string
+		u64           uint64
+		unknownFields []string
+	)
+
+	dst = dstIn
+
+	switch schema.Kind {
+	case SchemaVoid:
+		if nil != jsonValue {
+			err = schemaJSONTypeError(path, "null", jsonValue)
+		}
+	case SchemaInt:
+		i64, err = schemaJSONInt(path, jsonValue, false, 32)
+		dst = appendSchemaWord(dst, uint64(uint32(int32(i64))), 4)
+	case SchemaUint:
+		u64, err = schemaJSONUint(path, jsonValue, false, 32)
+		dst = appendSchemaWord(dst, u64, 4)
+	case SchemaEnum:
+		s, ok = jsonValue.(string)
+		if ok {
+			i64, ok = schemaEnumeratorValue(schema, s)
+			if !ok {
+				err = fmt.Errorf("%s: %w: %q is not declared by %s", path, ErrInvalidEnumeration, s, schema.TypeName())
+				return
+			}
+		} else {
+			i64, err = schemaJSONInt(path, jsonValue, false, 32)
+			if nil != err {
+				return
+			}
+			if nil != schema.Enumerators {
+				_, ok = schema.Enumerators[int32(i64)]
+				if !ok {
+					err = fmt.Errorf("%s: %w: %d is not declared by %s", path, ErrInvalidEnumeration, i64, schema.TypeName())
+					return
+				}
+			}
+		}
+		dst = appendSchemaWord(dst, uint64(uint32(int32(i64))), 4)
+	case SchemaBool:
+		ok, err = schemaJSONBool(path, jsonValue)
+		if ok {
+			dst = appendSchemaWord(dst, 1, 4)
+		} else {
+			dst = appendSchemaWord(dst, 0, 4)
+		}
+	case SchemaHyper:
+		i64, err = schemaJSONInt(path, jsonValue, true, 64)
+		dst = appendSchemaWord(dst, uint64(i64), 8)
+	case SchemaUhyper:
+		u64, err = schemaJSONUint(path, jsonValue, true, 64)
+		dst = appendSchemaWord(dst, u64, 8)
+	case SchemaFloat:
+		u64, err = schemaJSONFloat(path, jsonValue, 32)
+		dst = appendSchemaWord(dst, u64, 4)
+	case SchemaDouble:
+		u64, err = schemaJSONFloat(path, jsonValue, 64)
+		dst = appendSchemaWord(dst, u64, 8)
+	case SchemaFixedOpaque, SchemaOpaque, SchemaString:
+		s, ok = jsonValue.(string)
+		if !ok {
+			err = schemaJSONTypeError(path, "a string", jsonValue)
+			return
+		}
+		if SchemaString == schema.Kind {
+			jsonBytes = []byte(s)
+		} else {
+			jsonBytes, err = base64.StdEncoding.DecodeString(s)
+			if nil != err {
+				err = fmt.Errorf("%s: invalid base64: %v", path, err)
+				return
+			}
+		}
+		if SchemaFixedOpaque == schema.Kind {
+			if uint64(schema.Size) != uint64(len(jsonBytes)) {
+				err = fmt.Errorf("%s: expected %d bytes but found %d", path, schema.Size, len(jsonBytes))
+				return
+			}
+		} else {
+			if (0 != schema.Size) && (uint64(schema.Size) < uint64(len(jsonBytes))) {
+				err = fmt.Errorf("%s: %w: length %d exceeds maximum %d", path, ErrMaxSizeExceeded, len(jsonBytes), schema.Size)
+				return
+			}
+			dst = appendSchemaWord(dst, uint64(len(jsonBytes)), 4)
+		}
+		dst = append(dst, jsonBytes...)
+		dst = append(dst, make([]byte, (4-(len(jsonBytes)%4))%4)...)
+	case SchemaFixedArray, SchemaArray:
+		elements, ok = jsonValue.([]interface{})
+		if !ok {
+			err = schemaJSONTypeError(path, "an array", jsonValue)
+			return
+		}
+		if SchemaFixedArray == schema.Kind {
+			if uint64(schema.Size) != uint64(len(elements)) {
+				err = fmt.Errorf("%s: expected %d elements but found %d", path, schema.Size, len(elements))
+				return
+			}
+		} else {
+			if (0 != schema.Size) && (uint64(schema.Size) < uint64(len(elements))) {
+				err = fmt.Errorf("%s: %w: length %d exceeds maximum %d", path, ErrMaxSizeExceeded, len(elements), schema.Size)
+				return
+			}
+			dst = appendSchemaWord(dst, uint64(len(elements)), 4)
+		}
+		for elementIndex = range elements {
+			dst, err = appendSchemaJSON(dst, schema.Elem, elements[elementIndex], fmt.Sprintf("%s[%d]", path, elementIndex))
+			if nil != err {
+				return
+			}
+		}
+	case SchemaStruct:
+		members, ok = jsonValue.(map[string]interface{})
+		if !ok {
+			err = schemaJSONTypeError(path, "an object", jsonValue)
+			return
+		}
+		for _, field = range schema.Fields {
+			fieldValue, ok = members[field.Name]
+			if !ok {
+				err = fmt.Errorf("%s: missing field %q", path, field.Name)
+				return
+			}
+			dst, err = appendSchemaJSON(dst, field.Type, fieldValue, path+"."+field.Name)
+			if nil != err {
+				return
+			}
+		}
+		if len(schema.Fields) < len(members) {
+			for memberName = range members {
+				if !schema.hasField(memberName) {
+					unknownFields = append(unknownFields, memberName)
+				}
+			}
+			sort.Strings(unknownFields)
+			err = fmt.Errorf("%s: unknown field(s) %q", path, unknownFields)
+			return
+		}
+	case SchemaUnion:
+		members, ok = jsonValue.(map[string]interface{})
+		if !ok {
+			err = schemaJSONTypeError(path, "an object", jsonValue)
+			return
+		}
+		for memberName = range members {
+			if ("tag" != memberName) && ("value" != memberName) {
+				err = fmt.Errorf("%s: unknown member %q (expected only \"tag\" and \"value\")", path, memberName)
+				return
+			}
+		}
+		_, ok = members["tag"]
+		if !ok {
+			err = fmt.Errorf("%s: missing member \"tag\"", path)
+			return
+		}
+		dst, err = appendSchemaJSON(dst, schema.Discriminant.Type, members["tag"], path+".tag")
+		if nil != err {
+			return
+		}
+		u64, _ = decodeSchemaWord(dst, uint64(len(dst)-4), 4)
+		if SchemaUint == schema.Discriminant.Type.Kind {
+			discriminant = int64(u64)
+		} else {
+			discriminant = int64(int32(uint32(u64)))
+		}
+		arm, ok = schema.selectArm(discriminant)
+		if !ok {
+			err = fmt.Errorf("%s: %w: no arm of %s selected by tag %d", path, ErrInvalidDiscriminant, schema.TypeName(), discriminant)
+			return
+		}
+		armValue, ok = members["value"]
+		if !ok && (SchemaVoid != arm.Field.Type.Kind) {
+			err = fmt.Errorf("%s: missing member \"value\" (arm %s)", path, arm.Field.Name)
+			return
+		}
+		if arm.IsWrapped {
+			lengthOffset = len(dst)
+			dst = appendSchemaWord(dst, 0, 4)
+		}
+		dst, err = appendSchemaJSON(dst, arm.Field.Type, armValue, path+".value")
+		if (nil == err) && arm.IsWrapped {
+			putSchemaArmLength(dst, lengthOffset)
+		}
+	case SchemaOptional:
+		if nil == jsonValue {
+			dst = appendSchemaWord(dst, 0, 4)
+		} else {
+			dst = appendSchemaWord(dst, 1, 4)
+			dst, err = appendSchemaJSON(dst, schema.Elem, jsonValue, path)
+		}
+	default:
+		err = fmt.Errorf("%s: %w: unsupported %v", path, ErrInternal, schema.Kind)
+	}
+
+	return
+}
+
+// hasField reports whether the SchemaStruct schema has a field named fieldName.
+func (schema *Schema) hasField(fieldName string) (hasField bool) {
+	var (
+		field SchemaField
+	)
+
+	for _, field = range schema.Fields {
+		if fieldName == field.Name {
+			hasField = true
+			return
+		}
+	}
+
+	return
+}
+
+// schemaEnumeratorValue returns the value of the enumerator of the SchemaEnum schema named name.
+func schemaEnumeratorValue(schema *Schema, name string) (value int64, ok bool) {
+	var (
+		enumeratorName  string
+		enumeratorValue int32
+	)
+
+	for enumeratorValue, enumeratorName = range schema.Enumerators {
+		if name == enumeratorName {
+			value = int64(enumeratorValue)
+			ok = true
+			return
+		}
+	}
+
+	return
+}
+
+// appendSchemaWord appends the big-endian 4 or 8 byte word u64 to dst.
+func appendSchemaWord(dstIn []byte, u64 uint64, size uint64) (dst []byte) {
+	var (
+		shift uint64
+	)
+
+	dst = dstIn
+
+	for shift = size * 8; shift > 0; shift -= 8 {
+		dst = append(dst, byte(u64>>(shift-8)))
+	}
+
+	return
+}
+
+// putSchemaArmLength fills in the length (reserved at lengthOffset in dst) preceding the arm that follows it.
+func putSchemaArmLength(dst []byte, lengthOffset int) {
+	var (
+		length uint64
+	)
+
+	length = uint64(len(dst) - lengthOffset - 4)
+
+	dst[lengthOffset+0] = byte(length >> 24)
+	dst[lengthOffset+1] = byte(length >> 16)
+	dst[lengthOffset+2] = byte(length >> 8)
+	dst[lengthOffset+3] = byte(length)
+}
+
+// schemaJSONNumberText returns the text of jsonValue if it is a number (or, if allowString, a string).
+func schemaJSONNumberText(path string, jsonValue interface{}, allowString bool, expected string) (text string, err error) {
+	var (
+		number json.Number
+		ok     bool
+	)
+
+	number, ok = jsonValue.(json.Number)
+	if ok {
+		text = number.String()
+		return
+	}
+
+	if allowString {
+		text, ok = jsonValue.(string)
+		if ok {
+			return
+		}
+	}
+
+	err = schemaJSONTypeError(path, expected, jsonValue)
+
+	return
+}
+
+// schemaJSONInt parses jsonValue as a signed integer of bitSize bits.
+func schemaJSONInt(path string, jsonValue interface{}, allowString bool, bitSize int) (i64 int64, err error) {
+	var (
+		text string
+	)
+
+	text, err = schemaJSONNumberText(path, jsonValue, allowString, "an integer")
+	if nil != err {
+		return
+	}
+
+	i64, err = strconv.ParseInt(text, 10, bitSize)
+	if nil != err {
+		err = schemaJSONParseError(path, text, bitSize, err)
+	}
+
+	return
+}
+
+// schemaJSONUint parses jsonValue as an unsigned integer of bitSize bits.
+func schemaJSONUint(path string, jsonValue interface{}, allowString bool, bitSize int) (u64 uint64, err error) {
+	var (
+		text string
+	)
+
+	text, err = schemaJSONNumberText(path, jsonValue, allowString, "an unsigned integer")
+	if nil != err {
+		return
+	}
+
+	u64, err = strconv.ParseUint(text, 10, bitSize)
+	if nil != err {
+		err = schemaJSONParseError(path, text, bitSize, err)
+	}
+
+	return
+}
+
+// schemaJSONFloat parses jsonValue as a floating point number of bitSize bits, returning its IEEE 754 encoding.
+func schemaJSONFloat(path string, jsonValue interface{}, bitSize int) (u64 uint64, err error) {
+	var (
+		f64  float64
+		text string
+	)
+
+	text, err = schemaJSONNumberText(path, jsonValue, true, "a number")
+	if nil != err {
+		return
+	}
+
+	switch text {
+	case "NaN":
+		f64 = math.NaN()
+	case "Infinity":
+		f64 = math.Inf(1)
+	case "-Infinity":
+		f64 = math.Inf(-1)
+	default:
+		_, err = json.Number(text).Float64() // Note: Rejects the non-JSON spellings strconv.ParseFloat() accepts
+		if nil == err {
+			f64, err = strconv.ParseFloat(text, bitSize)
+		}
+		if nil != err {
+			err = schemaJSONParseError(path, text, bitSize, err)
+			return
+		}
+	}
+
+	if 32 == bitSize {
+		u64 = uint64(math.Float32bits(float32(f64)))
+	} else {
+		u64 = math.Float64bits(f64)
+	}
+
+	return
+}
+
+// schemaJSONBool returns jsonValue if it is a Boolean.
+func schemaJSONBool(path string, jsonValue interface{}) (b bool, err error) {
+	var (
+		ok bool
+	)
+
+	b, ok = jsonValue.(bool)
+	if !ok {
+		err = schemaJSONTypeError(path, "true or false", jsonValue)
+	}
+
+	return
+}
+
+// schemaJSONParseError converts a strconv error parsing text into one identifying path (and wrapping ErrOverflow
+// if text was out of range).
+func schemaJSONParseError(path string, text string, bitSize int, parseErr error) (err error) {
+	if errors.Is(parseErr, strconv.ErrRange) {
+		err = fmt.Errorf("%s: %w: %s does not fit in %d bits", path, ErrOverflow, text, bitSize)
+	} else {
+		err = fmt.Errorf("%s: invalid number %q", path, text)
+	}
+
+	return
+}
+
+// schemaJSONTypeError returns an error reporting that the JSON value at path was not of the expected type.
+func schemaJSONTypeError(path string, expected string, jsonValue interface{}) (err error) {
+	var (
+		found string
+	)
+
+	switch jsonValue.(type) {
+	case nil:
+		found = "null"
+	case bool:
+		found = "a Boolean"
+	case json.Number:
+		found = "a number"
+	case string:
+		found = "a string"
+	case []interface{}:
+		found = "an array"
+	case map[string]interface{}:
+		found = "an object"
+	default:
+		found = fmt.Sprintf("%T", jsonValue)
+	}
+
+	err = fmt.Errorf("%s: expected %s but found %s", path, expected, found)
+
+	return
+}
@@ -0,0 +1,188 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testJSONSchemaText = `
+enum color { RED = 1, GREEN = 2 };
+
+union shade switch (color c) {
+case RED:
+	unsigned int intensity;
+default:
+	void;
+};
+
+struct sample {
+	hyper          h;
+	unsigned hyper uh;
+	float          f;
+	double         d;
+	opaque         raw<4>;
+	string         label<>;
+	shade          s<>;
+	sample         *next;
+};
+`
+
+func TestJSON(t *testing.T) {
+	var (
+		err                 error
+		jsonBytes           []byte
+		jsonBytesExpected   string
+		packed              []byte
+		repacked            []byte
+		sampleSchema        *Schema
+		schemas             map[string]*Schema
+		unionStruct         UnionStruct
+		unionStructString   string
+		unionStructPacked   []byte
+		unionStructUnpacked UnionStruct
+	)
+
+	// Verify a Go type round trips
+
+	jsonBytes, err = ToJSON(goodParentStructPacked, ParentStruct{})
+	if nil != err {
+		t.Fatalf("ToJSON(goodParentStructPacked) received unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(jsonBytes), `{"Integer":-1000000,"UnsignedInteger":1000000,`) || !strings.Contains(string(jsonBytes), `"HyperInteger":"-1000000000000"`) || !strings.Contains(string(jsonBytes), `"FixedLengthOpaqueData":"AQIDBAU=","VariableLengthOpaqueDataNoMax":"AQID"`) {
+		t.Fatalf("ToJSON(goodParentStructPacked) returned unexpected JSON: %s", jsonBytes)
+	}
+
+	repacked, err = FromJSON(jsonBytes, goodParentStructPtr)
+	if nil != err {
+		t.Fatalf("FromJSON() received unexpected error: %v", err)
+	}
+	if !bytes.Equal(goodParentStructPacked, repacked) {
+		t.Fatalf("FromJSON() returned 0x%X (expected 0x%X)", repacked, goodParentStructPacked)
+	}
+
+	unionStructString = "<&>"
+	unionStruct = UnionStruct{Discriminant: 2, String: &unionStructString}
+	unionStructPacked, err = Pack(unionStruct)
+	if nil != err {
+		t.Fatalf("Pack(unionStruct) received unexpected error: %v", err)
+	}
+	jsonBytes, err = ToJSON(unionStructPacked, unionStruct)
+	if (nil != err) || (`{"tag":2,"value":"<&>"}` != string(jsonBytes)) {
+		t.Fatalf("ToJSON(unionStructPacked) returned unexpected JSON (%s) or error (%v)", jsonBytes, err)
+	}
+
+	packed, err = FromJSON([]byte(`{"tag": 4}`), unionStruct)
+	if nil != err {
+		t.Fatalf("FromJSON(<void arm>) received unexpected error: %v", err)
+	}
+	_, err = Unpack(packed, &unionStructUnpacked)
+	if (nil != err) || (4 != unionStructUnpacked.Discriminant) {
+		t.Fatalf("Unpack(FromJSON(<void arm>)) returned unexpected %+v or error (%v)", unionStructUnpacked, err)
+	}
+
+	// Verify a .x Schema round trips (including names for enums, hypers as strings, and non-finite floats)
+
+	schemas, err = ParseSchema(testJSONSchemaText)
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+	sampleSchema = schemas["sample"]
+
+	jsonBytesExpected = `{"h":"-9223372036854775808","uh":"18446744073709551615","f":1.5,"d":"NaN","raw":"AQI=","label":"x\ny","s":[{"tag":"RED","value":7},{"tag":"GREEN"}],"next":{"h":"1","uh":"2","f":"-Infinity","d":1e+300,"raw":"","label":"","s":[],"next":null}}`
+
+	packed, err = FromJSON([]byte(jsonBytesExpected), sampleSchema)
+	if nil != err {
+		t.Fatalf("FromJSON(<sample>) received unexpected error: %v", err)
+	}
+	jsonBytes, err = ToJSON(packed, sampleSchema)
+	if (nil != err) || (jsonBytesExpected != string(jsonBytes)) {
+		t.Fatalf("ToJSON(FromJSON(<sample>)) returned unexpected JSON (%s) or error (%v)", jsonBytes, err)
+	}
+
+	packed, err = FromJSON([]byte(`{"h":1,"uh":2,"f":0,"d":0,"raw":"","label":"","s":[{"tag":2}],"next":null}`), sampleSchema)
+	if nil != err {
+		t.Fatalf("FromJSON(<numeric hypers & enum>) received unexpected error: %v", err)
+	}
+	jsonBytes, err = ToJSON(packed, sampleSchema)
+	if (nil != err) || !strings.Contains(string(jsonBytes), `"s":[{"tag":"GREEN"}]`) {
+		t.Fatalf("ToJSON(<numeric hypers & enum>) returned unexpected JSON (%s) or error (%v)", jsonBytes, err)
+	}
+
+	// Verify ToJSON() is as strict as Unpack()
+
+	_, err = ToJSON(badParentStructPacked, ParentStruct{})
+	if !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Fatalf("ToJSON(badParentStructPacked) returned unexpected error: %v", err)
+	}
+	_, err = ToJSON(append(packed, 0x00, 0x00, 0x00, 0x00), sampleSchema)
+	if !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("ToJSON(<trailing bytes>) returned unexpected error: %v", err)
+	}
+	_, err = ToJSON(packed[:len(packed)-1], sampleSchema)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("ToJSON(<truncated>) returned unexpected error: %v", err)
+	}
+	_, err = ToJSON([]byte{0x00, 0x00, 0x00, 0x03}, schemas["color"])
+	if !errors.Is(err, ErrInvalidEnumeration) {
+		t.Fatalf("ToJSON(<undeclared enum>) returned unexpected error: %v", err)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	var (
+		err        error
+		errorsJSON = map[string]string{
+			`{"h":"x","uh":"0","f":0,"d":0,"raw":"","label":"","s":[],"next":null}`:                         `$.h: invalid number "x"`,
+			`{"h":"0","uh":"-1","f":0,"d":0,"raw":"","label":"","s":[],"next":null}`:                        `$.uh: invalid number "-1"`,
+			`{"h":"0","uh":"0","f":1e40,"d":0,"raw":"","label":"","s":[],"next":null}`:                      `$.f: value overflows type`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"AQIDBAU=","label":"","s":[],"next":null}`:                 `$.raw: XDR_MaxSize exceeded`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"!","label":"","s":[],"next":null}`:                        `$.raw: invalid base64`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":7,"s":[],"next":null}`:                          `$.label: expected a string but found a number`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[{"tag":"BLUE"}],"next":null}`:           `$.s[0].tag: invalid Enumeration value`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[{"tag":"RED"}],"next":null}`:            `$.s[0]: missing member "value"`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[{"tag":"RED","value":-1}],"next":null}`: `$.s[0].value: invalid number "-1"`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[{"value":1}],"next":null}`:              `$.s[0]: missing member "tag"`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[],"next":{}}`:                           `$.next: missing field "h"`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[],"next":null,"extra":1,"another":2}`:   `$: unknown field(s) ["another" "extra"]`,
+			`{"h":"0","uh":"0","f":0,"d":0,"raw":"","label":"","s":[],"next":null} {}`:                      `invalid JSON: data follows the value`,
+			`{"h":"0",`: `invalid JSON`,
+		}
+		expectedSubstring string
+		jsonText          string
+		schemas           map[string]*Schema
+	)
+
+	schemas, err = ParseSchema(testJSONSchemaText)
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+
+	for jsonText, expectedSubstring = range errorsJSON {
+		_, err = FromJSON([]byte(jsonText), schemas["sample"])
+		if (nil == err) || !strings.Contains(err.Error(), expectedSubstring) {
+			t.Fatalf("FromJSON(%s) returned unexpected error (expected to contain %q): %v", jsonText, expectedSubstring, err)
+		}
+	}
+
+	_, err = FromJSON([]byte(`[1]`), &Schema{Kind: SchemaArray})
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("FromJSON(<malformed *Schema>) returned unexpected error: %v", err)
+	}
+
+	_, err = FromJSON([]byte(`{"Discriminant":9}`), UnionWithoutDefaultStruct{})
+	if nil == err {
+		t.Fatalf("FromJSON(<not a union>) should have failed")
+	}
+	_, err = FromJSON([]byte(`{"tag":9}`), UnionWithoutDefaultStruct{})
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("FromJSON(<invalid discriminant>) returned unexpected error: %v", err)
+	}
+	_, err = FromJSON([]byte(`{"Integer":2147483648}`), struct {
+		Integer int32 `xdr:""`
+	}{})
+	if !errors.Is(err, ErrOverflow) {
+		t.Fatalf("FromJSON(<overflowing int>) returned unexpected error: %v", err)
+	}
+}
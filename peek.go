@@ -0,0 +1,40 @@
+package xdr
+
+// Peek unpacks a prefix of src (e.g. the header common to every message of a protocol) into dstObjIF (passed by
+// reference), returning the bytes of src following it (e.g. the body to be unpacked once the header is examined).
+//
+// Decoding is as for Unpack(), except that any error is reported along with a nil rest.
+func Peek(src []byte, dstObjIF interface{}) (rest []byte, err error) {
+	var (
+		bytesConsumed uint64
+	)
+
+	bytesConsumed, err = Unpack(src, dstObjIF)
+	if nil != err {
+		return
+	}
+
+	rest = src[bytesConsumed:]
+
+	return
+}
+
+// PeekField unpacks the single part at path (e.g. "Call.Proc" or "Body.Discriminant", see View.Lookup()) of the
+// value src holds as described by typ (as for Dump()) into dstObjIF (passed by reference), returning the bytes of
+// src following that part.
+//
+// Only the bytes preceding the part (to the extent needed to locate it) and those of the part itself are read.
+func PeekField(src []byte, typ interface{}, path string, dstObjIF interface{}) (rest []byte, err error) {
+	var (
+		endOffset uint64
+	)
+
+	endOffset, err = NewView(src, typ).Lookup(path).unpack(dstObjIF)
+	if nil != err {
+		return
+	}
+
+	rest = src[endOffset:]
+
+	return
+}
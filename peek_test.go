@@ -0,0 +1,86 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type PeekHeader struct {
+	XID  uint32 `xdr:""`
+	Proc uint32 `xdr:""`
+}
+
+type PeekBody struct {
+	Discriminant uint32  `xdr:""`
+	Read         *uint64 `xdr:"case=1"`
+	Write        *string `xdr:"string,max=8,case=2"`
+}
+
+type PeekMessage struct {
+	Header PeekHeader `xdr:""`
+	Body   PeekBody   `xdr:""`
+	Tail   uint32     `xdr:""`
+}
+
+func TestPeek(t *testing.T) {
+	var (
+		body          PeekBody
+		discriminant  uint32
+		err           error
+		header        PeekHeader
+		message       PeekMessage
+		messagePacked []byte
+		rest          []byte
+		write         string
+	)
+
+	write = "abc"
+	message = PeekMessage{
+		Header: PeekHeader{XID: 7, Proc: 2},
+		Body:   PeekBody{Discriminant: 2, Write: &write},
+		Tail:   9,
+	}
+
+	messagePacked, err = Pack(message)
+	if nil != err {
+		t.Fatalf("Pack(message) received unexpected error: %v", err)
+	}
+
+	rest, err = Peek(messagePacked, &header)
+	if (nil != err) || (PeekHeader{XID: 7, Proc: 2} != header) || !bytes.Equal(messagePacked[8:], rest) {
+		t.Fatalf("Peek(&header) returned %#v and rest 0x%X or unexpected error: %v", header, rest, err)
+	}
+
+	rest, err = Peek(rest, &body)
+	if (nil != err) || (nil == body.Write) || ("abc" != *body.Write) || !bytes.Equal(messagePacked[len(messagePacked)-4:], rest) {
+		t.Fatalf("Peek(&body) returned %#v and rest 0x%X or unexpected error: %v", body, rest, err)
+	}
+
+	_, err = Peek(messagePacked[:6], &header)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Peek(<truncated>) returned unexpected error: %v", err)
+	}
+
+	rest, err = PeekField(messagePacked, PeekMessage{}, "Body.Discriminant", &discriminant)
+	if (nil != err) || (2 != discriminant) || !bytes.Equal(messagePacked[12:], rest) {
+		t.Fatalf("PeekField(\"Body.Discriminant\") returned %d and rest 0x%X or unexpected error: %v", discriminant, rest, err)
+	}
+
+	// Verify bytes following the part peeked at are not read
+
+	rest, err = PeekField(messagePacked[:12], PeekMessage{}, "$.Body.Discriminant", &discriminant)
+	if (nil != err) || (2 != discriminant) || (0 != len(rest)) {
+		t.Fatalf("PeekField(<truncated>, \"$.Body.Discriminant\") returned %d and rest 0x%X or unexpected error: %v", discriminant, rest, err)
+	}
+
+	_, err = PeekField(messagePacked, PeekMessage{}, "Body.Read", &discriminant)
+	if nil == err {
+		t.Fatalf("PeekField(\"Body.Read\") should have failed")
+	}
+
+	_, err = PeekField(messagePacked[:16], PeekMessage{}, "Tail", &discriminant)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("PeekField(<truncated>, \"Tail\") returned unexpected error: %v", err)
+	}
+}
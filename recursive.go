@@ -2,29 +2,129 @@ package xdr
 
 import (
 	"fmt"
+	"math"
 	"reflect"
-	"strconv"
 )
 
-func examineRecursive(objValueOf reflect.Value, maxSize uint64) (bytesNeeded uint64, err error) {
+// examineCycleDetectionDepth is the nesting depth beyond which examineRecursive() starts tracking the pointers &
+// slices it follows (sparing the cost for all but values so deep they likely reference themselves).
+const examineCycleDetectionDepth = 1000
+
+// examinedPointer identifies a pointer or slice being followed by examineRecursive().
+type examinedPointer struct {
+	pointer uintptr
+	length  int // Note: A slice and a shorter reslice of it may share a pointer yet not form a cycle
+	typeOf  reflect.Type
+}
+
+type examineState struct {
+	options            *PackOptions
+	checkValues        bool                     // If not set, only tags are checked (e.g. of a value about to be unpacked)
+	pointersInProgress map[examinedPointer]bool // Allocated upon reaching examineCycleDetectionDepth
+	recordArmLengths   bool                     // If set, the length of each wrapped union arm is appended to armLengths
+	armLengths         []uint64                 // In encoding order (i.e. an arm precedes those nested in it)
+}
+
+func examineRecursive(objValueOf reflect.Value, tag xdrTag, depth uint64, state *examineState) (bytesNeeded uint64, err error) {
 	var (
+		armField           *xdrField
+		armLengthIndex     int
+		elementBytesNeeded uint64
+		field              xdrField
 		fieldBytesNeeded   uint64
 		i                  int
 		objTypeOf          reflect.Type
+		ok                 bool
 		paddedLength       uint64
-		xdrMaxSizeAsString string
-		xdrMaxSizeAsUint64 uint64
-		xdrName            string
+		pointer            examinedPointer
+		registration       *interfaceRegistration
+		structLayout       *xdrStructLayout
 	)
 
 	// Capture reflect.Type of objValueOf
 
-	objTypeOf = reflect.TypeOf(objValueOf.Interface())
+	if !objValueOf.IsValid() {
+		err = fmt.Errorf("objValueOf is not a valid reflect.Value (e.g. a nil interface{})")
+		return
+	}
+
+	objTypeOf = objValueOf.Type()
+
+	// Past examineCycleDetectionDepth, detect a pointer or slice referencing itself (else recursion would not end)
+
+	if examineCycleDetectionDepth < depth {
+		switch objValueOf.Kind() {
+		case reflect.Ptr:
+			pointer = examinedPointer{pointer: objValueOf.Pointer(), typeOf: objTypeOf}
+		case reflect.Slice:
+			pointer = examinedPointer{pointer: objValueOf.Pointer(), length: objValueOf.Len(), typeOf: objTypeOf}
+		}
+		if 0 != pointer.pointer {
+			if state.pointersInProgress[pointer] {
+				err = fmt.Errorf("%w: %v references itself", ErrCycle, objTypeOf)
+				return
+			}
+			if nil == state.pointersInProgress {
+				state.pointersInProgress = make(map[examinedPointer]bool)
+			}
+			state.pointersInProgress[pointer] = true
+			defer delete(state.pointersInProgress, pointer)
+		}
+	}
+
+	// Enforce PackOptions.MaxDepth for "aggregate" & "encapsulating" objValueOf.Kind()'s (as unpackRecursive() does
+	// Limits.MaxDepth), so that even a cycle through pointers & interfaces alone reaches examineCycleDetectionDepth
+
+	switch objValueOf.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Struct, reflect.Ptr, reflect.Interface:
+		depth++
+		if state.options.maxDepth() < depth {
+			err = fmt.Errorf("%w: nesting depth exceeds PackOptions.MaxDepth (%v)", ErrLimitExceeded, state.options.maxDepth())
+			return
+		}
+	}
 
 	// First check for "encapsulating" objValueOf.Kind()'s
 
+	if (objValueOf.Kind() == reflect.Ptr) && tag.optional {
+		if objValueOf.IsNil() {
+			bytesNeeded = 4
+		} else {
+			bytesNeeded, err = examineRecursive(objValueOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize}, depth, state)
+			bytesNeeded += 4
+		}
+		return
+	}
+
+	if objValueOf.Kind() == reflect.Interface {
+		registration, ok = lookupInterface(objTypeOf)
+	}
+
+	if ok && !objValueOf.IsNil() {
+		if !state.checkValues {
+			bytesNeeded = 0 // Note: Unpack() will replace what objValueOf holds with a value of the concrete type selected
+			return
+		}
+		_, ok = registration.discriminants[objValueOf.Elem().Type()]
+		if !ok {
+			err = fmt.Errorf("%w: %v holds %v (not registered by RegisterInterface())", ErrInvalidDiscriminant, objTypeOf, objValueOf.Elem().Type())
+			return
+		}
+		bytesNeeded, err = examineRecursive(objValueOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize}, depth, state)
+		bytesNeeded += 4
+		return
+	}
+
 	if (objValueOf.Kind() == reflect.Interface) || (objValueOf.Kind() == reflect.Ptr) {
-		bytesNeeded, err = examineRecursive(objValueOf.Elem(), maxSize)
+		if objValueOf.IsNil() {
+			if state.checkValues {
+				err = fmt.Errorf("objValueOf is a nil %v (only Optional-Data may be nil)", objTypeOf)
+			} else {
+				bytesNeeded = 0 // Note: Unpack() will allocate what objValueOf should reference
+			}
+			return
+		}
+		bytesNeeded, err = examineRecursive(objValueOf.Elem(), tag, depth, state)
 		return
 	}
 
@@ -38,14 +138,30 @@ func examineRecursive(objValueOf reflect.Value, maxSize uint64) (bytesNeeded uin
 	switch objValueOf.Kind() {
 	case reflect.Bool:
 		bytesNeeded = 4
-	case reflect.Int32:
-		bytesNeeded = 4
-	case reflect.Int64:
-		bytesNeeded = 8
-	case reflect.Uint32:
-		bytesNeeded = 4
-	case reflect.Uint64:
-		bytesNeeded = 8
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bytesNeeded = xdrIntegerSize(objValueOf.Kind(), tag)
+		if state.checkValues {
+			if (4 == bytesNeeded) && ((math.MinInt32 > objValueOf.Int()) || (math.MaxInt32 < objValueOf.Int())) {
+				err = fmt.Errorf("%w: %v value %d does not fit in 32 bits", ErrOverflow, objTypeOf, objValueOf.Int())
+				return
+			}
+			if !isDeclaredEnumeration(objValueOf) {
+				err = fmt.Errorf("%w: %s is not declared", ErrInvalidEnumeration, EnumerationString(objValueOf.Interface()))
+				return
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bytesNeeded = xdrIntegerSize(objValueOf.Kind(), tag)
+		if state.checkValues {
+			if (4 == bytesNeeded) && (math.MaxUint32 < objValueOf.Uint()) {
+				err = fmt.Errorf("%w: %v value %d does not fit in 32 bits", ErrOverflow, objTypeOf, objValueOf.Uint())
+				return
+			}
+			if !isDeclaredEnumeration(objValueOf) {
+				err = fmt.Errorf("%w: %s is not declared", ErrInvalidEnumeration, EnumerationString(objValueOf.Interface()))
+				return
+			}
+		}
 	case reflect.Array:
 		if 0 == objValueOf.Len() {
 			bytesNeeded = 0 // Note: This is actually impossible
@@ -56,24 +172,26 @@ func examineRecursive(objValueOf reflect.Value, maxSize uint64) (bytesNeeded uin
 				paddedLength = paddedLength * 4
 				bytesNeeded = paddedLength
 			} else {
-				bytesNeeded, err = examineRecursive(objValueOf.Index(0), 0)
-				if nil != err {
-					return
+				for i = 0; i < objValueOf.Len(); i++ {
+					elementBytesNeeded, err = examineRecursive(objValueOf.Index(i), xdrTag{}, depth, state)
+					if nil != err {
+						return
+					}
+					bytesNeeded += elementBytesNeeded
 				}
-				bytesNeeded = uint64(objValueOf.Len()) * bytesNeeded
 			}
 		}
 	case reflect.Slice:
 		if 0 == objValueOf.Len() {
 			bytesNeeded = 4
 		} else {
-			if 0 == maxSize {
+			if 0 == tag.maxSize {
 				if 0xFFFFFFFF < objValueOf.Len() {
 					err = fmt.Errorf("objValueOf slice exceeds maximum allowable length")
 					return
 				}
 			} else {
-				if maxSize < uint64(objValueOf.Len()) {
+				if tag.maxSize < uint64(objValueOf.Len()) {
 					err = fmt.Errorf("objValueOf slice exceeds XDR_MaxSize")
 					return
 				}
@@ -84,24 +202,27 @@ func examineRecursive(objValueOf reflect.Value, maxSize uint64) (bytesNeeded uin
 				paddedLength = paddedLength * 4
 				bytesNeeded = 4 + paddedLength
 			} else {
-				bytesNeeded, err = examineRecursive(objValueOf.Index(0), 0)
-				if nil != err {
-					return
+				bytesNeeded = 4
+				for i = 0; i < objValueOf.Len(); i++ {
+					elementBytesNeeded, err = examineRecursive(objValueOf.Index(i), xdrTag{}, depth, state)
+					if nil != err {
+						return
+					}
+					bytesNeeded += elementBytesNeeded
 				}
-				bytesNeeded = 4 + (uint64(objValueOf.Len()) * bytesNeeded)
 			}
 		}
 	case reflect.String:
 		if 0 == objValueOf.Len() {
 			bytesNeeded = 4
 		} else {
-			if 0 == maxSize {
+			if 0 == tag.maxSize {
 				if 0xFFFFFFFF < objValueOf.Len() {
 					err = fmt.Errorf("objValueOf string exceeds maximum allowable length")
 					return
 				}
 			} else {
-				if maxSize < uint64(objValueOf.Len()) {
+				if tag.maxSize < uint64(objValueOf.Len()) {
 					err = fmt.Errorf("objValueOf string exceeds XDR_MaxSize")
 					return
 				}
@@ -112,73 +233,49 @@ func examineRecursive(objValueOf reflect.Value, maxSize uint64) (bytesNeeded uin
 			bytesNeeded = 4 + paddedLength
 		}
 	case reflect.Struct:
-		for i = 0; i < objValueOf.NumField(); i++ {
-			xdrName = objTypeOf.Field(i).Tag.Get("XDR_Name")
-			switch objValueOf.Field(i).Kind() {
-			case reflect.Bool:
-				if xdrName != "Boolean" {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Bool")
-					return
-				}
-			case reflect.Int32:
-				if (xdrName != "Integer") && (xdrName != "Enumeration") {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Int32")
-					return
-				}
-			case reflect.Int64:
-				if xdrName != "Hyper Integer" {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Int64")
-					return
-				}
-			case reflect.Uint32:
-				if (xdrName != "Unsigned Integer") && (xdrName != "Enumeration") {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Uint32")
-					return
-				}
-			case reflect.Uint64:
-				if xdrName != "Unsigned Hyper Integer" {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Uint64")
-					return
-				}
-			case reflect.Array:
-				if (xdrName != "Fixed-Length Opaque Data") && (xdrName != "Fixed-Length Array") {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Array")
-					return
-				}
-			case reflect.Slice:
-				if (xdrName != "Variable-Length Opaque Data") && (xdrName != "String") && (xdrName != "Variable-Length Array") {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Slice")
-					return
-				}
-			case reflect.String:
-				if xdrName != "String" {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.String")
-					return
-				}
-			case reflect.Struct:
-				if xdrName != "Structure" {
-					err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Struct")
-					return
-				}
+		structLayout, err = xdrStructLayoutOf(objTypeOf)
+		if nil != err {
+			return
+		}
+		if structLayout.isUnion {
+			field = structLayout.fields[0]
+			bytesNeeded, err = examineRecursive(objValueOf.FieldByIndex(field.index), field.tag, depth, state)
+			if nil != err {
+				return
 			}
-			xdrMaxSizeAsString = objTypeOf.Field(i).Tag.Get("XDR_MaxSize")
-			if "" == xdrMaxSizeAsString {
-				xdrMaxSizeAsUint64 = 0
-			} else {
-				xdrMaxSizeAsUint64, err = strconv.ParseUint(xdrMaxSizeAsString, 10, 64)
-				if nil != err {
-					return
-				}
-				if 0xFFFFFFFF < xdrMaxSizeAsUint64 {
-					err = fmt.Errorf("XDR_MaxSize (%v) exceeds maximum allowed (0xFFFFFFFF)", xdrMaxSizeAsUint64)
-					return
-				}
+			if !state.checkValues {
+				// Note: The arm (if any) to be unpacked is not yet known
+				return
 			}
-			fieldBytesNeeded, err = examineRecursive(objValueOf.Field(i), xdrMaxSizeAsUint64)
+			armField, ok = selectUnionArm(structLayout, discriminantOf(objValueOf.FieldByIndex(field.index)))
+			if !ok {
+				err = fmt.Errorf("%w: no arm of %v selected by discriminant %v", ErrInvalidDiscriminant, objTypeOf, discriminantOf(objValueOf.FieldByIndex(field.index)))
+				return
+			}
+			if state.recordArmLengths && structLayout.isWrapped && !armField.tag.isUnknown {
+				// Note: Reserve the arm's slot ahead of those of any wrapped arms nested within it
+				armLengthIndex = len(state.armLengths)
+				state.armLengths = append(state.armLengths, 0)
+			}
+			fieldBytesNeeded, err = examineRecursive(objValueOf.FieldByIndex(armField.index), armField.tag, depth, state)
 			if nil != err {
 				return
 			}
 			bytesNeeded += fieldBytesNeeded
+			if structLayout.isWrapped && !armField.tag.isUnknown {
+				bytesNeeded += 4 // Note: Counts the length of the Variable-Length Opaque Data wrapping the arm
+				if state.recordArmLengths {
+					state.armLengths[armLengthIndex] = fieldBytesNeeded
+				}
+			}
+		} else {
+			for _, field = range structLayout.fields {
+				fieldBytesNeeded, err = examineRecursive(objValueOf.FieldByIndex(field.index), field.tag, depth, state)
+				if nil != err {
+					return
+				}
+				bytesNeeded += fieldBytesNeeded
+			}
 		}
 	default:
 		err = fmt.Errorf("objValueOf is %#v; objValueOf.Kind() == %v unsupported", objValueOf, objValueOf.Kind())
@@ -188,29 +285,89 @@ func examineRecursive(objValueOf reflect.Value, maxSize uint64) (bytesNeeded uin
 	return
 }
 
-func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (newOffset uint64) {
+func packRecursive(srcObjValueOf reflect.Value, tag xdrTag, dst []byte, oldOffset uint64) (newOffset uint64, err error) {
 	var (
+		armField     *xdrField
+		armOffset    uint64
 		b            bool
+		discriminant int32
+		field        xdrField
 		i            int
 		i64          int64
+		ok           bool
 		paddedLength uint64
+		registration *interfaceRegistration
 		s            string
 		srcObjTypeOf reflect.Type
+		structLayout *xdrStructLayout
 		u64          uint64
 	)
 
 	// Capture reflect.Type of srcObjValueOf
 
-	srcObjTypeOf = reflect.TypeOf(srcObjValueOf.Interface())
+	if !srcObjValueOf.IsValid() {
+		err = newPackError(oldOffset, ErrInternal, "srcObjValueOf is not a valid reflect.Value")
+		return
+	}
+
+	srcObjTypeOf = srcObjValueOf.Type()
 
 	// Handle specific srcObjValueOf.Kind()
 
 	switch srcObjValueOf.Kind() {
 	case reflect.Interface:
-		newOffset = packRecursive(srcObjValueOf.Elem(), dst, oldOffset)
+		if srcObjValueOf.IsNil() {
+			err = newPackError(oldOffset, ErrInternal, "srcObjValueOf is a nil %v", srcObjTypeOf)
+			return
+		}
+		registration, ok = lookupInterface(srcObjTypeOf)
+		if !ok {
+			newOffset, err = packRecursive(srcObjValueOf.Elem(), tag, dst, oldOffset)
+			return
+		}
+		discriminant, ok = registration.discriminants[srcObjValueOf.Elem().Type()]
+		if !ok {
+			err = newPackError(oldOffset, ErrInternal, "srcObjValueOf %v holds unregistered %v", srcObjTypeOf, srcObjValueOf.Elem().Type())
+			return
+		}
+		err = checkPackRoom(dst, oldOffset, 4)
+		if nil != err {
+			return
+		}
+		u64 = uint64(uint32(discriminant))
+		dst[oldOffset+0] = byte(u64 >> 24)
+		dst[oldOffset+1] = byte(u64 >> 16)
+		dst[oldOffset+2] = byte(u64 >> 8)
+		dst[oldOffset+3] = byte(u64)
+		newOffset, err = packRecursive(srcObjValueOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize}, dst, oldOffset+4)
 	case reflect.Ptr:
-		newOffset = packRecursive(srcObjValueOf.Elem(), dst, oldOffset)
+		if tag.optional {
+			err = checkPackRoom(dst, oldOffset, 4)
+			if nil != err {
+				return
+			}
+			dst[oldOffset+0] = 0x00
+			dst[oldOffset+1] = 0x00
+			dst[oldOffset+2] = 0x00
+			if srcObjValueOf.IsNil() {
+				dst[oldOffset+3] = 0x00
+				newOffset = oldOffset + 4
+			} else {
+				dst[oldOffset+3] = 0x01
+				newOffset, err = packRecursive(srcObjValueOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize}, dst, oldOffset+4)
+			}
+		} else {
+			if srcObjValueOf.IsNil() {
+				err = newPackError(oldOffset, ErrInternal, "srcObjValueOf is a nil %v (only Optional-Data may be nil)", srcObjTypeOf)
+				return
+			}
+			newOffset, err = packRecursive(srcObjValueOf.Elem(), tag, dst, oldOffset)
+		}
 	case reflect.Bool:
+		err = checkPackRoom(dst, oldOffset, 4)
+		if nil != err {
+			return
+		}
 		b = srcObjValueOf.Bool()
 		dst[oldOffset+0] = 0x00
 		dst[oldOffset+1] = 0x00
@@ -221,72 +378,92 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 			dst[oldOffset+3] = 0x00
 		}
 		newOffset = oldOffset + 4
-	case reflect.Int32:
-		i64 = srcObjValueOf.Int()
-		if 0 <= i64 {
-			u64 = uint64(i64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if 4 == xdrIntegerSize(srcObjValueOf.Kind(), tag) {
+			err = checkPackRoom(dst, oldOffset, 4)
+			if nil != err {
+				return
+			}
+			i64 = srcObjValueOf.Int()
+			if 0 <= i64 {
+				u64 = uint64(i64)
+			} else {
+				u64 = ^uint64(-i64) + 1
+			}
+			dst[oldOffset+3] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+2] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+1] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+0] = byte(u64 & 0xFF)
+			newOffset = oldOffset + 4
 		} else {
-			u64 = ^uint64(-i64) + 1
+			err = checkPackRoom(dst, oldOffset, 8)
+			if nil != err {
+				return
+			}
+			i64 = srcObjValueOf.Int()
+			if 0 <= i64 {
+				u64 = uint64(i64)
+			} else {
+				u64 = ^uint64(-i64) + 1
+			}
+			dst[oldOffset+7] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+6] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+5] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+4] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+3] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+2] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+1] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+0] = byte(u64 & 0xFF)
+			newOffset = oldOffset + 8
 		}
-		dst[oldOffset+3] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+2] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+1] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+0] = byte(u64 & 0xFF)
-		newOffset = oldOffset + 4
-	case reflect.Int64:
-		i64 = srcObjValueOf.Int()
-		if 0 <= i64 {
-			u64 = uint64(i64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if 4 == xdrIntegerSize(srcObjValueOf.Kind(), tag) {
+			err = checkPackRoom(dst, oldOffset, 4)
+			if nil != err {
+				return
+			}
+			u64 = srcObjValueOf.Uint()
+			dst[oldOffset+3] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+2] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+1] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+0] = byte(u64 & 0xFF)
+			newOffset = oldOffset + 4
 		} else {
-			u64 = ^uint64(-i64) + 1
+			err = checkPackRoom(dst, oldOffset, 8)
+			if nil != err {
+				return
+			}
+			u64 = srcObjValueOf.Uint()
+			dst[oldOffset+7] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+6] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+5] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+4] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+3] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+2] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+1] = byte(u64 & 0xFF)
+			u64 = u64 >> 8
+			dst[oldOffset+0] = byte(u64 & 0xFF)
+			newOffset = oldOffset + 8
 		}
-		dst[oldOffset+7] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+6] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+5] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+4] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+3] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+2] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+1] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+0] = byte(u64 & 0xFF)
-		newOffset = oldOffset + 8
-	case reflect.Uint32:
-		u64 = srcObjValueOf.Uint()
-		dst[oldOffset+3] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+2] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+1] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+0] = byte(u64 & 0xFF)
-		newOffset = oldOffset + 4
-	case reflect.Uint64:
-		u64 = srcObjValueOf.Uint()
-		dst[oldOffset+7] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+6] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+5] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+4] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+3] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+2] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+1] = byte(u64 & 0xFF)
-		u64 = u64 >> 8
-		dst[oldOffset+0] = byte(u64 & 0xFF)
-		newOffset = oldOffset + 8
 	case reflect.Array:
 		if 0 == srcObjValueOf.Len() {
 			newOffset = oldOffset // Note: This is actually impossible
@@ -295,6 +472,10 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 				paddedLength = uint64(srcObjValueOf.Len()) + 3
 				paddedLength = paddedLength / 4
 				paddedLength = paddedLength * 4
+				err = checkPackRoom(dst, oldOffset, paddedLength)
+				if nil != err {
+					return
+				}
 				for i = 0; i < srcObjValueOf.Len(); i++ {
 					dst[int(oldOffset)+i] = byte(srcObjValueOf.Index(i).Uint() & 0xFF)
 				}
@@ -305,11 +486,18 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 			} else {
 				newOffset = oldOffset
 				for i = 0; i < srcObjValueOf.Len(); i++ {
-					newOffset = packRecursive(srcObjValueOf.Index(i), dst, newOffset)
+					newOffset, err = packRecursive(srcObjValueOf.Index(i), xdrTag{}, dst, newOffset)
+					if nil != err {
+						return
+					}
 				}
 			}
 		}
 	case reflect.Slice:
+		err = checkPackRoom(dst, oldOffset, 4)
+		if nil != err {
+			return
+		}
 		u64 = uint64(srcObjValueOf.Len())
 		dst[oldOffset+3] = byte(u64 & 0xFF)
 		u64 = u64 >> 8
@@ -325,6 +513,10 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 				paddedLength = uint64(srcObjValueOf.Len()) + 3
 				paddedLength = paddedLength / 4
 				paddedLength = paddedLength * 4
+				err = checkPackRoom(dst, oldOffset+4, paddedLength)
+				if nil != err {
+					return
+				}
 				for i = 0; i < srcObjValueOf.Len(); i++ {
 					dst[int(oldOffset)+4+i] = byte(srcObjValueOf.Index(i).Uint() & 0xFF)
 				}
@@ -335,11 +527,18 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 			} else {
 				newOffset = oldOffset + 4
 				for i = 0; i < srcObjValueOf.Len(); i++ {
-					newOffset = packRecursive(srcObjValueOf.Index(i), dst, newOffset)
+					newOffset, err = packRecursive(srcObjValueOf.Index(i), xdrTag{}, dst, newOffset)
+					if nil != err {
+						return
+					}
 				}
 			}
 		}
 	case reflect.String:
+		err = checkPackRoom(dst, oldOffset, 4)
+		if nil != err {
+			return
+		}
 		u64 = uint64(srcObjValueOf.Len())
 		dst[oldOffset+3] = byte(u64 & 0xFF)
 		u64 = u64 >> 8
@@ -352,6 +551,10 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 		paddedLength = uint64(len(s)) + 3
 		paddedLength = paddedLength / 4
 		paddedLength = paddedLength * 4
+		err = checkPackRoom(dst, oldOffset+4, paddedLength)
+		if nil != err {
+			return
+		}
 		for i = 0; i < len(s); i++ {
 			dst[int(oldOffset)+4+i] = s[i] & 0xFF
 		}
@@ -360,117 +563,385 @@ func packRecursive(srcObjValueOf reflect.Value, dst []byte, oldOffset uint64) (n
 		}
 		newOffset = oldOffset + 4 + paddedLength
 	case reflect.Struct:
-		newOffset = oldOffset
-		for i = 0; i < srcObjValueOf.NumField(); i++ {
-			newOffset = packRecursive(srcObjValueOf.Field(i), dst, newOffset)
+		structLayout, err = xdrStructLayoutOf(srcObjTypeOf)
+		if nil != err {
+			return
+		}
+		if structLayout.isUnion {
+			field = structLayout.fields[0]
+			newOffset, err = packRecursive(srcObjValueOf.FieldByIndex(field.index), field.tag, dst, oldOffset)
+			if nil != err {
+				return
+			}
+			armField, ok = selectUnionArm(structLayout, discriminantOf(srcObjValueOf.FieldByIndex(field.index)))
+			if !ok {
+				err = newPackError(oldOffset, ErrInvalidDiscriminant, "no arm of %v selected by discriminant %v", srcObjTypeOf, discriminantOf(srcObjValueOf.FieldByIndex(field.index)))
+				return
+			}
+			if !structLayout.isWrapped || armField.tag.isUnknown {
+				newOffset, err = packRecursive(srcObjValueOf.FieldByIndex(armField.index), armField.tag, dst, newOffset)
+				return
+			}
+			// Pack the arm following room for its length (known only afterwards) as Variable-Length Opaque Data
+			err = checkPackRoom(dst, newOffset, 4)
+			if nil != err {
+				return
+			}
+			armOffset = newOffset
+			newOffset, err = packRecursive(srcObjValueOf.FieldByIndex(armField.index), armField.tag, dst, armOffset+4)
+			if nil != err {
+				return
+			}
+			u64 = newOffset - (armOffset + 4) // Note: Always a multiple of 4, so no padding follows
+			if 0xFFFFFFFF < u64 {
+				err = newPackError(armOffset, ErrOverflow, "arm of %v exceeds maximum allowable length", srcObjTypeOf)
+				return
+			}
+			dst[armOffset+0] = byte(u64 >> 24)
+			dst[armOffset+1] = byte(u64 >> 16)
+			dst[armOffset+2] = byte(u64 >> 8)
+			dst[armOffset+3] = byte(u64)
+		} else {
+			newOffset = oldOffset
+			for _, field = range structLayout.fields {
+				newOffset, err = packRecursive(srcObjValueOf.FieldByIndex(field.index), field.tag, dst, newOffset)
+				if nil != err {
+					return
+				}
+			}
 		}
+	default:
+		err = newPackError(oldOffset, ErrInternal, "srcObjValueOf has unsupported Kind() == %s", reflectKindName(srcObjValueOf.Kind()))
 	}
 
 	return
 }
 
-func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf reflect.Value) (newOffset uint64, err error) {
+// checkPackRoom returns a non-nil err if dst lacks room for n bytes at offset (i.e. Examine() undercounted).
+func checkPackRoom(dst []byte, offset uint64, n uint64) (err error) {
+	if uint64(len(dst)) < (offset + n) {
+		err = newPackError(offset, ErrSizeMismatch, "no room for 0x%X bytes in dst []byte of length 0x%X", n, len(dst))
+		return
+	}
+	err = nil
+	return
+}
+
+type unpackState struct {
+	options   *UnpackOptions
+	allocated uint64 // Total bytes allocated so far for []byte, string, and slice contents
+}
+
+func (state *unpackState) allocate(offset uint64, bytesToAllocate uint64) (err error) {
+	state.allocated += bytesToAllocate
+	if (0 != state.options.Limits.MaxAllocation) && (state.options.Limits.MaxAllocation < state.allocated) {
+		err = newUnpackError(offset, ErrLimitExceeded, "total allocation exceeds Limits.MaxAllocation (%v)", state.options.Limits.MaxAllocation)
+	}
+	return
+}
+
+func minimumSizeRecursive(objTypeOf reflect.Type, tag xdrTag, typesInProgress map[reflect.Type]bool) (minimumSize uint64) {
+	var (
+		armIndex       int
+		armMinimumSize uint64
+		err            error
+		field          xdrField
+		ok             bool
+		paddedLength   uint64
+		structLayout   *xdrStructLayout
+	)
+
+	switch objTypeOf.Kind() {
+	case reflect.Interface:
+		_, ok = lookupInterface(objTypeOf)
+		if ok {
+			minimumSize = 4 // Note: Only a lower bound is needed, so simply count the discriminant
+		} else {
+			minimumSize = 0
+		}
+	case reflect.Ptr:
+		if tag.optional {
+			minimumSize = 4
+		} else {
+			if typesInProgress[objTypeOf] {
+				minimumSize = 0 // Note: As for a struct, stop at a pointer type referencing itself (e.g. type P *P)
+				return
+			}
+			typesInProgress[objTypeOf] = true
+			minimumSize = minimumSizeRecursive(objTypeOf.Elem(), tag, typesInProgress)
+			delete(typesInProgress, objTypeOf)
+		}
+	case reflect.Bool:
+		minimumSize = 4
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		minimumSize = xdrIntegerSize(objTypeOf.Kind(), tag)
+	case reflect.Int64, reflect.Uint64:
+		minimumSize = 8
+	case reflect.Array:
+		if reflect.Uint8 == objTypeOf.Elem().Kind() {
+			paddedLength = uint64(objTypeOf.Len()) + 3
+			paddedLength = paddedLength / 4
+			paddedLength = paddedLength * 4
+			minimumSize = paddedLength
+		} else {
+			minimumSize = uint64(objTypeOf.Len()) * minimumSizeRecursive(objTypeOf.Elem(), xdrTag{}, typesInProgress)
+		}
+	case reflect.Slice:
+		minimumSize = 4
+	case reflect.String:
+		minimumSize = 4
+	case reflect.Struct:
+		if typesInProgress[objTypeOf] {
+			minimumSize = 0 // Note: Only a lower bound is needed, so simply stop at a recursive reference
+			return
+		}
+		structLayout, err = xdrStructLayoutOf(objTypeOf)
+		if nil != err {
+			minimumSize = 0
+			return
+		}
+		typesInProgress[objTypeOf] = true
+		if structLayout.isWrapped {
+			minimumSize = 8 // Note: Only a lower bound is needed, so simply count the discriminant & arm length
+		} else if structLayout.isUnion {
+			minimumSize = 4
+			for armIndex = 1; armIndex < len(structLayout.fields); armIndex++ {
+				field = structLayout.fields[armIndex]
+				armMinimumSize = minimumSizeRecursive(objTypeOf.FieldByIndex(field.index).Type, field.tag, typesInProgress)
+				if (1 == armIndex) || (armMinimumSize < minimumSize-4) {
+					minimumSize = 4 + armMinimumSize
+				}
+			}
+		} else {
+			for _, field = range structLayout.fields {
+				minimumSize += minimumSizeRecursive(objTypeOf.FieldByIndex(field.index).Type, field.tag, typesInProgress)
+			}
+		}
+		delete(typesInProgress, objTypeOf)
+	default:
+		minimumSize = 0
+	}
+
+	return
+}
+
+func unpackRecursive(src []byte, oldOffset uint64, tag xdrTag, dstObjValueOf reflect.Value, depth uint64, state *unpackState) (newOffset uint64, err error) {
 	var (
 		actualLength       uint64
+		armField           *xdrField
+		armIndex           int
+		armOffset          uint64
+		b                  bool
+		concreteTypeOf     reflect.Type
+		concreteValueOf    reflect.Value
+		copiedBytes        []byte
 		dstObjTypeOf       reflect.Type
+		elementMinimumSize uint64
+		field              xdrField
 		i                  int
 		i64                int64
+		ok                 bool
 		paddedLength       uint64
+		registration       *interfaceRegistration
+		structLayout       *xdrStructLayout
 		u64                uint64
-		xdrMaxSizeAsString string
-		xdrMaxSizeAsUint64 uint64
 	)
 
 	// Capture reflect.Type & reflect.Value of dstObjValueOf
 
-	dstObjTypeOf = reflect.TypeOf(dstObjValueOf.Interface())
+	dstObjTypeOf = dstObjValueOf.Type()
+
+	// Enforce Limits.MaxDepth for "aggregate" & "encapsulating" dstObjValueOf.Kind()'s (as a type may reference
+	// itself through pointers and interfaces alone, e.g. type P *P)
+
+	switch dstObjValueOf.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Struct, reflect.Ptr, reflect.Interface:
+		depth++
+		if state.options.Limits.maxDepth() < depth {
+			err = newUnpackError(oldOffset, ErrLimitExceeded, "nesting depth exceeds Limits.MaxDepth (%v)", state.options.Limits.maxDepth())
+			return
+		}
+	}
 
 	// Handle specific srcObjValueOf.Kind()
 
 	switch dstObjValueOf.Kind() {
 	case reflect.Interface:
-		newOffset, err = unpackRecursive(src, oldOffset, maxSize, dstObjValueOf.Elem())
+		registration, ok = lookupInterface(dstObjTypeOf)
+		if !ok {
+			if dstObjValueOf.IsNil() || (reflect.Ptr != dstObjValueOf.Elem().Kind()) || dstObjValueOf.Elem().IsNil() {
+				err = newUnpackError(oldOffset, ErrInvalidDestination, "%v is not registered (with RegisterInterface()) and does not hold a non-nil pointer", dstObjTypeOf)
+				return
+			}
+			newOffset, err = unpackRecursive(src, oldOffset, tag, dstObjValueOf.Elem(), depth, state)
+			if nil != err {
+				return
+			}
+			break
+		}
+		if uint64(len(src)) < (oldOffset + 4) {
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.Interface discriminant in src []byte")
+			return
+		}
+		i64 = int64(int32(uint32(src[oldOffset+0])<<24 | uint32(src[oldOffset+1])<<16 | uint32(src[oldOffset+2])<<8 | uint32(src[oldOffset+3])))
+		concreteTypeOf, ok = registration.concreteTypes[int32(i64)]
+		if !ok {
+			err = newUnpackError(oldOffset, ErrInvalidDiscriminant, "no concrete type of %v registered for discriminant %v", dstObjTypeOf, i64)
+			return
+		}
+		err = state.allocate(oldOffset, uint64(concreteTypeOf.Size()))
 		if nil != err {
 			return
 		}
-	case reflect.Ptr:
-		newOffset, err = unpackRecursive(src, oldOffset, maxSize, dstObjValueOf.Elem())
+		concreteValueOf = reflect.New(concreteTypeOf).Elem()
+		newOffset, err = unpackRecursive(src, oldOffset+4, xdrTag{name: tag.name, maxSize: tag.maxSize}, concreteValueOf, depth, state)
 		if nil != err {
 			return
 		}
+		dstObjValueOf.Set(concreteValueOf)
+	case reflect.Ptr:
+		if tag.optional {
+			if uint64(len(src)) < (oldOffset + 4) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for Optional-Data reflect.Ptr field in src []byte")
+				return
+			}
+			if UnpackModeLenient == state.options.Mode {
+				b = (0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (0 != src[oldOffset+3])
+			} else {
+				if (0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (1 < src[oldOffset+3]) {
+					err = newUnpackError(oldOffset, ErrInvalidBoolean, "Invalid bytes for Optional-Data reflect.Ptr field in src []byte")
+					return
+				}
+				b = (0x01 == src[oldOffset+3])
+			}
+			if !b {
+				dstObjValueOf.Set(reflect.Zero(dstObjTypeOf))
+				newOffset = oldOffset + 4
+				return
+			}
+			if dstObjValueOf.IsNil() {
+				err = state.allocate(oldOffset, uint64(dstObjTypeOf.Elem().Size()))
+				if nil != err {
+					return
+				}
+				dstObjValueOf.Set(reflect.New(dstObjTypeOf.Elem()))
+			}
+			newOffset, err = unpackRecursive(src, oldOffset+4, xdrTag{name: tag.name, maxSize: tag.maxSize}, dstObjValueOf.Elem(), depth, state)
+			if nil != err {
+				return
+			}
+		} else {
+			if dstObjValueOf.IsNil() {
+				err = state.allocate(oldOffset, uint64(dstObjTypeOf.Elem().Size()))
+				if nil != err {
+					return
+				}
+				dstObjValueOf.Set(reflect.New(dstObjTypeOf.Elem()))
+			}
+			newOffset, err = unpackRecursive(src, oldOffset, tag, dstObjValueOf.Elem(), depth, state)
+			if nil != err {
+				return
+			}
+		}
 	case reflect.Bool:
 		if uint64(len(src)) < (oldOffset + 4) {
-			err = fmt.Errorf("No room for reflect.Bool field in src []byte")
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.Bool field in src []byte")
 			return
 		}
-		if (0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (1 < src[oldOffset+3]) {
-			err = fmt.Errorf("Invalid bytes for reflect.Bool field in src []byte at offset 0x%X", oldOffset)
-			return
+		if UnpackModeLenient == state.options.Mode {
+			dstObjValueOf.SetBool((0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (0 != src[oldOffset+3]))
+		} else {
+			if (0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (1 < src[oldOffset+3]) {
+				err = newUnpackError(oldOffset, ErrInvalidBoolean, "Invalid bytes for reflect.Bool field in src []byte")
+				return
+			}
+			dstObjValueOf.SetBool(0x01 == src[oldOffset+3])
 		}
-		dstObjValueOf.SetBool(0x01 == src[oldOffset+3])
 		newOffset = oldOffset + 4
-	case reflect.Int32:
-		if uint64(len(src)) < (oldOffset + 4) {
-			err = fmt.Errorf("No room for reflect.Int32 field in src []byte")
-			return
-		}
-		u64 = uint64(src[oldOffset+0])
-		u64 = (u64 << 8) + uint64(src[oldOffset+1])
-		u64 = (u64 << 8) + uint64(src[oldOffset+2])
-		u64 = (u64 << 8) + uint64(src[oldOffset+3])
-		if 0 == ((u64 >> 0x1F) & 0x01) {
-			i64 = int64(u64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if 4 == xdrIntegerSize(dstObjValueOf.Kind(), tag) {
+			if uint64(len(src)) < (oldOffset + 4) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for %s field in src []byte", reflectKindName(dstObjValueOf.Kind()))
+				return
+			}
+			u64 = uint64(src[oldOffset+0])
+			u64 = (u64 << 8) + uint64(src[oldOffset+1])
+			u64 = (u64 << 8) + uint64(src[oldOffset+2])
+			u64 = (u64 << 8) + uint64(src[oldOffset+3])
+			if 0 == ((u64 >> 0x1F) & 0x01) {
+				i64 = int64(u64)
+			} else {
+				i64 = -int64(^((u64 - 1) | uint64(0xFFFFFFFF00000000)))
+			}
+			newOffset = oldOffset + 4
 		} else {
-			i64 = -int64(^((u64 - 1) | uint64(0xFFFFFFFF00000000)))
+			if uint64(len(src)) < (oldOffset + 8) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for %s field in src []byte", reflectKindName(dstObjValueOf.Kind()))
+				return
+			}
+			u64 = uint64(src[oldOffset+0])
+			u64 = (u64 << 8) + uint64(src[oldOffset+1])
+			u64 = (u64 << 8) + uint64(src[oldOffset+2])
+			u64 = (u64 << 8) + uint64(src[oldOffset+3])
+			u64 = (u64 << 8) + uint64(src[oldOffset+4])
+			u64 = (u64 << 8) + uint64(src[oldOffset+5])
+			u64 = (u64 << 8) + uint64(src[oldOffset+6])
+			u64 = (u64 << 8) + uint64(src[oldOffset+7])
+			if 0 == ((u64 >> 0x3F) & 0x01) {
+				i64 = int64(u64)
+			} else {
+				i64 = -int64(^(u64 - 1))
+			}
+			newOffset = oldOffset + 8
+		}
+		if dstObjValueOf.OverflowInt(i64) {
+			err = newUnpackError(oldOffset, ErrOverflow, "value %d overflows %v", i64, dstObjTypeOf)
+			return
 		}
 		dstObjValueOf.SetInt(i64)
-		newOffset = oldOffset + 4
-	case reflect.Int64:
-		if uint64(len(src)) < (oldOffset + 8) {
-			err = fmt.Errorf("No room for reflect.Int64 field in src []byte")
+		if (UnpackModeLenient != state.options.Mode) && !isDeclaredEnumeration(dstObjValueOf) {
+			err = newUnpackError(oldOffset, ErrInvalidEnumeration, "%s is not a declared Enumeration value", EnumerationString(dstObjValueOf.Interface()))
 			return
 		}
-		u64 = uint64(src[oldOffset+0])
-		u64 = (u64 << 8) + uint64(src[oldOffset+1])
-		u64 = (u64 << 8) + uint64(src[oldOffset+2])
-		u64 = (u64 << 8) + uint64(src[oldOffset+3])
-		u64 = (u64 << 8) + uint64(src[oldOffset+4])
-		u64 = (u64 << 8) + uint64(src[oldOffset+5])
-		u64 = (u64 << 8) + uint64(src[oldOffset+6])
-		u64 = (u64 << 8) + uint64(src[oldOffset+7])
-		if 0 == ((u64 >> 0x3F) & 0x01) {
-			i64 = int64(u64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if 4 == xdrIntegerSize(dstObjValueOf.Kind(), tag) {
+			if uint64(len(src)) < (oldOffset + 4) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for %s field in src []byte", reflectKindName(dstObjValueOf.Kind()))
+				return
+			}
+			u64 = uint64(src[oldOffset+0])
+			u64 = (u64 << 8) + uint64(src[oldOffset+1])
+			u64 = (u64 << 8) + uint64(src[oldOffset+2])
+			u64 = (u64 << 8) + uint64(src[oldOffset+3])
+			if (UnpackModeStrict == state.options.Mode) && ("Enumeration" == tag.name) && (0x7FFFFFFF < u64) {
+				err = newUnpackError(oldOffset, ErrInvalidEnumeration, "Enumeration value 0x%X exceeds the range of a signed 32-bit integer", u64)
+				return
+			}
+			newOffset = oldOffset + 4
 		} else {
-			i64 = -int64(^(u64 - 1))
+			if uint64(len(src)) < (oldOffset + 8) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for %s field in src []byte", reflectKindName(dstObjValueOf.Kind()))
+				return
+			}
+			u64 = uint64(src[oldOffset+0])
+			u64 = (u64 << 8) + uint64(src[oldOffset+1])
+			u64 = (u64 << 8) + uint64(src[oldOffset+2])
+			u64 = (u64 << 8) + uint64(src[oldOffset+3])
+			u64 = (u64 << 8) + uint64(src[oldOffset+4])
+			u64 = (u64 << 8) + uint64(src[oldOffset+5])
+			u64 = (u64 << 8) + uint64(src[oldOffset+6])
+			u64 = (u64 << 8) + uint64(src[oldOffset+7])
+			newOffset = oldOffset + 8
 		}
-		dstObjValueOf.SetInt(i64)
-		newOffset = oldOffset + 8
-	case reflect.Uint32:
-		if uint64(len(src)) < (oldOffset + 4) {
-			err = fmt.Errorf("No room for reflect.Uint32 field in src []byte")
+		if dstObjValueOf.OverflowUint(u64) {
+			err = newUnpackError(oldOffset, ErrOverflow, "value %d overflows %v", u64, dstObjTypeOf)
 			return
 		}
-		u64 = uint64(src[oldOffset+0])
-		u64 = (u64 << 8) + uint64(src[oldOffset+1])
-		u64 = (u64 << 8) + uint64(src[oldOffset+2])
-		u64 = (u64 << 8) + uint64(src[oldOffset+3])
 		dstObjValueOf.SetUint(u64)
-		newOffset = oldOffset + 4
-	case reflect.Uint64:
-		if uint64(len(src)) < (oldOffset + 8) {
-			err = fmt.Errorf("No room for reflect.Uint64 field in src []byte")
+		if (UnpackModeLenient != state.options.Mode) && !isDeclaredEnumeration(dstObjValueOf) {
+			err = newUnpackError(oldOffset, ErrInvalidEnumeration, "%s is not a declared Enumeration value", EnumerationString(dstObjValueOf.Interface()))
 			return
 		}
-		u64 = uint64(src[oldOffset+0])
-		u64 = (u64 << 8) + uint64(src[oldOffset+1])
-		u64 = (u64 << 8) + uint64(src[oldOffset+2])
-		u64 = (u64 << 8) + uint64(src[oldOffset+3])
-		u64 = (u64 << 8) + uint64(src[oldOffset+4])
-		u64 = (u64 << 8) + uint64(src[oldOffset+5])
-		u64 = (u64 << 8) + uint64(src[oldOffset+6])
-		u64 = (u64 << 8) + uint64(src[oldOffset+7])
-		dstObjValueOf.SetUint(u64)
-		newOffset = oldOffset + 8
 	case reflect.Array:
 		if 0 == dstObjValueOf.Len() {
 			newOffset = oldOffset // Note: This is actually impossible
@@ -480,15 +951,15 @@ func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf
 				paddedLength = paddedLength / 4
 				paddedLength = paddedLength * 4
 				if uint64(len(src)) < (oldOffset + paddedLength) {
-					err = fmt.Errorf("No room for refelct.Array field in src []byte")
+					err = newUnpackError(oldOffset, ErrTruncated, "No room for refelct.Array field in src []byte")
 					return
 				}
 				for i = 0; i < dstObjValueOf.Len(); i++ {
 					dstObjValueOf.Index(i).SetUint(uint64(src[int(oldOffset)+i]))
 				}
-				for i = dstObjValueOf.Len(); i < int(paddedLength); i++ {
+				for i = dstObjValueOf.Len(); (UnpackModeLenient != state.options.Mode) && (i < int(paddedLength)); i++ {
 					if 0x00 != src[int(oldOffset)+i] {
-						err = fmt.Errorf("Non-zero pad bytes in src []byte")
+						err = newUnpackError(oldOffset+uint64(i), ErrNonZeroPadding, "Non-zero pad bytes in src []byte")
 						return
 					}
 				}
@@ -496,7 +967,7 @@ func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf
 			} else {
 				newOffset = oldOffset
 				for i = 0; i < dstObjValueOf.Len(); i++ {
-					newOffset, err = unpackRecursive(src, newOffset, 0, dstObjValueOf.Index(i))
+					newOffset, err = unpackRecursive(src, newOffset, xdrTag{}, dstObjValueOf.Index(i), depth, state)
 					if nil != err {
 						return
 					}
@@ -505,7 +976,7 @@ func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf
 		}
 	case reflect.Slice:
 		if uint64(len(src)) < (oldOffset + 4) {
-			err = fmt.Errorf("No room for reflect.Slice length field in src []byte")
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.Slice length field in src []byte")
 			return
 		}
 		actualLength = uint64(src[oldOffset+0])
@@ -516,38 +987,70 @@ func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf
 			dstObjValueOf.Set(reflect.MakeSlice(dstObjTypeOf, 0, 0))
 			newOffset = oldOffset + 4
 		} else {
-			if 0 == maxSize {
+			if 0 == tag.maxSize {
 				if 0xFFFFFFFF < actualLength {
-					err = fmt.Errorf("dstObjValueOf slice exceeds maximum allowable length")
+					err = newUnpackError(oldOffset, ErrMaxSizeExceeded, "dstObjValueOf slice exceeds maximum allowable length")
 					return
 				}
 			} else {
-				if maxSize < actualLength {
-					err = fmt.Errorf("dstObjValueOf slice exceeds XDR_MaxSize")
+				if tag.maxSize < actualLength {
+					err = newUnpackError(oldOffset, ErrMaxSizeExceeded, "dstObjValueOf slice exceeds XDR_MaxSize")
 					return
 				}
 			}
 			if reflect.Uint8 == dstObjTypeOf.Elem().Kind() {
+				if (0 != state.options.Limits.MaxStringLength) && (state.options.Limits.MaxStringLength < actualLength) {
+					err = newUnpackError(oldOffset, ErrLimitExceeded, "dstObjValueOf slice exceeds Limits.MaxStringLength (%v)", state.options.Limits.MaxStringLength)
+					return
+				}
 				paddedLength = actualLength + 3
 				paddedLength = paddedLength / 4
 				paddedLength = paddedLength * 4
 				if (oldOffset + 4 + paddedLength) > uint64(len(src)) {
-					err = fmt.Errorf("No room for byte reflect.Slice padded length in src []byte")
+					err = newUnpackError(oldOffset, ErrTruncated, "No room for byte reflect.Slice padded length in src []byte")
 					return
 				}
-				dstObjValueOf.SetBytes(src[(oldOffset + 4):(oldOffset + 4 + actualLength)])
-				for i = int(oldOffset + 4 + actualLength); i < int(oldOffset+4+paddedLength); i++ {
+				if state.options.AliasBytes {
+					dstObjValueOf.SetBytes(src[(oldOffset + 4):(oldOffset + 4 + actualLength)])
+				} else {
+					err = state.allocate(oldOffset, actualLength)
+					if nil != err {
+						return
+					}
+					copiedBytes = make([]byte, actualLength)
+					copy(copiedBytes, src[(oldOffset+4):(oldOffset+4+actualLength)])
+					dstObjValueOf.SetBytes(copiedBytes)
+				}
+				for i = int(oldOffset + 4 + actualLength); (UnpackModeLenient != state.options.Mode) && (i < int(oldOffset+4+paddedLength)); i++ {
 					if 0x00 != src[i] {
-						err = fmt.Errorf("Non-zero pad bytes in src []byte")
+						err = newUnpackError(uint64(i), ErrNonZeroPadding, "Non-zero pad bytes in src []byte")
 						return
 					}
 				}
 				newOffset = oldOffset + 4 + paddedLength
 			} else {
+				if (0 != state.options.Limits.MaxArrayElements) && (state.options.Limits.MaxArrayElements < actualLength) {
+					err = newUnpackError(oldOffset, ErrLimitExceeded, "dstObjValueOf slice exceeds Limits.MaxArrayElements (%v)", state.options.Limits.MaxArrayElements)
+					return
+				}
+				// Ensure the remainder of src could possibly hold actualLength elements before allocating them
+				elementMinimumSize = minimumSizeRecursive(dstObjTypeOf.Elem(), xdrTag{}, make(map[reflect.Type]bool))
+				if (0 != elementMinimumSize) && (((uint64(len(src)) - (oldOffset + 4)) / elementMinimumSize) < actualLength) {
+					err = newUnpackError(oldOffset, ErrTruncated, "No room for %v reflect.Slice elements in src []byte", actualLength)
+					return
+				}
+				if (0 == elementMinimumSize) && (state.options.Limits.maxZeroSizeElements() < actualLength) {
+					err = newUnpackError(oldOffset, ErrLimitExceeded, "dstObjValueOf slice of elements possibly encoded in zero bytes exceeds %v elements", state.options.Limits.maxZeroSizeElements())
+					return
+				}
+				err = state.allocate(oldOffset, actualLength*uint64(dstObjTypeOf.Elem().Size()))
+				if nil != err {
+					return
+				}
 				dstObjValueOf.Set(reflect.MakeSlice(dstObjTypeOf, int(actualLength), int(actualLength)))
 				newOffset = oldOffset + 4
 				for i = 0; i < dstObjValueOf.Len(); i++ {
-					newOffset, err = unpackRecursive(src, newOffset, 0, dstObjValueOf.Index(i))
+					newOffset, err = unpackRecursive(src, newOffset, xdrTag{}, dstObjValueOf.Index(i), depth, state)
 					if nil != err {
 						return
 					}
@@ -556,7 +1059,7 @@ func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf
 		}
 	case reflect.String:
 		if uint64(len(src)) < (oldOffset + 4) {
-			err = fmt.Errorf("No room for reflect.String length field in src []byte")
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.String length field in src []byte")
 			return
 		}
 		actualLength = uint64(src[oldOffset+0])
@@ -567,54 +1070,101 @@ func unpackRecursive(src []byte, oldOffset uint64, maxSize uint64, dstObjValueOf
 			dstObjValueOf.SetString("")
 			newOffset = oldOffset + 4
 		} else {
-			if 0 == maxSize {
+			if 0 == tag.maxSize {
 				if 0xFFFFFFFF < actualLength {
-					err = fmt.Errorf("dstObjValueOf string exceeds maximum allowable length")
+					err = newUnpackError(oldOffset, ErrMaxSizeExceeded, "dstObjValueOf string exceeds maximum allowable length")
 					return
 				}
 			} else {
-				if maxSize < actualLength {
-					err = fmt.Errorf("dstObjValueOf string exceeds XDR_MaxSize")
+				if tag.maxSize < actualLength {
+					err = newUnpackError(oldOffset, ErrMaxSizeExceeded, "dstObjValueOf string exceeds XDR_MaxSize")
 					return
 				}
 			}
+			if (0 != state.options.Limits.MaxStringLength) && (state.options.Limits.MaxStringLength < actualLength) {
+				err = newUnpackError(oldOffset, ErrLimitExceeded, "dstObjValueOf string exceeds Limits.MaxStringLength (%v)", state.options.Limits.MaxStringLength)
+				return
+			}
 			paddedLength = actualLength + 3
 			paddedLength = paddedLength / 4
 			paddedLength = paddedLength * 4
 			if (oldOffset + 4 + paddedLength) > uint64(len(src)) {
-				err = fmt.Errorf("No room for reflect.String padded length in src []byte")
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.String padded length in src []byte")
+				return
+			}
+			err = state.allocate(oldOffset, actualLength)
+			if nil != err {
 				return
 			}
 			dstObjValueOf.SetString(string(src[(oldOffset + 4):(oldOffset + 4 + actualLength)]))
-			for i = int(oldOffset + 4 + actualLength); i < int(oldOffset+4+paddedLength); i++ {
+			for i = int(oldOffset + 4 + actualLength); (UnpackModeLenient != state.options.Mode) && (i < int(oldOffset+4+paddedLength)); i++ {
 				if 0x00 != src[i] {
-					err = fmt.Errorf("Non-zero pad bytes in src []byte")
+					err = newUnpackError(uint64(i), ErrNonZeroPadding, "Non-zero pad bytes in src []byte")
 					return
 				}
 			}
 			newOffset = oldOffset + 4 + paddedLength
 		}
 	case reflect.Struct:
-		newOffset = oldOffset
-		for i = 0; i < dstObjValueOf.NumField(); i++ {
-			xdrMaxSizeAsString = dstObjTypeOf.Field(i).Tag.Get("XDR_MaxSize")
-			if "" == xdrMaxSizeAsString {
-				xdrMaxSizeAsUint64 = 0
-			} else {
-				// Note: The strconv.ParseUint() & bounds check failures would have been already caught by examineRecursive()
-				xdrMaxSizeAsUint64, err = strconv.ParseUint(xdrMaxSizeAsString, 10, 64)
-				if nil != err {
-					return
+		// Note: Any xdrStructLayoutOf() failure would have been already caught by examineRecursive()
+		structLayout, err = xdrStructLayoutOf(dstObjTypeOf)
+		if nil != err {
+			return
+		}
+		if structLayout.isUnion {
+			field = structLayout.fields[0]
+			newOffset, err = unpackRecursive(src, oldOffset, field.tag, dstObjValueOf.FieldByIndex(field.index), depth, state)
+			if nil != err {
+				return
+			}
+			armField, ok = selectUnionArm(structLayout, discriminantOf(dstObjValueOf.FieldByIndex(field.index)))
+			if !ok {
+				err = newUnpackError(oldOffset, ErrInvalidDiscriminant, "no arm of %v selected by discriminant %v", dstObjTypeOf, discriminantOf(dstObjValueOf.FieldByIndex(field.index)))
+				return
+			}
+			for armIndex = 1; armIndex < len(structLayout.fields); armIndex++ {
+				if &structLayout.fields[armIndex] != armField {
+					field = structLayout.fields[armIndex]
+					dstObjValueOf.FieldByIndex(field.index).Set(reflect.Zero(dstObjTypeOf.FieldByIndex(field.index).Type))
 				}
-				if 0xFFFFFFFF < xdrMaxSizeAsUint64 {
-					err = fmt.Errorf("XDR_MaxSize (%v) exceeds maximum allowed (0xFFFFFFFF)", xdrMaxSizeAsUint64)
+			}
+			if !structLayout.isWrapped || armField.tag.isUnknown {
+				newOffset, err = unpackRecursive(src, newOffset, armField.tag, dstObjValueOf.FieldByIndex(armField.index), depth, state)
+				if nil != err {
 					return
 				}
+				break
+			}
+			// Unpack the arm from within the Variable-Length Opaque Data wrapping it (which it must exactly fill)
+			armOffset = newOffset
+			if uint64(len(src)) < (armOffset + 4) {
+				err = newUnpackError(armOffset, ErrTruncated, "No room for Discriminated Union arm length in src []byte")
+				return
 			}
-			newOffset, err = unpackRecursive(src, newOffset, xdrMaxSizeAsUint64, dstObjValueOf.Field(i))
+			actualLength = uint64(src[armOffset+0])
+			actualLength = (actualLength << 8) + uint64(src[armOffset+1])
+			actualLength = (actualLength << 8) + uint64(src[armOffset+2])
+			actualLength = (actualLength << 8) + uint64(src[armOffset+3])
+			if uint64(len(src)) < (armOffset + 4 + actualLength) {
+				err = newUnpackError(armOffset, ErrTruncated, "No room for Discriminated Union arm in src []byte")
+				return
+			}
+			newOffset, err = unpackRecursive(src[:armOffset+4+actualLength], armOffset+4, armField.tag, dstObjValueOf.FieldByIndex(armField.index), depth, state)
 			if nil != err {
 				return
 			}
+			if (armOffset + 4 + actualLength) != newOffset {
+				err = newUnpackError(newOffset, ErrTrailingBytes, "%v arm %s did not fill its length (%v)", dstObjTypeOf, armField.name, actualLength)
+				return
+			}
+		} else {
+			newOffset = oldOffset
+			for _, field = range structLayout.fields {
+				newOffset, err = unpackRecursive(src, newOffset, field.tag, dstObjValueOf.FieldByIndex(field.index), depth, state)
+				if nil != err {
+					return
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,346 @@
+package xdr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/rpc"
+)
+
+// Each net/rpc request & response is sent as a single record using the record marking of RFC 5531 (section 11):
+// a sequence of fragments, each preceded by a 4-byte big-endian header whose high bit marks the last fragment of the
+// record and whose remaining 31 bits give the fragment's length. A record holds the XDR encoding of the appropriate
+// rpc*Header followed by that of the request args or response reply.
+
+const (
+	rpcLastFragmentFlag  = uint32(0x80000000)
+	rpcFragmentSizeMask  = uint32(0x7FFFFFFF)
+	rpcMaxRecordSize     = uint64(0x04000000) // 64 MiB bounds the memory consumed by a record from a (hostile) peer
+	rpcMaxDepth          = DefaultMaxDepth    // Bounds the nesting (and thus stack) consumed decoding a record
+	rpcMaxErrorString    = 65536              // Matches the XDR_MaxSize of rpcResponseHeader.Error
+	rpcReadChunkSize     = uint64(0x10000)    // Bytes of a fragment read (and so allocated) at a time
+	rpcBodyErrorPrefix   = "xdr: "            // Prefixes the Error of a response whose reply could not be packed
+	rpcRecordHeaderBytes = 4
+)
+
+type rpcRequestHeader struct {
+	ServiceMethod string `xdr:"string,max=256"`
+	Seq           uint64 `xdr:"uhyper"`
+}
+
+type rpcResponseHeader struct {
+	ServiceMethod string `xdr:"string,max=256"`
+	Seq           uint64 `xdr:"uhyper"`
+	Error         string `xdr:"string,max=65536"`
+}
+
+// rpcConn implements the record marking shared by rpcServerCodec & rpcClientCodec.
+type rpcConn struct {
+	rwc     io.ReadWriteCloser
+	r       *bufio.Reader
+	w       *bufio.Writer
+	options UnpackOptions // Directs the unpacking of each record's rpc*Header and of its body
+	record  []byte        // Most recently read record (reused for the next unless options.AliasBytes)
+	body    []byte        // Portion of record following its rpc*Header
+}
+
+type rpcServerCodec struct {
+	rpcConn
+}
+
+type rpcClientCodec struct {
+	rpcConn
+}
+
+var (
+	_ rpc.ServerCodec = &rpcServerCodec{}
+	_ rpc.ClientCodec = &rpcClientCodec{}
+)
+
+// NewServerCodec returns an rpc.ServerCodec exchanging XDR-encoded requests & responses over conn.
+//
+// The args & reply types of methods served via the returned rpc.ServerCodec must be supported by Pack() & Unpack().
+func NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return NewServerCodecWithOptions(conn, nil)
+}
+
+// NewServerCodecWithOptions is like NewServerCodec() but unpacks requests as directed by options. Should options
+// be nil, requests are unpacked with Limits bounding MaxAllocation to the maximum record size (64 MiB) and MaxDepth
+// to DefaultMaxDepth.
+func NewServerCodecWithOptions(conn io.ReadWriteCloser, options *UnpackOptions) rpc.ServerCodec {
+	return &rpcServerCodec{rpcConn: newRPCConn(conn, options)}
+}
+
+// NewClientCodec returns an rpc.ClientCodec exchanging XDR-encoded requests & responses over conn.
+//
+// The args & reply types passed to rpc.Client.Call() must be supported by Pack() & Unpack().
+func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	return NewClientCodecWithOptions(conn, nil)
+}
+
+// NewClientCodecWithOptions is like NewClientCodec() but unpacks responses as directed by options (with the same
+// defaults as NewServerCodecWithOptions() should options be nil).
+func NewClientCodecWithOptions(conn io.ReadWriteCloser, options *UnpackOptions) rpc.ClientCodec {
+	return &rpcClientCodec{rpcConn: newRPCConn(conn, options)}
+}
+
+func newRPCConn(conn io.ReadWriteCloser, options *UnpackOptions) (rpcConn rpcConn) {
+	rpcConn.rwc = conn
+	rpcConn.r = bufio.NewReader(conn)
+	rpcConn.w = bufio.NewWriter(conn)
+	if nil == options {
+		rpcConn.options = UnpackOptions{Limits: Limits{MaxAllocation: rpcMaxRecordSize, MaxDepth: rpcMaxDepth}}
+	} else {
+		rpcConn.options = *options
+	}
+	return
+}
+
+// ReadRequestHeader reads the next request record, unpacking its header into request.
+func (serverCodec *rpcServerCodec) ReadRequestHeader(request *rpc.Request) (err error) {
+	var (
+		header rpcRequestHeader
+	)
+
+	err = serverCodec.readRecord(&header)
+	if nil != err {
+		return
+	}
+
+	request.ServiceMethod = header.ServiceMethod
+	request.Seq = header.Seq
+
+	return
+}
+
+// ReadRequestBody unpacks the args of the request just read into args (or discards them if args is nil).
+func (serverCodec *rpcServerCodec) ReadRequestBody(args interface{}) (err error) {
+	err = serverCodec.readBody(args)
+	return
+}
+
+// WriteResponse writes a response record holding reply.
+//
+// Should reply not be packable, the response is instead sent with an Error describing why (and no reply).
+func (serverCodec *rpcServerCodec) WriteResponse(response *rpc.Response, reply interface{}) (err error) {
+	var (
+		body   []byte
+		header rpcResponseHeader
+	)
+
+	header = rpcResponseHeader{
+		ServiceMethod: response.ServiceMethod,
+		Seq:           response.Seq,
+		Error:         response.Error,
+	}
+
+	if "" == header.Error {
+		body, err = Pack(reply)
+		if nil != err {
+			header.Error = rpcBodyErrorPrefix + err.Error()
+			body = nil
+		}
+	}
+
+	if rpcMaxErrorString < len(header.Error) {
+		header.Error = header.Error[:rpcMaxErrorString]
+	}
+
+	err = serverCodec.writeRecord(header, body)
+
+	return
+}
+
+// Close closes the underlying conn.
+func (serverCodec *rpcServerCodec) Close() (err error) {
+	err = serverCodec.rwc.Close()
+	return
+}
+
+// WriteRequest writes a request record holding args.
+func (clientCodec *rpcClientCodec) WriteRequest(request *rpc.Request, args interface{}) (err error) {
+	var (
+		body []byte
+	)
+
+	body, err = Pack(args)
+	if nil != err {
+		return
+	}
+
+	err = clientCodec.writeRecord(rpcRequestHeader{ServiceMethod: request.ServiceMethod, Seq: request.Seq}, body)
+
+	return
+}
+
+// ReadResponseHeader reads the next response record, unpacking its header into response.
+func (clientCodec *rpcClientCodec) ReadResponseHeader(response *rpc.Response) (err error) {
+	var (
+		header rpcResponseHeader
+	)
+
+	err = clientCodec.readRecord(&header)
+	if nil != err {
+		return
+	}
+
+	response.ServiceMethod = header.ServiceMethod
+	response.Seq = header.Seq
+	response.Error = header.Error
+
+	return
+}
+
+// ReadResponseBody unpacks the reply of the response just read into reply (or discards it if reply is nil).
+func (clientCodec *rpcClientCodec) ReadResponseBody(reply interface{}) (err error) {
+	err = clientCodec.readBody(reply)
+	return
+}
+
+// Close closes the underlying conn.
+func (clientCodec *rpcClientCodec) Close() (err error) {
+	err = clientCodec.rwc.Close()
+	return
+}
+
+// writeRecord writes (and flushes) a single-fragment record holding the XDR encoding of header followed by body.
+func (rpcConn *rpcConn) writeRecord(header interface{}, body []byte) (err error) {
+	var (
+		headerPacked []byte
+		recordSize   uint64
+	)
+
+	headerPacked, err = Pack(header)
+	if nil != err {
+		return
+	}
+
+	recordSize = uint64(len(headerPacked)) + uint64(len(body))
+	if rpcMaxRecordSize < recordSize {
+		err = fmt.Errorf("record size (0x%X) exceeds maximum allowed (0x%X)", recordSize, rpcMaxRecordSize)
+		return
+	}
+
+	_, err = rpcConn.w.Write([]byte{
+		byte((rpcLastFragmentFlag | uint32(recordSize)) >> 24),
+		byte(recordSize >> 16),
+		byte(recordSize >> 8),
+		byte(recordSize),
+	})
+	if nil != err {
+		return
+	}
+	_, err = rpcConn.w.Write(headerPacked)
+	if nil != err {
+		return
+	}
+	_, err = rpcConn.w.Write(body)
+	if nil != err {
+		return
+	}
+
+	err = rpcConn.w.Flush()
+
+	return
+}
+
+// readRecord reads the next record (reassembling its fragments) and unpacks its leading header into headerPtr.
+//
+// A fragment is read (and rpcConn.record grown) rpcReadChunkSize bytes at a time, so a fragment header claiming a
+// size its peer never sends cannot allocate more than the bytes actually received. Should options.AliasBytes be
+// set, each record is read into a fresh rpcConn.record, as the values unpacked from the previous one (e.g. the args
+// of a request still being served) reference it.
+//
+// An io.EOF between records is returned unwrapped (as net/rpc expects upon orderly connection shutdown).
+func (rpcConn *rpcConn) readRecord(headerPtr interface{}) (err error) {
+	var (
+		bytesConsumed   uint64
+		chunkSize       uint64
+		fragmentHeader  [rpcRecordHeaderBytes]byte
+		fragmentSize    uint64
+		isFirstFragment bool
+		isLastFragment  bool
+		recordSize      uint64
+		u32             uint32
+	)
+
+	if rpcConn.options.AliasBytes {
+		rpcConn.record = nil
+	} else {
+		rpcConn.record = rpcConn.record[:0]
+	}
+	rpcConn.body = nil
+
+	isFirstFragment = true
+
+	for !isLastFragment {
+		_, err = io.ReadFull(rpcConn.r, fragmentHeader[:])
+		if nil != err {
+			if (io.EOF == err) && !isFirstFragment {
+				err = io.ErrUnexpectedEOF
+			}
+			return
+		}
+		isFirstFragment = false
+
+		u32 = uint32(fragmentHeader[0])<<24 | uint32(fragmentHeader[1])<<16 | uint32(fragmentHeader[2])<<8 | uint32(fragmentHeader[3])
+		isLastFragment = (rpcLastFragmentFlag == (u32 & rpcLastFragmentFlag))
+		fragmentSize = uint64(u32 & rpcFragmentSizeMask)
+
+		recordSize += fragmentSize
+		if rpcMaxRecordSize < recordSize {
+			err = fmt.Errorf("record size (at least 0x%X) exceeds maximum allowed (0x%X)", recordSize, rpcMaxRecordSize)
+			return
+		}
+
+		for ; 0 < fragmentSize; fragmentSize -= chunkSize {
+			chunkSize = fragmentSize
+			if rpcReadChunkSize < chunkSize {
+				chunkSize = rpcReadChunkSize
+			}
+			rpcConn.record = append(rpcConn.record, make([]byte, chunkSize)...)
+			_, err = io.ReadFull(rpcConn.r, rpcConn.record[uint64(len(rpcConn.record))-chunkSize:])
+			if nil != err {
+				if io.EOF == err {
+					err = io.ErrUnexpectedEOF
+				}
+				return
+			}
+		}
+	}
+
+	bytesConsumed, err = UnpackWithOptions(rpcConn.record, headerPtr, &rpcConn.options)
+	if nil != err {
+		return
+	}
+
+	rpcConn.body = rpcConn.record[bytesConsumed:]
+
+	return
+}
+
+// readBody unpacks the body of the record just read into bodyPtr (or discards it if bodyPtr is nil).
+func (rpcConn *rpcConn) readBody(bodyPtr interface{}) (err error) {
+	var (
+		bytesConsumed uint64
+	)
+
+	if nil == bodyPtr {
+		rpcConn.body = nil
+		return
+	}
+
+	bytesConsumed, err = UnpackWithOptions(rpcConn.body, bodyPtr, &rpcConn.options)
+	if nil != err {
+		return
+	}
+
+	if uint64(len(rpcConn.body)) > bytesConsumed {
+		err = newUnpackError(bytesConsumed, ErrTrailingBytes, "0x%X bytes remain in record body", uint64(len(rpcConn.body))-bytesConsumed)
+		return
+	}
+
+	rpcConn.body = nil
+
+	return
+}
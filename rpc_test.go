@@ -0,0 +1,282 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type RPCArgs struct {
+	A int32 `xdr:""`
+	B int32 `xdr:""`
+}
+
+type RPCReply struct {
+	Sum     int32  `xdr:""`
+	Comment string `xdr:"string,max=32"`
+}
+
+type RPCBlob struct {
+	Data []byte `xdr:""`
+}
+
+type RPCArith struct{}
+
+func (rpcArith *RPCArith) Add(args *RPCArgs, reply *RPCReply) (err error) {
+	reply.Sum = args.A + args.B
+	reply.Comment = "added"
+	return
+}
+
+func (rpcArith *RPCArith) Fail(args *RPCArgs, reply *RPCReply) (err error) {
+	err = errors.New("intentional failure")
+	return
+}
+
+func (rpcArith *RPCArith) Unpackable(args *RPCArgs, reply *RPCReply) (err error) {
+	reply.Comment = strings.Repeat("x", 33)
+	return
+}
+
+func TestRPCCodecs(t *testing.T) {
+	var (
+		client     *rpc.Client
+		clientConn net.Conn
+		err        error
+		i          int32
+		reply      RPCReply
+		server     *rpc.Server
+		serverConn net.Conn
+	)
+
+	server = rpc.NewServer()
+	err = server.Register(&RPCArith{})
+	if nil != err {
+		t.Fatalf("server.Register(&RPCArith{}) received unexpected error: %v", err)
+	}
+
+	clientConn, serverConn = net.Pipe()
+
+	go server.ServeCodec(NewServerCodec(serverConn))
+
+	client = rpc.NewClientWithCodec(NewClientCodec(clientConn))
+
+	for i = 0; i < 4; i++ {
+		reply = RPCReply{}
+		err = client.Call("RPCArith.Add", &RPCArgs{A: i, B: -7}, &reply)
+		if nil != err {
+			t.Fatalf("client.Call(\"RPCArith.Add\") received unexpected error: %v", err)
+		}
+		if (i-7 != reply.Sum) || ("added" != reply.Comment) {
+			t.Fatalf("client.Call(\"RPCArith.Add\") returned unexpected reply: %+v", reply)
+		}
+	}
+
+	err = client.Call("RPCArith.Fail", &RPCArgs{}, &reply)
+	if (nil == err) || ("intentional failure" != err.Error()) {
+		t.Fatalf("client.Call(\"RPCArith.Fail\") returned unexpected error: %v", err)
+	}
+
+	err = client.Call("RPCArith.Unpackable", &RPCArgs{}, &reply)
+	if (nil == err) || !strings.HasPrefix(err.Error(), rpcBodyErrorPrefix) {
+		t.Fatalf("client.Call(\"RPCArith.Unpackable\") returned unexpected error: %v", err)
+	}
+
+	err = client.Call("RPCArith.Missing", &RPCArgs{}, &reply)
+	if nil == err {
+		t.Fatalf("client.Call(\"RPCArith.Missing\") should have failed")
+	}
+
+	err = client.Call("RPCArith.Add", map[int]int{}, &reply)
+	if nil == err {
+		t.Fatalf("client.Call(\"RPCArith.Add\", <unpackable args>) should have failed")
+	}
+
+	// Verify the connection remains usable following the above failures
+
+	err = client.Call("RPCArith.Add", &RPCArgs{A: 1, B: 2}, &reply)
+	if (nil != err) || (3 != reply.Sum) {
+		t.Fatalf("client.Call(\"RPCArith.Add\") returned unexpected reply (%+v) or error (%v)", reply, err)
+	}
+
+	err = client.Close()
+	if nil != err {
+		t.Fatalf("client.Close() received unexpected error: %v", err)
+	}
+}
+
+type rpcBufferConn struct {
+	bytes.Buffer
+}
+
+func (rpcBufferConn *rpcBufferConn) Close() (err error) {
+	return
+}
+
+func TestRPCRecordMarking(t *testing.T) {
+	var (
+		args           RPCArgs
+		argsPacked     []byte
+		blob           *RPCBlob
+		blobData       string
+		blobPacked     []byte
+		conn           *rpcBufferConn
+		err            error
+		firstBlob      RPCBlob
+		header         rpcRequestHeader
+		headerPacked   []byte
+		memStatsAfter  runtime.MemStats
+		memStatsBefore runtime.MemStats
+		record         []byte
+		request        rpc.Request
+		rpcConnWriter  rpcConn
+		secondBlob     RPCBlob
+		serverCodec    rpc.ServerCodec
+	)
+
+	headerPacked, err = Pack(rpcRequestHeader{ServiceMethod: "RPCArith.Add", Seq: 7})
+	if nil != err {
+		t.Fatalf("Pack(rpcRequestHeader{}) received unexpected error: %v", err)
+	}
+	argsPacked, err = Pack(RPCArgs{A: 1, B: 2})
+	if nil != err {
+		t.Fatalf("Pack(RPCArgs{}) received unexpected error: %v", err)
+	}
+	record = append(headerPacked, argsPacked...)
+
+	// Send record as two fragments (splitting the header) as a non-Go peer might
+
+	conn = &rpcBufferConn{}
+	_, _ = conn.Write([]byte{0x00, 0x00, 0x00, 0x06})
+	_, _ = conn.Write(record[:6])
+	_, _ = conn.Write([]byte{0x80, 0x00, 0x00, byte(len(record) - 6)})
+	_, _ = conn.Write(record[6:])
+
+	serverCodec = NewServerCodec(conn)
+
+	err = serverCodec.ReadRequestHeader(&request)
+	if nil != err {
+		t.Fatalf("serverCodec.ReadRequestHeader() received unexpected error: %v", err)
+	}
+	if ("RPCArith.Add" != request.ServiceMethod) || (7 != request.Seq) {
+		t.Fatalf("serverCodec.ReadRequestHeader() returned unexpected request: %+v", request)
+	}
+	err = serverCodec.ReadRequestBody(&args)
+	if nil != err {
+		t.Fatalf("serverCodec.ReadRequestBody() received unexpected error: %v", err)
+	}
+	if (RPCArgs{A: 1, B: 2}) != args {
+		t.Fatalf("serverCodec.ReadRequestBody() returned unexpected args: %+v", args)
+	}
+
+	// Verify orderly shutdown (EOF between records) & truncation (EOF within a record) are distinguished
+
+	err = serverCodec.ReadRequestHeader(&request)
+	if io.EOF != err {
+		t.Fatalf("serverCodec.ReadRequestHeader(<at EOF>) returned unexpected error: %v", err)
+	}
+
+	_, _ = conn.Write([]byte{0x80, 0x00, 0x00, 0x10, 0x00})
+	err = serverCodec.ReadRequestHeader(&request)
+	if io.ErrUnexpectedEOF != err {
+		t.Fatalf("serverCodec.ReadRequestHeader(<truncated record>) returned unexpected error: %v", err)
+	}
+
+	// Verify a hostile record size is rejected before being allocated
+
+	conn.Reset()
+	_, _ = conn.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	err = serverCodec.ReadRequestHeader(&request)
+	if nil == err {
+		t.Fatalf("serverCodec.ReadRequestHeader(<hostile record size>) should have failed")
+	}
+
+	// Verify a fragment size claimed (but not sent) by a peer is not allocated in advance of its bytes
+
+	conn.Reset()
+	_, _ = conn.Write([]byte{0x84, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	runtime.ReadMemStats(&memStatsBefore)
+	err = serverCodec.ReadRequestHeader(&request)
+	runtime.ReadMemStats(&memStatsAfter)
+	if io.ErrUnexpectedEOF != err {
+		t.Fatalf("serverCodec.ReadRequestHeader(<unsent 64 MiB fragment>) returned unexpected error: %v", err)
+	}
+	if (rpcMaxRecordSize / 16) < (memStatsAfter.TotalAlloc - memStatsBefore.TotalAlloc) {
+		t.Fatalf("serverCodec.ReadRequestHeader(<unsent 64 MiB fragment>) allocated %d bytes", memStatsAfter.TotalAlloc-memStatsBefore.TotalAlloc)
+	}
+
+	// Verify a deeply nested body is rejected (rather than exhausting the stack)
+
+	conn.Reset()
+	headerPacked, err = Pack(rpcRequestHeader{ServiceMethod: "Deep.Node", Seq: 8})
+	if nil != err {
+		t.Fatalf("Pack(rpcRequestHeader{}) received unexpected error: %v", err)
+	}
+	record = append(headerPacked, deepNodeSrc...)
+	_, _ = conn.Write([]byte{0x80 | byte(len(record)>>24), byte(len(record) >> 16), byte(len(record) >> 8), byte(len(record))})
+	_, _ = conn.Write(record)
+	err = serverCodec.ReadRequestHeader(&request)
+	if nil != err {
+		t.Fatalf("serverCodec.ReadRequestHeader(<deeply nested record>) received unexpected error: %v", err)
+	}
+	err = serverCodec.ReadRequestBody(&DeepNode{})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("serverCodec.ReadRequestBody(<deeply nested record>) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+
+	// Verify the Limits used may be supplied
+
+	conn.Reset()
+	_, _ = conn.Write([]byte{0x80, 0x00, 0x00, byte(len(headerPacked) + len(argsPacked))})
+	_, _ = conn.Write(headerPacked)
+	_, _ = conn.Write(argsPacked)
+	serverCodec = NewServerCodecWithOptions(conn, &UnpackOptions{Limits: Limits{MaxStringLength: 8}})
+	err = serverCodec.ReadRequestHeader(&request)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("serverCodec.ReadRequestHeader(<ServiceMethod exceeding Limits.MaxStringLength>) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+
+	// Verify args unpacked with AliasBytes are not overwritten by reading the next record
+
+	conn.Reset()
+	for _, blobData = range []string{"first", "second"} {
+		blobPacked, err = Pack(RPCBlob{Data: []byte(blobData)})
+		if nil != err {
+			t.Fatalf("Pack(RPCBlob{}) received unexpected error: %v", err)
+		}
+		_, _ = conn.Write([]byte{0x80, 0x00, 0x00, byte(len(headerPacked) + len(blobPacked))})
+		_, _ = conn.Write(headerPacked)
+		_, _ = conn.Write(blobPacked)
+	}
+	serverCodec = NewServerCodecWithOptions(conn, &UnpackOptions{AliasBytes: true})
+	for _, blob = range []*RPCBlob{&firstBlob, &secondBlob} {
+		err = serverCodec.ReadRequestHeader(&request)
+		if nil != err {
+			t.Fatalf("serverCodec.ReadRequestHeader(<RPCBlob>) received unexpected error: %v", err)
+		}
+		err = serverCodec.ReadRequestBody(blob)
+		if nil != err {
+			t.Fatalf("serverCodec.ReadRequestBody(<RPCBlob>) received unexpected error: %v", err)
+		}
+	}
+	if ("first" != string(firstBlob.Data)) || ("second" != string(secondBlob.Data)) {
+		t.Fatalf("serverCodec.ReadRequestBody(<RPCBlob>) with AliasBytes returned %q then %q", firstBlob.Data, secondBlob.Data)
+	}
+
+	// Verify records written are single fragments
+
+	conn.Reset()
+	rpcConnWriter = newRPCConn(conn, nil)
+	err = rpcConnWriter.writeRecord(header, argsPacked)
+	if nil != err {
+		t.Fatalf("writeRecord() received unexpected error: %v", err)
+	}
+	if (0x80 != conn.Bytes()[0]) || (uint64(conn.Len()-4) != uint64(conn.Bytes()[3])) {
+		t.Fatalf("writeRecord() wrote unexpected 0x%X", conn.Bytes())
+	}
+}
@@ -0,0 +1,371 @@
+package xdr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SchemaKind identifies the XDR data type described by a Schema.
+type SchemaKind int
+
+const (
+	SchemaVoid        SchemaKind = iota // void (e.g. an arm of a Discriminated Union holding no data)
+	SchemaInt                           // Integer
+	SchemaUint                          // Unsigned Integer
+	SchemaEnum                          // Enumeration
+	SchemaBool                          // Boolean
+	SchemaHyper                         // Hyper Integer
+	SchemaUhyper                        // Unsigned Hyper Integer
+	SchemaFloat                         // Floating-Point (only in schemas parsed by ParseSchema())
+	SchemaDouble                        // Double-Precision Floating-Point (only in schemas parsed by ParseSchema())
+	SchemaFixedOpaque                   // Fixed-Length Opaque Data
+	SchemaOpaque                        // Variable-Length Opaque Data
+	SchemaString                        // String
+	SchemaFixedArray                    // Fixed-Length Array
+	SchemaArray                         // Variable-Length Array
+	SchemaStruct                        // Structure
+	SchemaUnion                         // Discriminated Union
+	SchemaOptional                      // Optional-Data
+)
+
+var schemaKindNames = map[SchemaKind]string{
+	SchemaVoid:        "void",
+	SchemaInt:         "int",
+	SchemaUint:        "unsigned int",
+	SchemaEnum:        "enum",
+	SchemaBool:        "bool",
+	SchemaHyper:       "hyper",
+	SchemaUhyper:      "unsigned hyper",
+	SchemaFloat:       "float",
+	SchemaDouble:      "double",
+	SchemaFixedOpaque: "opaque[]",
+	SchemaOpaque:      "opaque<>",
+	SchemaString:      "string<>",
+	SchemaFixedArray:  "[]",
+	SchemaArray:       "<>",
+	SchemaStruct:      "struct",
+	SchemaUnion:       "union",
+	SchemaOptional:    "*",
+}
+
+// String returns the RFC 4506 spelling of schemaKind.
+func (schemaKind SchemaKind) String() (s string) {
+	var (
+		ok bool
+	)
+
+	s, ok = schemaKindNames[schemaKind]
+	if !ok {
+		s = fmt.Sprintf("SchemaKind(%d)", int(schemaKind))
+	}
+
+	return
+}
+
+// Schema describes the XDR encoding of a type, whether derived from a Go type or parsed from an RFC 4506 (.x) file.
+//
+// A Schema may reference itself (e.g. via the Elem of a SchemaOptional) to describe a recursive type.
+type Schema struct {
+	Kind         SchemaKind
+	Name         string           // Name of a named type (e.g. a struct, enum, union, or typedef), else ""
+	Size         uint32           // Length of a SchemaFixedOpaque or SchemaFixedArray, or maximum length of a SchemaOpaque, SchemaString, or SchemaArray (0 if unbounded)
+	Elem         *Schema          // Element type of a SchemaFixedArray or SchemaArray, or referent of a SchemaOptional
+	Fields       []SchemaField    // Fields of a SchemaStruct
+	Discriminant SchemaField      // Discriminant of a SchemaUnion
+	Arms         []SchemaArm      // Arms of a SchemaUnion
+	Enumerators  map[int32]string // Declared values of a SchemaEnum (or nil if any value is permitted)
+}
+
+// SchemaField describes a field of a SchemaStruct or the discriminant of a SchemaUnion.
+type SchemaField struct {
+	Name string
+	Type *Schema
+}
+
+// SchemaArm describes an arm of a SchemaUnion (where a void arm has a Field.Type.Kind of SchemaVoid).
+//
+// An arm of a Go union also having an unknown arm (whose Field.Type is the opaque<> holding any other arm) is
+// IsWrapped, as its encoding is preceded by its length (just as if it were encoded within that opaque<>).
+type SchemaArm struct {
+	Cases     []int64 // Discriminant values selecting this arm
+	IsDefault bool    // Set if this arm is selected by any discriminant value not selecting another arm
+	IsWrapped bool    // Set if this arm is encoded preceded by its length (i.e. as Variable-Length Opaque Data)
+	Field     SchemaField
+}
+
+// TypeName returns the name of schema if it is a named type, else its RFC 4506 spelling (e.g. "opaque<16>").
+func (schema *Schema) TypeName() (typeName string) {
+	if "" != schema.Name {
+		typeName = schema.Name
+		return
+	}
+
+	switch schema.Kind {
+	case SchemaFixedOpaque:
+		typeName = fmt.Sprintf("opaque[%d]", schema.Size)
+	case SchemaOpaque, SchemaString:
+		typeName = strings.TrimSuffix(schema.Kind.String(), "<>") + schemaMaxSizeString(schema.Size)
+	case SchemaFixedArray:
+		typeName = fmt.Sprintf("%s[%d]", schema.Elem.TypeName(), schema.Size)
+	case SchemaArray:
+		typeName = schema.Elem.TypeName() + schemaMaxSizeString(schema.Size)
+	case SchemaOptional:
+		typeName = schema.Elem.TypeName() + "*"
+	default:
+		typeName = schema.Kind.String()
+	}
+
+	return
+}
+
+func schemaMaxSizeString(maxSize uint32) (s string) {
+	if 0 == maxSize {
+		s = "<>"
+	} else {
+		s = fmt.Sprintf("<%d>", maxSize)
+	}
+	return
+}
+
+// selectArm returns the arm of the SchemaUnion schema selected by discriminant.
+func (schema *Schema) selectArm(discriminant int64) (arm *SchemaArm, ok bool) {
+	var (
+		armIndex  int
+		caseValue int64
+	)
+
+	for armIndex = range schema.Arms {
+		for _, caseValue = range schema.Arms[armIndex].Cases {
+			if caseValue == discriminant {
+				arm = &schema.Arms[armIndex]
+				ok = true
+				return
+			}
+		}
+	}
+
+	for armIndex = range schema.Arms {
+		if schema.Arms[armIndex].IsDefault {
+			arm = &schema.Arms[armIndex]
+			ok = true
+			return
+		}
+	}
+
+	ok = false
+	return
+}
+
+// minimumSize returns a lower bound on the number of bytes encoding a value described by schema.
+func (schema *Schema) minimumSize(inProgress map[*Schema]bool) (minimumSize uint64) {
+	var (
+		arm            SchemaArm
+		armMinimumSize uint64
+		field          SchemaField
+		isFirstArm     bool
+	)
+
+	if inProgress[schema] {
+		minimumSize = 0 // Note: Only a lower bound is needed, so simply stop at a recursive reference
+		return
+	}
+	inProgress[schema] = true
+	defer delete(inProgress, schema)
+
+	switch schema.Kind {
+	case SchemaVoid:
+		minimumSize = 0
+	case SchemaInt, SchemaUint, SchemaEnum, SchemaBool, SchemaFloat, SchemaOpaque, SchemaString, SchemaArray, SchemaOptional:
+		minimumSize = 4
+	case SchemaHyper, SchemaUhyper, SchemaDouble:
+		minimumSize = 8
+	case SchemaFixedOpaque:
+		minimumSize = (uint64(schema.Size) + 3) / 4 * 4
+	case SchemaFixedArray:
+		minimumSize = uint64(schema.Size) * schema.Elem.minimumSize(inProgress)
+	case SchemaStruct:
+		for _, field = range schema.Fields {
+			minimumSize += field.Type.minimumSize(inProgress)
+		}
+	case SchemaUnion:
+		isFirstArm = true
+		for _, arm = range schema.Arms {
+			armMinimumSize = arm.Field.Type.minimumSize(inProgress)
+			if arm.IsWrapped {
+				armMinimumSize += 4
+			}
+			if isFirstArm || (armMinimumSize < minimumSize) {
+				minimumSize = armMinimumSize
+			}
+			isFirstArm = false
+		}
+		minimumSize += 4
+	}
+
+	return
+}
+
+// schemaOf returns the Schema describing the encoding of Go type typeOf by Pack() and Unpack().
+func schemaOf(typeOf reflect.Type) (schema *Schema, err error) {
+	schema, err = schemaOfRecursive(typeOf, xdrTag{}, make(map[reflect.Type]*Schema))
+	return
+}
+
+// schemaOfType returns the Schema describing the Go type of typIF, which may itself be a *Schema, a reflect.Type,
+// or a value (or pointer to a value) of the Go type.
+func schemaOfType(typIF interface{}) (schema *Schema, err error) {
+	var (
+		ok     bool
+		typeOf reflect.Type
+	)
+
+	schema, ok = typIF.(*Schema)
+	if ok {
+		if nil == schema {
+			err = fmt.Errorf("nil *Schema")
+		}
+		return
+	}
+
+	typeOf, ok = typIF.(reflect.Type)
+	if !ok {
+		typeOf = reflect.TypeOf(typIF)
+		if nil == typeOf {
+			err = fmt.Errorf("nil type")
+			return
+		}
+	}
+
+	schema, err = schemaOf(typeOf)
+
+	return
+}
+
+func schemaOfRecursive(typeOf reflect.Type, tag xdrTag, structSchemas map[reflect.Type]*Schema) (schema *Schema, err error) {
+	var (
+		arm          SchemaArm
+		discriminant int32
+		field        xdrField
+		fieldIndex   int
+		fieldSchema  *Schema
+		names        map[int32]string
+		ok           bool
+		registration *interfaceRegistration
+		structLayout *xdrStructLayout
+	)
+
+	if tag.optional {
+		schema = &Schema{Kind: SchemaOptional}
+		schema.Elem, err = schemaOfRecursive(typeOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize}, structSchemas)
+		return
+	}
+
+	switch typeOf.Kind() {
+	case reflect.Ptr:
+		schema, err = schemaOfRecursive(typeOf.Elem(), tag, structSchemas)
+	case reflect.Bool:
+		schema = &Schema{Kind: SchemaBool}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case "Enumeration" == tag.name:
+			schema = &Schema{Kind: SchemaEnum}
+			names, ok = lookupEnumeration(typeOf)
+			if ok {
+				schema.Name = typeOf.Name()
+				schema.Enumerators = names
+			}
+		case 8 == xdrIntegerSize(typeOf.Kind(), tag):
+			if (reflect.Int == typeOf.Kind()) || (reflect.Int64 == typeOf.Kind()) {
+				schema = &Schema{Kind: SchemaHyper}
+			} else {
+				schema = &Schema{Kind: SchemaUhyper}
+			}
+		default:
+			if (reflect.Uint <= typeOf.Kind()) && (reflect.Uint64 >= typeOf.Kind()) {
+				schema = &Schema{Kind: SchemaUint}
+			} else {
+				schema = &Schema{Kind: SchemaInt}
+			}
+		}
+	case reflect.Array:
+		if reflect.Uint8 == typeOf.Elem().Kind() {
+			schema = &Schema{Kind: SchemaFixedOpaque, Size: uint32(typeOf.Len())}
+		} else {
+			schema = &Schema{Kind: SchemaFixedArray, Size: uint32(typeOf.Len())}
+			schema.Elem, err = schemaOfRecursive(typeOf.Elem(), xdrTag{}, structSchemas)
+		}
+	case reflect.Slice:
+		if reflect.Uint8 == typeOf.Elem().Kind() {
+			if "String" == tag.name {
+				schema = &Schema{Kind: SchemaString, Size: uint32(tag.maxSize)}
+			} else {
+				schema = &Schema{Kind: SchemaOpaque, Size: uint32(tag.maxSize)}
+			}
+		} else {
+			schema = &Schema{Kind: SchemaArray, Size: uint32(tag.maxSize)}
+			schema.Elem, err = schemaOfRecursive(typeOf.Elem(), xdrTag{}, structSchemas)
+		}
+	case reflect.String:
+		schema = &Schema{Kind: SchemaString, Size: uint32(tag.maxSize)}
+	case reflect.Interface:
+		schema, ok = structSchemas[typeOf]
+		if ok {
+			return
+		}
+		registration, ok = lookupInterface(typeOf)
+		if !ok {
+			err = fmt.Errorf("%v has Kind() == reflect.Interface lacking a static XDR encoding (see RegisterInterface())", typeOf)
+			return
+		}
+		schema = &Schema{Kind: SchemaUnion, Name: typeOf.Name(), Discriminant: SchemaField{Name: "Discriminant", Type: &Schema{Kind: SchemaInt}}}
+		structSchemas[typeOf] = schema
+		for _, discriminant = range registration.sortedDiscriminants() {
+			fieldSchema, err = schemaOfRecursive(registration.concreteTypes[discriminant], xdrTag{name: tag.name, maxSize: tag.maxSize}, structSchemas)
+			if nil != err {
+				return
+			}
+			schema.Arms = append(schema.Arms, SchemaArm{Cases: []int64{int64(discriminant)}, Field: SchemaField{Name: concreteTypeName(registration.concreteTypes[discriminant]), Type: fieldSchema}})
+		}
+	case reflect.Struct:
+		schema, ok = structSchemas[typeOf]
+		if ok {
+			return
+		}
+		structLayout, err = xdrStructLayoutOf(typeOf)
+		if nil != err {
+			return
+		}
+		schema = &Schema{Name: typeOf.Name()}
+		structSchemas[typeOf] = schema
+		for fieldIndex, field = range structLayout.fields {
+			fieldSchema, err = schemaOfRecursive(typeOf.FieldByIndex(field.index).Type, field.tag, structSchemas)
+			if nil != err {
+				return
+			}
+			switch {
+			case !structLayout.isUnion:
+				schema.Fields = append(schema.Fields, SchemaField{Name: field.name, Type: fieldSchema})
+			case 0 == fieldIndex:
+				schema.Discriminant = SchemaField{Name: field.name, Type: fieldSchema}
+			default:
+				arm = SchemaArm{Cases: field.tag.cases, IsDefault: field.tag.isDefault || field.tag.isUnknown, IsWrapped: structLayout.isWrapped && !field.tag.isUnknown, Field: SchemaField{Name: field.name, Type: fieldSchema}}
+				if field.tag.isUnknown {
+					arm.Field.Type = &Schema{Kind: SchemaOpaque, Size: uint32(field.tag.maxSize)} // Note: As on the wire, the unknown arm is opaque<>
+				} else if (SchemaStruct == fieldSchema.Kind) && (0 == len(fieldSchema.Fields)) && ("" == fieldSchema.Name) {
+					arm.Field.Type = &Schema{Kind: SchemaVoid} // Note: An unnamed struct{} arm (e.g. *struct{}) is void
+				}
+				schema.Arms = append(schema.Arms, arm)
+			}
+		}
+		if structLayout.isUnion {
+			schema.Kind = SchemaUnion
+		} else {
+			schema.Kind = SchemaStruct
+		}
+	default:
+		err = fmt.Errorf("%v has Kind() == %s lacking a static XDR encoding", typeOf, reflectKindName(typeOf.Kind()))
+	}
+
+	return
+}
@@ -0,0 +1,371 @@
+package xdr
+
+import (
+	"fmt"
+	"math"
+)
+
+// schemaNode records the decoding of one value from src as directed by its Schema.
+//
+// Problems not preventing decoding from continuing (e.g. non-zero padding) are recorded in notes, while a problem
+// that does (e.g. truncation) is recorded in err (after which no further schemaNode's are decoded). Either would
+// have caused Unpack() (in UnpackModeDefault) to fail.
+type schemaNode struct {
+	schema   *Schema
+	name     string         // Field or arm name, "[<index>]" for an array element, or "" for the outermost value
+	offset   uint64         // Offset in src of the first byte of the value
+	header   uint64         // Bytes of a length, Boolean, or discriminant (and any arm length) preceding any children
+	data     uint64         // Bytes of a leaf value (excluding padding)
+	padding  uint64         // Bytes of padding following data
+	size     uint64         // Bytes encoding the value (including those of its children)
+	value    interface{}    // Decoded leaf value, length, Boolean, or discriminant (see decodeSchemaNode())
+	children []*schemaNode  // Fields, elements, selected arm, or referent (as appropriate)
+	notes    []*UnpackError // Problems tolerated by decodeSchemaNode() (e.g. non-zero padding)
+	err      error
+}
+
+// schemaDecodeState bounds the resources consumed by decodeSchemaNode() (whose caller may not trust src).
+type schemaDecodeState struct {
+	maxDepth     uint64             // Nesting depth of arrays, structs, unions, & Optional-Data beyond which decoding fails
+	maxNodes     uint64             // Count of schemaNode's beyond which decoding fails
+	nodes        uint64             // Count of schemaNode's decoded so far
+	minimumSizes map[*Schema]uint64 // Caches (*Schema).minimumSize() of the element of each array decoded
+}
+
+// newSchemaDecodeState returns a schemaDecodeState for the decoding of src limited to maxDepth.
+//
+// A schemaNode is encoded in at least one byte of src unless it is encoded in none (e.g. a void) or shares those of
+// a child (e.g. a struct holding one field), so decoding is limited to len(src) + DefaultMaxZeroSizeElements of them.
+func newSchemaDecodeState(src []byte, maxDepth uint64) (state *schemaDecodeState) {
+	state = &schemaDecodeState{
+		maxDepth:     maxDepth,
+		maxNodes:     uint64(len(src)) + DefaultMaxZeroSizeElements,
+		minimumSizes: make(map[*Schema]uint64),
+	}
+	return
+}
+
+// decodeSchema decodes a value described by schema from src (nested no deeper than maxDepth), returning the
+// outermost schemaNode.
+//
+// On failure, err is that of the innermost schemaNode on which decoding stopped (and root remains partially decoded).
+func decodeSchema(src []byte, schema *Schema, maxDepth uint64) (root *schemaNode, err error) {
+	root, err = decodeSchemaNode(src, 0, schema, "", 0, newSchemaDecodeState(src, maxDepth))
+	return
+}
+
+// decodeSchemaNode decodes a value described by schema from src at offset.
+//
+// The value of the returned schemaNode depends on schema.Kind:
+//
+//	SchemaInt, SchemaEnum, SchemaHyper:      int64
+//	SchemaUint, SchemaUhyper:                uint64
+//	SchemaBool, SchemaOptional:              bool
+//	SchemaFloat, SchemaDouble:               float64
+//	SchemaFixedOpaque, SchemaOpaque:         []byte (referencing src)
+//	SchemaString:                            string
+//	SchemaArray:                             uint64 (the length)
+//	SchemaUnion:                             int64 (the discriminant)
+//	SchemaVoid, SchemaFixedArray, SchemaStruct: nil
+func decodeSchemaNode(src []byte, offset uint64, schema *Schema, name string, depth uint64, state *schemaDecodeState) (node *schemaNode, err error) {
+	var (
+		arm          *SchemaArm
+		b            bool
+		child        *schemaNode
+		elementIndex uint64
+		field        SchemaField
+		length       uint64
+		minimumSize  uint64
+		ok           bool
+		u64          uint64
+	)
+
+	node = &schemaNode{schema: schema, name: name, offset: offset}
+
+	defer func() {
+		node.err = err
+		if nil == err {
+			for _, child = range node.children {
+				node.size += child.size
+			}
+			node.size += node.header + node.data + node.padding
+		}
+	}()
+
+	// Enforce state's limits (before any children are decoded)
+
+	state.nodes++
+	if state.maxNodes < state.nodes {
+		err = newUnpackError(offset, ErrLimitExceeded, "decoding exceeds %d values", state.maxNodes)
+		return
+	}
+
+	switch schema.Kind {
+	case SchemaFixedArray, SchemaArray, SchemaStruct, SchemaUnion, SchemaOptional:
+		depth++
+		if state.maxDepth < depth {
+			err = newUnpackError(offset, ErrLimitExceeded, "nesting depth exceeds %d", state.maxDepth)
+			return
+		}
+	}
+
+	// Decode the value (and any children)
+
+	switch schema.Kind {
+	case SchemaVoid:
+		// Nothing to decode
+	case SchemaInt, SchemaEnum:
+		u64, err = decodeSchemaWord(src, offset, 4)
+		if nil != err {
+			return
+		}
+		node.data = 4
+		node.value = int64(int32(uint32(u64)))
+		if (SchemaEnum == schema.Kind) && (nil != schema.Enumerators) {
+			_, ok = schema.Enumerators[int32(uint32(u64))]
+			if !ok {
+				node.notes = append(node.notes, newSchemaNote(offset, ErrInvalidEnumeration, "%d is not declared", node.value))
+			}
+		}
+	case SchemaUint:
+		u64, err = decodeSchemaWord(src, offset, 4)
+		if nil != err {
+			return
+		}
+		node.data = 4
+		node.value = u64
+	case SchemaHyper:
+		u64, err = decodeSchemaWord(src, offset, 8)
+		if nil != err {
+			return
+		}
+		node.data = 8
+		node.value = int64(u64)
+	case SchemaUhyper:
+		u64, err = decodeSchemaWord(src, offset, 8)
+		if nil != err {
+			return
+		}
+		node.data = 8
+		node.value = u64
+	case SchemaFloat:
+		u64, err = decodeSchemaWord(src, offset, 4)
+		if nil != err {
+			return
+		}
+		node.data = 4
+		node.value = float64(math.Float32frombits(uint32(u64)))
+	case SchemaDouble:
+		u64, err = decodeSchemaWord(src, offset, 8)
+		if nil != err {
+			return
+		}
+		node.data = 8
+		node.value = math.Float64frombits(u64)
+	case SchemaBool:
+		b, node.notes, err = decodeSchemaBool(src, offset, node.notes)
+		if nil != err {
+			return
+		}
+		node.data = 4
+		node.value = b
+	case SchemaFixedOpaque:
+		err = decodeSchemaBytes(src, offset, uint64(schema.Size), node)
+	case SchemaOpaque, SchemaString:
+		length, err = decodeSchemaWord(src, offset, 4)
+		if nil != err {
+			return
+		}
+		node.header = 4
+		if (0 != schema.Size) && (uint64(schema.Size) < length) {
+			node.notes = append(node.notes, newSchemaNote(offset, ErrMaxSizeExceeded, "length %d exceeds maximum %d", length, schema.Size))
+		}
+		err = decodeSchemaBytes(src, offset+4, length, node)
+		if (nil == err) && (SchemaString == schema.Kind) {
+			node.value = string(node.value.([]byte))
+		}
+	case SchemaFixedArray, SchemaArray:
+		if SchemaFixedArray == schema.Kind {
+			length = uint64(schema.Size)
+		} else {
+			length, err = decodeSchemaWord(src, offset, 4)
+			if nil != err {
+				return
+			}
+			node.header = 4
+			node.value = length
+			if (0 != schema.Size) && (uint64(schema.Size) < length) {
+				node.notes = append(node.notes, newSchemaNote(offset, ErrMaxSizeExceeded, "length %d exceeds maximum %d", length, schema.Size))
+			}
+		}
+		minimumSize, ok = state.minimumSizes[schema.Elem]
+		if !ok {
+			minimumSize = schema.Elem.minimumSize(make(map[*Schema]bool))
+			state.minimumSizes[schema.Elem] = minimumSize
+		}
+		if (0 != length) && (((0 == minimumSize) && (uint64(len(src)) < length)) || ((0 != minimumSize) && ((uint64(len(src))-offset-node.header)/minimumSize < length))) {
+			err = newUnpackError(offset, ErrTruncated, "No room for %d elements of %s", length, schema.Elem.TypeName())
+			return
+		}
+		u64 = offset + node.header
+		for elementIndex = 0; elementIndex < length; elementIndex++ {
+			child, err = decodeSchemaNode(src, u64, schema.Elem, fmt.Sprintf("[%d]", elementIndex), depth, state)
+			node.children = append(node.children, child)
+			if nil != err {
+				return
+			}
+			u64 += child.size
+		}
+	case SchemaStruct:
+		u64 = offset
+		for _, field = range schema.Fields {
+			child, err = decodeSchemaNode(src, u64, field.Type, field.Name, depth, state)
+			node.children = append(node.children, child)
+			if nil != err {
+				return
+			}
+			u64 += child.size
+		}
+	case SchemaUnion:
+		child, err = decodeSchemaNode(src, offset, schema.Discriminant.Type, schema.Discriminant.Name, depth, state)
+		if nil != err {
+			node.children = append(node.children, child)
+			return
+		}
+		node.header = child.size
+		node.notes = append(node.notes, child.notes...)
+		switch value := child.value.(type) {
+		case int64:
+			node.value = value
+		case uint64:
+			node.value = int64(value)
+		case bool:
+			if value {
+				node.value = int64(1)
+			} else {
+				node.value = int64(0)
+			}
+		default:
+			err = newUnpackError(offset, ErrInternal, "discriminant %s is a %s (not an int, unsigned int, enum, or bool)", schema.Discriminant.Name, schema.Discriminant.Type.TypeName())
+			return
+		}
+		arm, ok = schema.selectArm(node.value.(int64))
+		if !ok {
+			err = newUnpackError(offset, ErrInvalidDiscriminant, "no arm of %s selected by discriminant %v", schema.TypeName(), node.value)
+			return
+		}
+		if !arm.IsWrapped {
+			child, err = decodeSchemaNode(src, offset+node.header, arm.Field.Type, arm.Field.Name, depth, state)
+			node.children = append(node.children, child)
+			break
+		}
+		// Decode the arm from within the length wrapping it (which, as for Unpack(), it must exactly fill)
+		length, err = decodeSchemaWord(src, offset+node.header, 4)
+		if nil != err {
+			return
+		}
+		node.header += 4
+		if (uint64(len(src)) < offset+node.header) || (uint64(len(src))-offset-node.header < length) {
+			err = newUnpackError(offset+node.header-4, ErrTruncated, "No room for %d byte arm %s in src []byte", length, arm.Field.Name)
+			return
+		}
+		child, err = decodeSchemaNode(src[:offset+node.header+length], offset+node.header, arm.Field.Type, arm.Field.Name, depth, state)
+		node.children = append(node.children, child)
+		if (nil == err) && (length != child.size) {
+			err = newUnpackError(offset+node.header+child.size, ErrTrailingBytes, "arm %s did not fill its length (%d)", arm.Field.Name, length)
+		}
+	case SchemaOptional:
+		b, node.notes, err = decodeSchemaBool(src, offset, node.notes)
+		if nil != err {
+			return
+		}
+		node.header = 4
+		node.value = b
+		if b {
+			child, err = decodeSchemaNode(src, offset+4, schema.Elem, "*", depth, state)
+			node.children = append(node.children, child)
+		}
+	default:
+		err = newUnpackError(offset, ErrInternal, "unsupported %v", schema.Kind)
+	}
+
+	return
+}
+
+// decodeSchemaWord decodes a big-endian 4 or 8 byte word from src at offset.
+func decodeSchemaWord(src []byte, offset uint64, size uint64) (u64 uint64, err error) {
+	var (
+		i uint64
+	)
+
+	if uint64(len(src)) < (offset + size) {
+		err = newUnpackError(offset, ErrTruncated, "No room for %d byte value in src []byte", size)
+		return
+	}
+
+	for i = 0; i < size; i++ {
+		u64 = (u64 << 8) | uint64(src[offset+i])
+	}
+
+	return
+}
+
+// decodeSchemaBool decodes a Boolean from src at offset, noting (but tolerating) an encoding other than 0 or 1.
+func decodeSchemaBool(src []byte, offset uint64, notesIn []*UnpackError) (b bool, notes []*UnpackError, err error) {
+	var (
+		u64 uint64
+	)
+
+	notes = notesIn
+
+	u64, err = decodeSchemaWord(src, offset, 4)
+	if nil != err {
+		return
+	}
+
+	if 1 < u64 {
+		notes = append(notes, newSchemaNote(offset, ErrInvalidBoolean, "0x%08X treated as TRUE", u64))
+	}
+
+	b = (0 != u64)
+
+	return
+}
+
+// decodeSchemaBytes decodes length bytes (plus padding) from src at offset into node, noting non-zero padding.
+func decodeSchemaBytes(src []byte, offset uint64, length uint64, node *schemaNode) (err error) {
+	var (
+		i            uint64
+		paddedLength uint64
+	)
+
+	paddedLength = (length + 3) / 4 * 4
+
+	if (uint64(len(src)) < offset) || ((uint64(len(src)) - offset) < paddedLength) {
+		err = newUnpackError(offset, ErrTruncated, "No room for %d bytes (plus padding) in src []byte", length)
+		return
+	}
+
+	node.value = src[offset : offset+length]
+	node.data = length
+	node.padding = paddedLength - length
+
+	for i = offset + length; i < offset+paddedLength; i++ {
+		if 0 != src[i] {
+			node.notes = append(node.notes, newSchemaNote(i, ErrNonZeroPadding, "0x%02X", src[i]))
+			break
+		}
+	}
+
+	return
+}
+
+// newSchemaNote returns a problem tolerated by decodeSchemaNode() (see schemaNode).
+func newSchemaNote(offset uint64, cause error, format string, args ...interface{}) (note *UnpackError) {
+	note = &UnpackError{
+		Offset: offset,
+		Cause:  cause,
+		Detail: fmt.Sprintf("%v: ", cause) + fmt.Sprintf(format, args...),
+	}
+	return
+}
@@ -0,0 +1,772 @@
+package xdr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseSchema parses the RFC 4506 (section 6) XDR language specification in text, returning the Schema of each
+// type it defines by name. The resulting Schemas may be passed to Dump() (e.g. to decode data of a non-Go peer).
+//
+// Comments (/* ... */ and // ...) and lines beginning with % or # (as passed through by rpcgen) are ignored.
+// The quadruple type and RPC program definitions (RFC 5531) are not supported.
+func ParseSchema(text string) (schemas map[string]*Schema, err error) {
+	var (
+		aliasName string
+		parser    *schemaParser
+		typeName  string
+	)
+
+	parser = &schemaParser{
+		constants:    make(map[string]int64),
+		types:        make(map[string]*Schema),
+		defined:      make(map[string]bool),
+		aliases:      make(map[string]string),
+		firstUseLine: make(map[string]int),
+	}
+
+	err = parser.tokenize(text)
+	if nil != err {
+		return
+	}
+
+	for !parser.atEnd() {
+		err = parser.parseDefinition()
+		if nil != err {
+			return
+		}
+	}
+
+	for typeName = range parser.types {
+		if !parser.defined[typeName] {
+			err = fmt.Errorf("line %d: type %s is used but never defined", parser.firstUseLine[typeName], typeName)
+			return
+		}
+	}
+
+	for aliasName = range parser.aliases {
+		err = parser.resolveAlias(aliasName, make(map[string]bool))
+		if nil != err {
+			return
+		}
+	}
+
+	schemas = parser.types
+
+	return
+}
+
+type schemaToken struct {
+	text string
+	line int
+}
+
+type schemaParser struct {
+	tokens       []schemaToken
+	next         int                // Index of the next token in tokens to be consumed
+	constants    map[string]int64   // Values of constants & enumerators
+	types        map[string]*Schema // The (perhaps not yet defined) Schema of each named type mentioned so far
+	defined      map[string]bool    // Set for each named type defined so far
+	aliases      map[string]string  // Named types defined (by typedef) as merely another named type
+	firstUseLine map[string]int     // Line on which each named type was first mentioned
+}
+
+func (parser *schemaParser) tokenize(text string) (err error) {
+	var (
+		c     byte
+		end   int
+		line  int
+		start int
+	)
+
+	line = 1
+
+	for start = 0; start < len(text); {
+		c = text[start]
+		switch {
+		case '\n' == c:
+			line++
+			start++
+		case (' ' == c) || ('\t' == c) || ('\r' == c) || ('\f' == c) || ('\v' == c):
+			start++
+		case (('%' == c) || ('#' == c)) && ((0 == start) || ('\n' == text[start-1])):
+			end = strings.IndexByte(text[start:], '\n')
+			if -1 == end {
+				start = len(text)
+			} else {
+				start += end
+			}
+		case strings.HasPrefix(text[start:], "//"):
+			end = strings.IndexByte(text[start:], '\n')
+			if -1 == end {
+				start = len(text)
+			} else {
+				start += end
+			}
+		case strings.HasPrefix(text[start:], "/*"):
+			end = strings.Index(text[start+2:], "*/")
+			if -1 == end {
+				err = fmt.Errorf("line %d: unterminated comment", line)
+				return
+			}
+			line += strings.Count(text[start:start+2+end+2], "\n")
+			start += 2 + end + 2
+		case isSchemaIdentifierByte(c, true) || isSchemaDigit(c) || (('-' == c) && (start+1 < len(text)) && isSchemaDigit(text[start+1])):
+			for end = start + 1; (end < len(text)) && isSchemaIdentifierByte(text[end], false); end++ {
+			}
+			parser.tokens = append(parser.tokens, schemaToken{text: text[start:end], line: line})
+			start = end
+		case strings.IndexByte("{}[]<>();,=*:", c) >= 0:
+			parser.tokens = append(parser.tokens, schemaToken{text: text[start : start+1], line: line})
+			start++
+		default:
+			err = fmt.Errorf("line %d: unexpected character %q", line, c)
+			return
+		}
+	}
+
+	return
+}
+
+func isSchemaDigit(c byte) (isDigit bool) {
+	isDigit = ('0' <= c) && ('9' >= c)
+	return
+}
+
+func isSchemaIdentifierByte(c byte, isFirst bool) (isIdentifierByte bool) {
+	isIdentifierByte = (('a' <= c) && ('z' >= c)) || (('A' <= c) && ('Z' >= c)) || ('_' == c) || (!isFirst && isSchemaDigit(c))
+	return
+}
+
+func (parser *schemaParser) atEnd() (atEnd bool) {
+	atEnd = parser.next >= len(parser.tokens)
+	return
+}
+
+// line returns the line of the next token (or of the last token if all have been consumed).
+func (parser *schemaParser) line() (line int) {
+	switch {
+	case !parser.atEnd():
+		line = parser.tokens[parser.next].line
+	case 0 < len(parser.tokens):
+		line = parser.tokens[len(parser.tokens)-1].line
+	default:
+		line = 1
+	}
+	return
+}
+
+func (parser *schemaParser) peek() (text string) {
+	if parser.atEnd() {
+		text = ""
+	} else {
+		text = parser.tokens[parser.next].text
+	}
+	return
+}
+
+func (parser *schemaParser) consume() (text string) {
+	text = parser.peek()
+	parser.next++
+	return
+}
+
+func (parser *schemaParser) expect(text string) (err error) {
+	if text != parser.peek() {
+		err = parser.errorf("expected \"%s\"", text)
+		return
+	}
+	parser.next++
+	return
+}
+
+func (parser *schemaParser) errorf(format string, args ...interface{}) (err error) {
+	var (
+		found string
+	)
+
+	if parser.atEnd() {
+		found = "end of file"
+	} else {
+		found = "\"" + parser.peek() + "\""
+	}
+
+	err = fmt.Errorf("line %d: %s but found %s", parser.line(), fmt.Sprintf(format, args...), found)
+
+	return
+}
+
+func (parser *schemaParser) identifier() (identifier string, err error) {
+	identifier = parser.peek()
+	if ("" == identifier) || !isSchemaIdentifierByte(identifier[0], true) || isSchemaKeyword(identifier) {
+		err = parser.errorf("expected an identifier")
+		return
+	}
+	parser.next++
+	return
+}
+
+var schemaKeywords = map[string]bool{
+	"bool": true, "case": true, "const": true, "default": true, "double": true, "enum": true, "float": true,
+	"hyper": true, "int": true, "opaque": true, "quadruple": true, "string": true, "struct": true, "switch": true,
+	"typedef": true, "union": true, "unsigned": true, "void": true,
+}
+
+func isSchemaKeyword(text string) (isKeyword bool) {
+	isKeyword = schemaKeywords[text]
+	return
+}
+
+// value parses a constant (decimal, hexadecimal, or octal) or the name of a previously defined constant or enumerator.
+func (parser *schemaParser) value() (value int64, err error) {
+	var (
+		ok   bool
+		text string
+	)
+
+	text = parser.peek()
+
+	if ("" != text) && (isSchemaDigit(text[0]) || ('-' == text[0])) {
+		value, err = strconv.ParseInt(text, 0, 64)
+		if nil != err {
+			err = parser.errorf("expected a constant")
+			return
+		}
+		parser.next++
+		return
+	}
+
+	value, ok = parser.constants[text]
+	if !ok {
+		err = parser.errorf("expected a constant or the name of a previously defined constant")
+		return
+	}
+	parser.next++
+
+	return
+}
+
+// size parses a value that must be a valid length (or maximum length).
+func (parser *schemaParser) size() (size uint32, err error) {
+	var (
+		value int64
+	)
+
+	value, err = parser.value()
+	if nil != err {
+		return
+	}
+	if (0 > value) || (math.MaxUint32 < value) {
+		parser.next--
+		err = parser.errorf("expected a length between 0 and 0xFFFFFFFF")
+		return
+	}
+
+	size = uint32(value)
+
+	return
+}
+
+func (parser *schemaParser) defineConstant(name string, value int64) (err error) {
+	var (
+		alreadyDefined bool
+	)
+
+	_, alreadyDefined = parser.constants[name]
+	if alreadyDefined {
+		err = fmt.Errorf("line %d: constant %s is already defined", parser.line(), name)
+		return
+	}
+
+	parser.constants[name] = value
+
+	return
+}
+
+// namedType returns the (perhaps not yet defined) Schema of the named type typeName.
+func (parser *schemaParser) namedType(typeName string) (schema *Schema) {
+	var (
+		ok bool
+	)
+
+	schema, ok = parser.types[typeName]
+	if !ok {
+		schema = &Schema{Name: typeName}
+		parser.types[typeName] = schema
+		parser.firstUseLine[typeName] = parser.line()
+	}
+
+	return
+}
+
+// defineType fills in the Schema of the named type typeName from schema.
+func (parser *schemaParser) defineType(typeName string, schema *Schema) (err error) {
+	var (
+		namedSchema *Schema
+	)
+
+	if parser.defined[typeName] {
+		err = fmt.Errorf("line %d: type %s is already defined", parser.line(), typeName)
+		return
+	}
+
+	namedSchema = parser.namedType(typeName)
+	parser.defined[typeName] = true
+
+	if ("" != schema.Name) && (schema == parser.types[schema.Name]) {
+		// Note: schema may not yet be defined, so resolve it once parsing is complete
+		parser.aliases[typeName] = schema.Name
+		return
+	}
+
+	*namedSchema = *schema
+	namedSchema.Name = typeName
+
+	return
+}
+
+func (parser *schemaParser) resolveAlias(aliasName string, inProgress map[string]bool) (err error) {
+	var (
+		ok         bool
+		targetName string
+	)
+
+	targetName, ok = parser.aliases[aliasName]
+	if !ok {
+		return
+	}
+
+	if inProgress[aliasName] {
+		err = fmt.Errorf("type %s is defined in terms of itself", aliasName)
+		return
+	}
+	inProgress[aliasName] = true
+
+	err = parser.resolveAlias(targetName, inProgress)
+	if nil != err {
+		return
+	}
+
+	*parser.types[aliasName] = *parser.types[targetName]
+	parser.types[aliasName].Name = aliasName
+
+	delete(parser.aliases, aliasName)
+
+	return
+}
+
+// parseDefinition parses a type-def or constant-def.
+func (parser *schemaParser) parseDefinition() (err error) {
+	var (
+		field    SchemaField
+		keyword  string
+		schema   *Schema
+		typeName string
+		value    int64
+	)
+
+	keyword = parser.consume()
+
+	switch keyword {
+	case "const":
+		typeName, err = parser.identifier()
+		if nil != err {
+			return
+		}
+		err = parser.expect("=")
+		if nil != err {
+			return
+		}
+		value, err = parser.value()
+		if nil != err {
+			return
+		}
+		err = parser.defineConstant(typeName, value)
+		if nil != err {
+			return
+		}
+	case "typedef":
+		field, err = parser.parseDeclaration(false)
+		if nil != err {
+			return
+		}
+		err = parser.defineType(field.Name, field.Type)
+		if nil != err {
+			return
+		}
+	case "enum", "struct", "union":
+		typeName, err = parser.identifier()
+		if nil != err {
+			return
+		}
+		_ = parser.namedType(typeName)
+		switch keyword {
+		case "enum":
+			schema, err = parser.parseEnumBody()
+		case "struct":
+			schema, err = parser.parseStructBody()
+		case "union":
+			schema, err = parser.parseUnionBody()
+		}
+		if nil != err {
+			return
+		}
+		err = parser.defineType(typeName, schema)
+		if nil != err {
+			return
+		}
+	default:
+		parser.next--
+		err = parser.errorf("expected \"const\", \"typedef\", \"enum\", \"struct\", or \"union\"")
+		return
+	}
+
+	err = parser.expect(";")
+
+	return
+}
+
+// parseTypeSpecifier parses a type-specifier.
+func (parser *schemaParser) parseTypeSpecifier() (schema *Schema, err error) {
+	var (
+		typeName string
+	)
+
+	switch parser.peek() {
+	case "unsigned":
+		parser.next++
+		switch parser.peek() {
+		case "int":
+			parser.next++
+			schema = &Schema{Kind: SchemaUint}
+		case "hyper":
+			parser.next++
+			schema = &Schema{Kind: SchemaUhyper}
+		default:
+			schema = &Schema{Kind: SchemaUint} // Note: rpcgen accepts "unsigned" alone as "unsigned int"
+		}
+	case "int":
+		parser.next++
+		schema = &Schema{Kind: SchemaInt}
+	case "hyper":
+		parser.next++
+		schema = &Schema{Kind: SchemaHyper}
+	case "float":
+		parser.next++
+		schema = &Schema{Kind: SchemaFloat}
+	case "double":
+		parser.next++
+		schema = &Schema{Kind: SchemaDouble}
+	case "bool":
+		parser.next++
+		schema = &Schema{Kind: SchemaBool}
+	case "quadruple":
+		err = parser.errorf("quadruple is not supported")
+	case "enum":
+		parser.next++
+		schema, err = parser.parseEnumBody()
+	case "struct":
+		parser.next++
+		schema, err = parser.parseStructBody()
+	case "union":
+		parser.next++
+		schema, err = parser.parseUnionBody()
+	default:
+		typeName, err = parser.identifier()
+		if nil != err {
+			return
+		}
+		schema = parser.namedType(typeName)
+	}
+
+	return
+}
+
+// parseDeclaration parses a declaration (where void is only permitted if allowVoid is set).
+func (parser *schemaParser) parseDeclaration(allowVoid bool) (field SchemaField, err error) {
+	var (
+		isFixedSize bool
+		schema      *Schema
+		size        uint32
+	)
+
+	switch parser.peek() {
+	case "void":
+		if !allowVoid {
+			err = parser.errorf("void may only be declared as an arm of a union")
+			return
+		}
+		parser.next++
+		field.Type = &Schema{Kind: SchemaVoid}
+		return
+	case "opaque", "string":
+		if "opaque" == parser.consume() {
+			schema = &Schema{Kind: SchemaOpaque}
+		} else {
+			schema = &Schema{Kind: SchemaString}
+		}
+		field.Name, err = parser.identifier()
+		if nil != err {
+			return
+		}
+		isFixedSize, size, err = parser.parseSize(SchemaString != schema.Kind)
+		if nil != err {
+			return
+		}
+		if isFixedSize {
+			schema.Kind = SchemaFixedOpaque
+		}
+		schema.Size = size
+		field.Type = schema
+		return
+	}
+
+	schema, err = parser.parseTypeSpecifier()
+	if nil != err {
+		return
+	}
+
+	if "*" == parser.peek() {
+		parser.next++
+		field.Name, err = parser.identifier()
+		if nil != err {
+			return
+		}
+		field.Type = &Schema{Kind: SchemaOptional, Elem: schema}
+		return
+	}
+
+	field.Name, err = parser.identifier()
+	if nil != err {
+		return
+	}
+
+	switch parser.peek() {
+	case "[", "<":
+		isFixedSize, size, err = parser.parseSize(true)
+		if nil != err {
+			return
+		}
+		if isFixedSize {
+			field.Type = &Schema{Kind: SchemaFixedArray, Size: size, Elem: schema}
+		} else {
+			field.Type = &Schema{Kind: SchemaArray, Size: size, Elem: schema}
+		}
+	default:
+		field.Type = schema
+	}
+
+	return
+}
+
+// parseSize parses "[" value "]" (only if allowFixedSize is set) or "<" [ value ] ">".
+func (parser *schemaParser) parseSize(allowFixedSize bool) (isFixedSize bool, size uint32, err error) {
+	switch parser.peek() {
+	case "[":
+		if !allowFixedSize {
+			err = parser.errorf("expected \"<\"")
+			return
+		}
+		parser.next++
+		isFixedSize = true
+		size, err = parser.size()
+		if nil != err {
+			return
+		}
+		err = parser.expect("]")
+	case "<":
+		parser.next++
+		isFixedSize = false
+		if ">" != parser.peek() {
+			size, err = parser.size()
+			if nil != err {
+				return
+			}
+		}
+		err = parser.expect(">")
+	default:
+		if allowFixedSize {
+			err = parser.errorf("expected \"[\" or \"<\"")
+		} else {
+			err = parser.errorf("expected \"<\"")
+		}
+	}
+
+	return
+}
+
+// parseEnumBody parses an enum-body.
+func (parser *schemaParser) parseEnumBody() (schema *Schema, err error) {
+	var (
+		name  string
+		value int64
+	)
+
+	err = parser.expect("{")
+	if nil != err {
+		return
+	}
+
+	schema = &Schema{Kind: SchemaEnum, Enumerators: make(map[int32]string)}
+
+	for {
+		name, err = parser.identifier()
+		if nil != err {
+			return
+		}
+		err = parser.expect("=")
+		if nil != err {
+			return
+		}
+		value, err = parser.value()
+		if nil != err {
+			return
+		}
+		if (math.MinInt32 > value) || (math.MaxInt32 < value) {
+			parser.next--
+			err = parser.errorf("expected an enumerator value that fits in 32 bits")
+			return
+		}
+		err = parser.defineConstant(name, value)
+		if nil != err {
+			return
+		}
+		schema.Enumerators[int32(value)] = name
+		if "," != parser.peek() {
+			break
+		}
+		parser.next++
+	}
+
+	err = parser.expect("}")
+
+	return
+}
+
+// parseStructBody parses a struct-body.
+func (parser *schemaParser) parseStructBody() (schema *Schema, err error) {
+	var (
+		field SchemaField
+	)
+
+	err = parser.expect("{")
+	if nil != err {
+		return
+	}
+
+	schema = &Schema{Kind: SchemaStruct}
+
+	for {
+		field, err = parser.parseDeclaration(false)
+		if nil != err {
+			return
+		}
+		schema.Fields = append(schema.Fields, field)
+		err = parser.expect(";")
+		if nil != err {
+			return
+		}
+		if "}" == parser.peek() {
+			break
+		}
+	}
+
+	err = parser.expect("}")
+
+	return
+}
+
+// parseUnionBody parses a union-body.
+func (parser *schemaParser) parseUnionBody() (schema *Schema, err error) {
+	var (
+		arm         SchemaArm
+		casesSeen   map[int64]bool
+		caseValue   int64
+		defaultSeen bool
+	)
+
+	err = parser.expect("switch")
+	if nil != err {
+		return
+	}
+	err = parser.expect("(")
+	if nil != err {
+		return
+	}
+
+	schema = &Schema{Kind: SchemaUnion}
+
+	schema.Discriminant, err = parser.parseDeclaration(false)
+	if nil != err {
+		return
+	}
+	err = parser.expect(")")
+	if nil != err {
+		return
+	}
+	err = parser.expect("{")
+	if nil != err {
+		return
+	}
+
+	casesSeen = make(map[int64]bool)
+
+	for ("case" == parser.peek()) || ("default" == parser.peek()) {
+		arm = SchemaArm{}
+		if "default" == parser.consume() {
+			if defaultSeen {
+				parser.next--
+				err = parser.errorf("expected only one default arm")
+				return
+			}
+			defaultSeen = true
+			arm.IsDefault = true
+			err = parser.expect(":")
+			if nil != err {
+				return
+			}
+		} else {
+			for {
+				caseValue, err = parser.value()
+				if nil != err {
+					return
+				}
+				if casesSeen[caseValue] {
+					parser.next--
+					err = parser.errorf("expected a case value not already selecting another arm")
+					return
+				}
+				casesSeen[caseValue] = true
+				arm.Cases = append(arm.Cases, caseValue)
+				err = parser.expect(":")
+				if nil != err {
+					return
+				}
+				if "case" != parser.peek() {
+					break
+				}
+				parser.next++
+			}
+		}
+		arm.Field, err = parser.parseDeclaration(true)
+		if nil != err {
+			return
+		}
+		schema.Arms = append(schema.Arms, arm)
+		err = parser.expect(";")
+		if nil != err {
+			return
+		}
+	}
+
+	if 0 == len(schema.Arms) {
+		err = parser.errorf("expected \"case\" or \"default\"")
+		return
+	}
+
+	err = parser.expect("}")
+
+	return
+}
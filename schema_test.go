@@ -0,0 +1,197 @@
+package xdr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSchemaText = `
+/*
+ * A specification exercising most of RFC 4506 section 6
+ */
+%#include <stdint.h>
+
+const MAX_NAME = 0x10;
+const COUNT = 3;
+
+typedef opaque    handle[8];
+typedef string    name<MAX_NAME>;
+typedef name      alias;            // typedef of a named type (resolved once defined)
+typedef entry     *entryList;       // forward reference
+
+enum kind {
+	KIND_FILE = 1,
+	KIND_DIR  = 2,
+	KIND_LINK = 3
+};
+
+struct entry {
+	alias     entryName;
+	unsigned  id;
+	hyper     size;
+	entryList next;
+};
+
+union result switch (kind k) {
+case KIND_FILE:
+case KIND_LINK:
+	handle fileHandle;
+case KIND_DIR:
+	entry  entries<>;
+default:
+	void;
+};
+
+struct everything {
+	int               i;
+	unsigned hyper    uh;
+	bool              b;
+	float             f;
+	double            d;
+	opaque            data<>;
+	int               fixed[COUNT];
+	struct { int x; } inner;
+	result            r;
+};
+`
+
+func TestParseSchema(t *testing.T) {
+	var (
+		entrySchema      *Schema
+		err              error
+		everythingSchema *Schema
+		kindSchema       *Schema
+		resultSchema     *Schema
+		schemas          map[string]*Schema
+	)
+
+	schemas, err = ParseSchema(testSchemaText)
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+
+	kindSchema = schemas["kind"]
+	if (nil == kindSchema) || (SchemaEnum != kindSchema.Kind) || ("KIND_LINK" != kindSchema.Enumerators[3]) {
+		t.Fatalf("ParseSchema() returned unexpected kind: %+v", kindSchema)
+	}
+
+	if ("handle" != schemas["handle"].TypeName()) || (SchemaFixedOpaque != schemas["handle"].Kind) || (8 != schemas["handle"].Size) {
+		t.Fatalf("ParseSchema() returned unexpected handle: %+v", schemas["handle"])
+	}
+	if (SchemaString != schemas["alias"].Kind) || (16 != schemas["alias"].Size) {
+		t.Fatalf("ParseSchema() returned unexpected alias: %+v", schemas["alias"])
+	}
+
+	entrySchema = schemas["entry"]
+	if (nil == entrySchema) || (4 != len(entrySchema.Fields)) {
+		t.Fatalf("ParseSchema() returned unexpected entry: %+v", entrySchema)
+	}
+	if (SchemaUint != entrySchema.Fields[1].Type.Kind) || (SchemaHyper != entrySchema.Fields[2].Type.Kind) {
+		t.Fatalf("ParseSchema() returned unexpected entry fields: %+v", entrySchema.Fields)
+	}
+	if (SchemaOptional != entrySchema.Fields[3].Type.Kind) || (entrySchema != entrySchema.Fields[3].Type.Elem) {
+		t.Fatalf("ParseSchema() returned unexpected entry.next: %+v", entrySchema.Fields[3].Type)
+	}
+
+	resultSchema = schemas["result"]
+	if (nil == resultSchema) || (SchemaUnion != resultSchema.Kind) || (kindSchema != resultSchema.Discriminant.Type) || (3 != len(resultSchema.Arms)) {
+		t.Fatalf("ParseSchema() returned unexpected result: %+v", resultSchema)
+	}
+	if !reflect.DeepEqual([]int64{1, 3}, resultSchema.Arms[0].Cases) || !resultSchema.Arms[2].IsDefault || (SchemaVoid != resultSchema.Arms[2].Field.Type.Kind) {
+		t.Fatalf("ParseSchema() returned unexpected result arms: %+v", resultSchema.Arms)
+	}
+	if "entry<>" != resultSchema.Arms[1].Field.Type.TypeName() {
+		t.Fatalf("ParseSchema() returned unexpected result.entries: %s", resultSchema.Arms[1].Field.Type.TypeName())
+	}
+
+	everythingSchema = schemas["everything"]
+	if (nil == everythingSchema) || (9 != len(everythingSchema.Fields)) {
+		t.Fatalf("ParseSchema() returned unexpected everything: %+v", everythingSchema)
+	}
+	if "int[3]" != everythingSchema.Fields[6].Type.TypeName() {
+		t.Fatalf("ParseSchema() returned unexpected everything.fixed: %s", everythingSchema.Fields[6].Type.TypeName())
+	}
+	if (SchemaStruct != everythingSchema.Fields[7].Type.Kind) || ("x" != everythingSchema.Fields[7].Type.Fields[0].Name) {
+		t.Fatalf("ParseSchema() returned unexpected everything.inner: %+v", everythingSchema.Fields[7].Type)
+	}
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	var (
+		err        error
+		errorsText = map[string]string{
+			"struct s { int i; ":                                         "line 1: expected",
+			"struct s { int i; };\nstruct s { int j; };":                 "line 2: type s is already defined",
+			"struct s {\n missing m;\n};":                                "line 2: type missing is used but never defined",
+			"typedef a b;\ntypedef b a;":                                 "in terms of itself",
+			"union u switch (int d) {\ncase 1: void;\ncase 1: void;\n};": "line 3: ",
+			"struct s { quadruple q; };":                                 "quadruple",
+			"const C = 1;\nconst C = 2;":                                 "line 2: ",
+			"struct s { opaque o[UNDEFINED]; };":                         "UNDEFINED",
+		}
+		expectedSubstring string
+		text              string
+	)
+
+	for text, expectedSubstring = range errorsText {
+		_, err = ParseSchema(text)
+		if (nil == err) || !strings.Contains(err.Error(), expectedSubstring) {
+			t.Fatalf("ParseSchema(%q) returned unexpected error (expected to contain %q): %v", text, expectedSubstring, err)
+		}
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	var (
+		err    error
+		schema *Schema
+	)
+
+	schema, err = schemaOfType(goodParentStructPtr)
+	if nil != err {
+		t.Fatalf("schemaOfType(goodParentStructPtr) received unexpected error: %v", err)
+	}
+	if ("ParentStruct" != schema.TypeName()) || (SchemaStruct != schema.Kind) {
+		t.Fatalf("schemaOfType(goodParentStructPtr) returned unexpected schema: %+v", schema)
+	}
+
+	schema, err = schemaOfType(reflect.TypeOf(CompactTaggedStruct{}))
+	if nil != err {
+		t.Fatalf("schemaOfType(CompactTaggedStruct) received unexpected error: %v", err)
+	}
+	if 11 != len(schema.Fields) {
+		t.Fatalf("schemaOfType(CompactTaggedStruct) returned %d fields (expected 11)", len(schema.Fields))
+	}
+	if ("opaque[3]" != schema.Fields[5].Type.TypeName()) || ("opaque<6>" != schema.Fields[6].Type.TypeName()) || ("string<255>" != schema.Fields[7].Type.TypeName()) {
+		t.Fatalf("schemaOfType(CompactTaggedStruct) returned unexpected opaque/string fields: %+v", schema.Fields[5:8])
+	}
+	if ("int[2]" != schema.Fields[8].Type.TypeName()) || ("unsigned int<4>" != schema.Fields[9].Type.TypeName()) {
+		t.Fatalf("schemaOfType(CompactTaggedStruct) returned unexpected array fields: %s & %s", schema.Fields[8].Type.TypeName(), schema.Fields[9].Type.TypeName())
+	}
+
+	schema, err = schemaOfType(UnionStruct{})
+	if nil != err {
+		t.Fatalf("schemaOfType(UnionStruct{}) received unexpected error: %v", err)
+	}
+	if (SchemaUnion != schema.Kind) || ("Discriminant" != schema.Discriminant.Name) || (4 != len(schema.Arms)) || (SchemaVoid != schema.Arms[2].Field.Type.Kind) || !schema.Arms[3].IsDefault {
+		t.Fatalf("schemaOfType(UnionStruct{}) returned unexpected schema: %+v", schema)
+	}
+
+	schema, err = schemaOfType(OptionalStruct{})
+	if nil != err {
+		t.Fatalf("schemaOfType(OptionalStruct{}) received unexpected error: %v", err)
+	}
+	if ("unsigned int*" != schema.Fields[0].Type.TypeName()) || ("string<8>*" != schema.Fields[1].Type.TypeName()) {
+		t.Fatalf("schemaOfType(OptionalStruct{}) returned unexpected fields: %s & %s", schema.Fields[0].Type.TypeName(), schema.Fields[1].Type.TypeName())
+	}
+
+	_, err = schemaOfType(map[int]int{})
+	if nil == err {
+		t.Fatalf("schemaOfType(map[int]int{}) should have failed")
+	}
+	_, err = schemaOfType(nil)
+	if nil == err {
+		t.Fatalf("schemaOfType(nil) should have failed")
+	}
+}
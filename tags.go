@@ -0,0 +1,825 @@
+package xdr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// xdrTag captures the tags of a struct field (or their equivalent for values not held in a struct field).
+type xdrTag struct {
+	name      string  // XDR_Name of the struct field (or "" if not a struct field)
+	maxSize   uint64  // XDR_MaxSize of the struct field (or 0 if unspecified)
+	skip      bool    // Set if the struct field is tagged XDR_Name:"-" (and is neither packed nor unpacked)
+	optional  bool    // Set if the (pointer) struct field is Optional-Data
+	cases     []int64 // Discriminant values selecting this struct field as the arm of a Discriminated Union
+	isDefault bool    // Set if this struct field is the default arm of a Discriminated Union
+	isUnknown bool    // Set if this ([]byte) struct field captures, as raw bytes, any arm of a Discriminated Union not otherwise known
+}
+
+// xdrField describes a struct field to be packed and unpacked.
+type xdrField struct {
+	index  []int        // Index sequence of the struct field (i.e. as passed to reflect.Value.FieldByIndex())
+	name   string       // Name of the struct field (for error messages)
+	typeOf reflect.Type // Type of the struct field (sparing Validate() the allocations of reflect.Type.FieldByIndex())
+	tag    xdrTag
+}
+
+// xdrStructLayout describes the packing and unpacking of a struct type.
+type xdrStructLayout struct {
+	fields    []xdrField // In order, omitting unexported struct fields & those tagged XDR_Name:"-" and flattening embedded structs
+	isUnion   bool       // If set, fields[0] is the discriminant and fields[1:] are the arms of a Discriminated Union
+	isWrapped bool       // If set (i.e. an arm is tagged as unknown), each arm of the Discriminated Union is encoded within Variable-Length Opaque Data
+}
+
+type xdrStructLayoutCacheEntry struct {
+	structLayout *xdrStructLayout
+	err          error
+}
+
+var xdrStructLayoutCache sync.Map // map[reflect.Type]*xdrStructLayoutCacheEntry
+
+// InferTags, embedded in a struct, opts each of its untagged struct fields (including those of structs embedded and
+// flattened alongside it) into having its XDR_Name inferred from its Go type, just as if tagged `xdr:""`. It is
+// neither packed nor unpacked.
+type InferTags struct{}
+
+var inferTagsTypeOf = reflect.TypeOf(InferTags{})
+
+// compactXDRNames maps each name accepted in an `xdr:"..."` tag to its XDR_Name equivalent (where
+// "opaque" and "array" resolve to their Fixed-Length or Variable-Length forms by the Go type tagged).
+var compactXDRNames = map[string]string{
+	"int":    "Integer",
+	"uint":   "Unsigned Integer",
+	"enum":   "Enumeration",
+	"bool":   "Boolean",
+	"hyper":  "Hyper Integer",
+	"uhyper": "Unsigned Hyper Integer",
+	"opaque": "Variable-Length Opaque Data",
+	"string": "String",
+	"array":  "Variable-Length Array",
+	"struct": "Structure",
+	"union":  "Discriminated Union",
+}
+
+// inferXDRName returns the XDR_Name naturally mapped to a struct field of type typeOf whose `xdr:"..."` tag omits a
+// name (or that is tagged XDR_Name:"Optional-Data", or is untagged in a struct embedding InferTags).
+//
+// Should no such mapping exist, "" is returned (leaving examineRecursive() to report the field as unsupported).
+func inferXDRName(typeOf reflect.Type) (xdrName string) {
+	switch typeOf.Kind() {
+	case reflect.Ptr:
+		xdrName = inferXDRName(typeOf.Elem())
+	case reflect.Bool:
+		xdrName = "Boolean"
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		xdrName = "Integer"
+	case reflect.Int, reflect.Int64:
+		xdrName = "Hyper Integer"
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		xdrName = "Unsigned Integer"
+	case reflect.Uint, reflect.Uint64:
+		xdrName = "Unsigned Hyper Integer"
+	case reflect.Array:
+		if reflect.Uint8 == typeOf.Elem().Kind() {
+			xdrName = "Fixed-Length Opaque Data"
+		} else {
+			xdrName = "Fixed-Length Array"
+		}
+	case reflect.Slice:
+		if reflect.Uint8 == typeOf.Elem().Kind() {
+			xdrName = "Variable-Length Opaque Data"
+		} else {
+			xdrName = "Variable-Length Array"
+		}
+	case reflect.String:
+		xdrName = "String"
+	case reflect.Struct:
+		if hasUnionArmTags(typeOf) {
+			xdrName = "Discriminated Union"
+		} else {
+			xdrName = "Structure"
+		}
+	default:
+		xdrName = ""
+	}
+	return
+}
+
+// hasUnionArmTags reports whether any field of structTypeOf is tagged as the arm of a Discriminated Union.
+func hasUnionArmTags(structTypeOf reflect.Type) (isUnion bool) {
+	var (
+		compactItem  string
+		compactTag   string
+		i            int
+		isCompactTag bool
+	)
+
+	for i = 0; i < structTypeOf.NumField(); i++ {
+		if "" != structTypeOf.Field(i).Tag.Get("XDR_Case") {
+			isUnion = true
+			return
+		}
+		compactTag, isCompactTag = structTypeOf.Field(i).Tag.Lookup("xdr")
+		if isCompactTag {
+			for _, compactItem = range strings.Split(compactTag, ",") {
+				if ("default" == compactItem) || ("unknown" == compactItem) || strings.HasPrefix(compactItem, "case=") {
+					isUnion = true
+					return
+				}
+			}
+		}
+		if isFlattenedXDRField(structTypeOf.Field(i)) && hasUnionArmTags(structTypeOf.Field(i).Type) {
+			isUnion = true
+			return
+		}
+	}
+
+	isUnion = false
+	return
+}
+
+// isFlattenedXDRField reports whether structField is an untagged embedded struct whose fields are packed inline.
+//
+// As with encoding/json, this applies even if the embedded struct's type is unexported (as its fields may not be).
+func isFlattenedXDRField(structField reflect.StructField) (isFlattened bool) {
+	var (
+		isCompactTag bool
+	)
+
+	if !structField.Anonymous || (reflect.Struct != structField.Type.Kind()) {
+		isFlattened = false
+		return
+	}
+
+	_, isCompactTag = structField.Tag.Lookup("xdr")
+	if isCompactTag || ("" != structField.Tag.Get("XDR_Name")) || ("" != structField.Tag.Get("XDR_MaxSize")) || ("" != structField.Tag.Get("XDR_Case")) {
+		isFlattened = false
+		return
+	}
+
+	isFlattened = true
+	return
+}
+
+// embedsInferTags reports whether structTypeOf (or a struct embedded and flattened within it) embeds InferTags.
+func embedsInferTags(structTypeOf reflect.Type) (inferUntagged bool) {
+	var (
+		i           int
+		structField reflect.StructField
+	)
+
+	for i = 0; i < structTypeOf.NumField(); i++ {
+		structField = structTypeOf.Field(i)
+		if isFlattenedXDRField(structField) && ((inferTagsTypeOf == structField.Type) || embedsInferTags(structField.Type)) {
+			inferUntagged = true
+			return
+		}
+	}
+
+	inferUntagged = false
+	return
+}
+
+// xdrIntegerSize returns the number of bytes (4 or 8) encoding an integer of the given Kind() as directed by tag.
+//
+// Only reflect.Int & reflect.Uint consult tag, defaulting (when untagged) to a Hyper Integer so no value is truncated.
+func xdrIntegerSize(kind reflect.Kind, tag xdrTag) (size uint64) {
+	switch kind {
+	case reflect.Int64, reflect.Uint64:
+		size = 8
+	case reflect.Int, reflect.Uint:
+		if ("" == tag.name) || ("Hyper Integer" == tag.name) || ("Unsigned Hyper Integer" == tag.name) {
+			size = 8
+		} else {
+			size = 4
+		}
+	default:
+		size = 4
+	}
+	return
+}
+
+// reflectKindName returns the name of kind as it would appear in Go source (e.g. "reflect.Uint16").
+func reflectKindName(kind reflect.Kind) (name string) {
+	name = kind.String()
+	name = "reflect." + strings.ToUpper(name[:1]) + name[1:]
+	return
+}
+
+// parseXDRTag parses either the `xdr:"..."` tag or the XDR_Name, XDR_MaxSize, & XDR_Case tags of structField. If
+// inferUntagged is set (i.e. the struct embeds InferTags), a missing XDR_Name is inferred from the Go type.
+func parseXDRTag(structField reflect.StructField, inferUntagged bool) (tag xdrTag, err error) {
+	var (
+		compactTag         string
+		isCompactTag       bool
+		xdrCaseAsString    string
+		xdrMaxSizeAsString string
+	)
+
+	compactTag, isCompactTag = structField.Tag.Lookup("xdr")
+	if isCompactTag {
+		if ("" != structField.Tag.Get("XDR_Name")) || ("" != structField.Tag.Get("XDR_MaxSize")) || ("" != structField.Tag.Get("XDR_Case")) {
+			err = fmt.Errorf("struct field %s may not combine an xdr tag with XDR_Name, XDR_MaxSize, or XDR_Case tags", structField.Name)
+			return
+		}
+		tag, err = parseCompactXDRTag(structField, compactTag)
+		return
+	}
+
+	tag.name = structField.Tag.Get("XDR_Name")
+
+	switch tag.name {
+	case "":
+		if inferUntagged {
+			tag.name = inferXDRName(structField.Type)
+		}
+	case "-":
+		tag.skip = true
+		return
+	case "Optional-Data":
+		tag.optional = true
+		if reflect.Ptr == structField.Type.Kind() {
+			tag.name = inferXDRName(structField.Type.Elem())
+		} else {
+			tag.name = inferXDRName(structField.Type)
+		}
+	}
+
+	xdrMaxSizeAsString = structField.Tag.Get("XDR_MaxSize")
+	if "" == xdrMaxSizeAsString {
+		tag.maxSize = 0
+	} else {
+		tag.maxSize, err = parseXDRMaxSize(xdrMaxSizeAsString)
+		if nil != err {
+			return
+		}
+	}
+
+	xdrCaseAsString = structField.Tag.Get("XDR_Case")
+	if "default" == xdrCaseAsString {
+		tag.isDefault = true
+	} else if "unknown" == xdrCaseAsString {
+		tag.isUnknown = true
+	} else if "" != xdrCaseAsString {
+		tag.cases, err = parseXDRCases(xdrCaseAsString)
+		if nil != err {
+			return
+		}
+	}
+
+	return
+}
+
+// parseCompactXDRTag parses the `xdr:"..."` tag of structField according to the following grammar:
+//
+//	tag   = "-" | [ item { "," item } ] .
+//	item  = name | "optional" | "max=" size | "case=" value { "|" value } | "default" | "unknown" .
+//	name  = "int" | "uint" | "enum" | "bool" | "hyper" | "uhyper" |
+//	        "opaque" | "string" | "array" | "struct" | "union" .
+//	size  = decimal_digits .
+//	value = [ "-" ] decimal_digits .
+//
+// A name may appear at most once; if omitted, it is inferred from the Go type of structField.
+func parseCompactXDRTag(structField reflect.StructField, compactTag string) (tag xdrTag, err error) {
+	var (
+		compactItem  string
+		compactItems []string
+		compactName  string
+		ok           bool
+		seenCases    bool
+		seenMaxSize  bool
+		valueTypeOf  reflect.Type
+		xdrName      string
+	)
+
+	if "-" == compactTag {
+		tag.skip = true
+		return
+	}
+
+	if "" != compactTag {
+		compactItems = strings.Split(compactTag, ",")
+	}
+
+	for _, compactItem = range compactItems {
+		switch {
+		case "optional" == compactItem:
+			if tag.optional {
+				err = fmt.Errorf("struct field %s xdr tag repeats \"optional\"", structField.Name)
+				return
+			}
+			tag.optional = true
+		case "default" == compactItem:
+			if tag.isDefault || tag.isUnknown || seenCases {
+				err = fmt.Errorf("struct field %s xdr tag may specify only one of \"case=\", \"default\", or \"unknown\"", structField.Name)
+				return
+			}
+			tag.isDefault = true
+		case "unknown" == compactItem:
+			if tag.isDefault || tag.isUnknown || seenCases {
+				err = fmt.Errorf("struct field %s xdr tag may specify only one of \"case=\", \"default\", or \"unknown\"", structField.Name)
+				return
+			}
+			tag.isUnknown = true
+		case strings.HasPrefix(compactItem, "max="):
+			if seenMaxSize {
+				err = fmt.Errorf("struct field %s xdr tag repeats \"max=\"", structField.Name)
+				return
+			}
+			seenMaxSize = true
+			tag.maxSize, err = parseXDRMaxSize(strings.TrimPrefix(compactItem, "max="))
+			if nil != err {
+				return
+			}
+		case strings.HasPrefix(compactItem, "case="):
+			if tag.isDefault || tag.isUnknown || seenCases {
+				err = fmt.Errorf("struct field %s xdr tag may specify only one of \"case=\", \"default\", or \"unknown\"", structField.Name)
+				return
+			}
+			seenCases = true
+			tag.cases, err = parseXDRCases(strings.TrimPrefix(compactItem, "case="))
+			if nil != err {
+				return
+			}
+		default:
+			xdrName, ok = compactXDRNames[compactItem]
+			if !ok {
+				err = fmt.Errorf("struct field %s xdr tag contains unrecognized item \"%s\"", structField.Name, compactItem)
+				return
+			}
+			if "" != compactName {
+				err = fmt.Errorf("struct field %s xdr tag specifies both \"%s\" and \"%s\"", structField.Name, compactName, compactItem)
+				return
+			}
+			compactName = compactItem
+			tag.name = xdrName
+		}
+	}
+
+	valueTypeOf = structField.Type
+	if tag.optional && (reflect.Ptr == valueTypeOf.Kind()) {
+		valueTypeOf = valueTypeOf.Elem()
+	}
+
+	switch compactName {
+	case "":
+		tag.name = inferXDRName(valueTypeOf)
+	case "opaque":
+		if reflect.Array == valueTypeOf.Kind() {
+			tag.name = "Fixed-Length Opaque Data"
+		}
+	case "array":
+		if reflect.Array == valueTypeOf.Kind() {
+			tag.name = "Fixed-Length Array"
+		}
+	}
+
+	return
+}
+
+func parseXDRMaxSize(xdrMaxSizeAsString string) (maxSize uint64, err error) {
+	maxSize, err = strconv.ParseUint(xdrMaxSizeAsString, 10, 64)
+	if nil != err {
+		return
+	}
+	if 0xFFFFFFFF < maxSize {
+		err = fmt.Errorf("XDR_MaxSize (%v) exceeds maximum allowed (0xFFFFFFFF)", maxSize)
+		return
+	}
+	return
+}
+
+func parseXDRCases(casesAsString string) (cases []int64, err error) {
+	var (
+		caseAsInt64  int64
+		caseAsString string
+	)
+
+	for _, caseAsString = range strings.Split(casesAsString, "|") {
+		caseAsInt64, err = strconv.ParseInt(caseAsString, 10, 64)
+		if nil != err {
+			return
+		}
+		if (math.MinInt32 > caseAsInt64) || (math.MaxUint32 < caseAsInt64) {
+			err = fmt.Errorf("union case (%v) does not fit in 32 bits", caseAsInt64)
+			return
+		}
+		cases = append(cases, caseAsInt64)
+	}
+
+	return
+}
+
+// checkXDRName returns a non-nil err if tag.name (and tag.optional) cannot apply to a struct field of type typeOf.
+func checkXDRName(typeOf reflect.Type, tag xdrTag) (err error) {
+	if tag.optional {
+		if reflect.Ptr != typeOf.Kind() {
+			err = fmt.Errorf("struct field tagged Optional-Data must have Kind() == reflect.Ptr (not %s)", reflectKindName(typeOf.Kind()))
+			return
+		}
+		typeOf = typeOf.Elem()
+	}
+
+	for reflect.Ptr == typeOf.Kind() {
+		typeOf = typeOf.Elem()
+	}
+
+	switch typeOf.Kind() {
+	case reflect.Bool:
+		if tag.name != "Boolean" {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Bool")
+		}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		if (tag.name != "Integer") && (tag.name != "Enumeration") {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == %s", reflectKindName(typeOf.Kind()))
+		}
+	case reflect.Int:
+		if (tag.name != "Integer") && (tag.name != "Hyper Integer") && (tag.name != "Enumeration") {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Int")
+		}
+	case reflect.Int64:
+		if tag.name != "Hyper Integer" {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Int64")
+		}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		if (tag.name != "Unsigned Integer") && (tag.name != "Enumeration") {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == %s", reflectKindName(typeOf.Kind()))
+		}
+	case reflect.Uint:
+		if (tag.name != "Unsigned Integer") && (tag.name != "Unsigned Hyper Integer") && (tag.name != "Enumeration") {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Uint")
+		}
+	case reflect.Uint64:
+		if tag.name != "Unsigned Hyper Integer" {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Uint64")
+		}
+	case reflect.Array:
+		if (tag.name != "Fixed-Length Opaque Data") && (tag.name != "Fixed-Length Array") {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Array")
+		} else if ("Fixed-Length Opaque Data" == tag.name) && (reflect.Uint8 != typeOf.Elem().Kind()) {
+			err = fmt.Errorf("struct field tagged Fixed-Length Opaque Data must have Elem().Kind() == reflect.Uint8")
+		}
+	case reflect.Slice:
+		if (tag.name != "Variable-Length Opaque Data") && (tag.name != "String") && (tag.name != "Variable-Length Array") {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Slice")
+		} else if ("Variable-Length Array" != tag.name) && (reflect.Uint8 != typeOf.Elem().Kind()) {
+			err = fmt.Errorf("struct field tagged %s must have Elem().Kind() == reflect.Uint8", tag.name)
+		}
+	case reflect.String:
+		if tag.name != "String" {
+			err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.String")
+		}
+	case reflect.Struct:
+		if hasUnionArmTags(typeOf) {
+			if tag.name != "Discriminated Union" {
+				err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Struct (with union arms)")
+			}
+		} else {
+			if tag.name != "Structure" {
+				err = fmt.Errorf("struct field missing valid XDR_Name tag for Kind() == reflect.Struct")
+			}
+		}
+	case reflect.Interface:
+		// Note: The XDR encoding of an Interface is determined by the concrete value it holds
+	default:
+		err = fmt.Errorf("struct field Kind() == %s unsupported", reflectKindName(typeOf.Kind()))
+	}
+
+	return
+}
+
+// xdrStructLayoutOf returns the (cached) xdrStructLayout of structTypeOf, validating its tags on first use.
+func xdrStructLayoutOf(structTypeOf reflect.Type) (structLayout *xdrStructLayout, err error) {
+	var (
+		cacheEntry *xdrStructLayoutCacheEntry
+		cachedIF   interface{}
+		ok         bool
+	)
+
+	cachedIF, ok = xdrStructLayoutCache.Load(structTypeOf)
+	if ok {
+		cacheEntry = cachedIF.(*xdrStructLayoutCacheEntry)
+		structLayout = cacheEntry.structLayout
+		err = cacheEntry.err
+		return
+	}
+
+	structLayout, err = buildXDRStructLayout(structTypeOf)
+
+	xdrStructLayoutCache.Store(structTypeOf, &xdrStructLayoutCacheEntry{structLayout: structLayout, err: err})
+
+	return
+}
+
+func buildXDRStructLayout(structTypeOf reflect.Type) (structLayout *xdrStructLayout, err error) {
+	var (
+		armIndex     int
+		caseValue    int64
+		casesSeen    map[int64]string
+		defaultSeen  bool
+		field        xdrField
+		ok           bool
+		priorArmName string
+		unknownSeen  bool
+	)
+
+	structLayout = &xdrStructLayout{
+		fields:  make([]xdrField, 0, structTypeOf.NumField()),
+		isUnion: hasUnionArmTags(structTypeOf),
+	}
+
+	err = appendXDRStructFields(structLayout, structTypeOf, structTypeOf, nil, embedsInferTags(structTypeOf))
+	if nil != err {
+		return
+	}
+
+	if structLayout.isUnion && (0 == len(structLayout.fields)) {
+		err = fmt.Errorf("%v: Discriminated Union lacks a discriminant", structTypeOf)
+		return
+	}
+
+	if !structLayout.isUnion {
+		return
+	}
+
+	// Validate Discriminated Union discriminant (the first field)...
+
+	field = structLayout.fields[0]
+
+	if field.tag.isDefault || field.tag.isUnknown || (0 < len(field.tag.cases)) {
+		err = fmt.Errorf("%v field %s: discriminant of a Discriminated Union may not be tagged as an arm", structTypeOf, field.name)
+		return
+	}
+	if field.tag.optional {
+		err = fmt.Errorf("%v field %s: discriminant of a Discriminated Union may not be Optional-Data", structTypeOf, field.name)
+		return
+	}
+
+	switch structTypeOf.FieldByIndex(field.index).Type.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		// These are all fine
+	case reflect.Int, reflect.Uint:
+		if 4 != xdrIntegerSize(structTypeOf.FieldByIndex(field.index).Type.Kind(), field.tag) {
+			err = fmt.Errorf("%v field %s: discriminant of a Discriminated Union must not be a Hyper Integer", structTypeOf, field.name)
+			return
+		}
+	default:
+		err = fmt.Errorf("%v field %s: discriminant of a Discriminated Union must be an Integer, Unsigned Integer, Enumeration, or Boolean", structTypeOf, field.name)
+		return
+	}
+
+	// ...and arms (the remaining fields)
+
+	casesSeen = make(map[int64]string)
+
+	for armIndex = 1; armIndex < len(structLayout.fields); armIndex++ {
+		field = structLayout.fields[armIndex]
+		if field.tag.isDefault {
+			if defaultSeen {
+				err = fmt.Errorf("%v field %s: Discriminated Union may have only one default arm", structTypeOf, field.name)
+				return
+			}
+			defaultSeen = true
+			continue
+		}
+		if field.tag.isUnknown {
+			if unknownSeen {
+				err = fmt.Errorf("%v field %s: Discriminated Union may have only one unknown arm", structTypeOf, field.name)
+				return
+			}
+			if (reflect.Slice != field.typeOf.Kind()) || (reflect.Uint8 != field.typeOf.Elem().Kind()) || ("Variable-Length Opaque Data" != field.tag.name) || field.tag.optional {
+				err = fmt.Errorf("%v field %s: unknown arm of a Discriminated Union must be Variable-Length Opaque Data held in a []byte", structTypeOf, field.name)
+				return
+			}
+			unknownSeen = true
+			continue
+		}
+		if 0 == len(field.tag.cases) {
+			err = fmt.Errorf("%v field %s: arm of a Discriminated Union must be tagged with its case value(s) or as the default or unknown arm", structTypeOf, field.name)
+			return
+		}
+		for _, caseValue = range field.tag.cases {
+			priorArmName, ok = casesSeen[caseValue]
+			if ok {
+				err = fmt.Errorf("%v field %s: case %v already selects arm %s", structTypeOf, field.name, caseValue, priorArmName)
+				return
+			}
+			casesSeen[caseValue] = field.name
+		}
+	}
+
+	if defaultSeen && unknownSeen {
+		err = fmt.Errorf("%v: Discriminated Union may not have both a default arm and an unknown arm", structTypeOf)
+		return
+	}
+
+	structLayout.isWrapped = unknownSeen
+
+	return
+}
+
+// appendXDRStructFields appends to structLayout the fields of structTypeOf (itself found at indexPrefix within
+// outerStructTypeOf), skipping unexported fields & those tagged XDR_Name:"-" and flattening embedded structs. If
+// inferUntagged is set, the XDR_Name of each untagged field is inferred from its Go type.
+func appendXDRStructFields(structLayout *xdrStructLayout, outerStructTypeOf reflect.Type, structTypeOf reflect.Type, indexPrefix []int, inferUntagged bool) (err error) {
+	var (
+		i            int
+		index        []int
+		isCompactTag bool
+		structField  reflect.StructField
+		tag          xdrTag
+	)
+
+	for i = 0; i < structTypeOf.NumField(); i++ {
+		structField = structTypeOf.Field(i)
+
+		index = make([]int, len(indexPrefix), len(indexPrefix)+1)
+		copy(index, indexPrefix)
+		index = append(index, i)
+
+		if isFlattenedXDRField(structField) {
+			err = appendXDRStructFields(structLayout, outerStructTypeOf, structField.Type, index, inferUntagged)
+			if nil != err {
+				return
+			}
+			continue
+		}
+
+		tag, err = parseXDRTag(structField, inferUntagged)
+		if nil != err {
+			err = fmt.Errorf("%v field %s: %v", outerStructTypeOf, structField.Name, err)
+			return
+		}
+		if tag.skip {
+			continue
+		}
+
+		if "" != structField.PkgPath {
+			// Unexported struct fields are ignored unless tagged (which is surely a mistake)
+
+			_, isCompactTag = structField.Tag.Lookup("xdr")
+			if isCompactTag || ("" != structField.Tag.Get("XDR_Name")) || ("" != structField.Tag.Get("XDR_MaxSize")) || ("" != structField.Tag.Get("XDR_Case")) {
+				err = fmt.Errorf("%v field %s: unexported struct field may not be tagged (other than as XDR_Name:\"-\")", outerStructTypeOf, structField.Name)
+				return
+			}
+			continue
+		}
+
+		err = checkXDRName(structField.Type, tag)
+		if nil != err {
+			if ("" == tag.name) && ("" != inferXDRName(structField.Type)) {
+				err = fmt.Errorf("%v field %s: %v (tag it `xdr:\"\"`, or embed xdr.InferTags in the struct, to infer XDR_Name from its Go type)", outerStructTypeOf, structField.Name, err)
+			} else {
+				err = fmt.Errorf("%v field %s: %v", outerStructTypeOf, structField.Name, err)
+			}
+			return
+		}
+		structLayout.fields = append(structLayout.fields, xdrField{index: index, name: structField.Name, typeOf: structField.Type, tag: tag})
+	}
+
+	err = nil
+	return
+}
+
+// discriminantOf returns the value of the discriminant of a Discriminated Union as an int64.
+func discriminantOf(discriminantValueOf reflect.Value) (discriminant int64) {
+	switch discriminantValueOf.Kind() {
+	case reflect.Bool:
+		if discriminantValueOf.Bool() {
+			discriminant = 1
+		} else {
+			discriminant = 0
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		discriminant = int64(discriminantValueOf.Uint())
+	default:
+		discriminant = discriminantValueOf.Int()
+	}
+	return
+}
+
+// selectUnionArm returns the arm of the Discriminated Union described by structLayout selected by discriminant
+// (falling back to the default or unknown arm, if any).
+func selectUnionArm(structLayout *xdrStructLayout, discriminant int64) (armField *xdrField, ok bool) {
+	var (
+		armIndex  int
+		caseValue int64
+	)
+
+	for armIndex = 1; armIndex < len(structLayout.fields); armIndex++ {
+		for _, caseValue = range structLayout.fields[armIndex].tag.cases {
+			if caseValue == discriminant {
+				armField = &structLayout.fields[armIndex]
+				ok = true
+				return
+			}
+		}
+	}
+
+	for armIndex = 1; armIndex < len(structLayout.fields); armIndex++ {
+		if structLayout.fields[armIndex].tag.isDefault || structLayout.fields[armIndex].tag.isUnknown {
+			armField = &structLayout.fields[armIndex]
+			ok = true
+			return
+		}
+	}
+
+	ok = false
+	return
+}
+
+// CheckTags validates the tags of the type of objIF and of every type reachable from it.
+//
+// Pack() and Unpack() only validate tags of the portions of a type present in the value being (un)packed
+// (e.g. not the element type of an empty slice), so CheckTags() is useful to find tag errors at init time.
+func CheckTags(objIF interface{}) (err error) {
+	var (
+		objTypeOf reflect.Type
+	)
+
+	objTypeOf = reflect.TypeOf(objIF)
+	if nil == objTypeOf {
+		err = fmt.Errorf("CheckTags() passed nil objIF")
+		return
+	}
+
+	err = checkTypeRecursive(objTypeOf, xdrTag{}, make(map[reflect.Type]bool))
+
+	return
+}
+
+// MustCheckTags is like CheckTags() but panics upon finding a tag error.
+func MustCheckTags(objIF interface{}) {
+	var (
+		err error
+	)
+
+	err = CheckTags(objIF)
+	if nil != err {
+		panic(err)
+	}
+}
+
+func checkTypeRecursive(objTypeOf reflect.Type, tag xdrTag, typesChecked map[reflect.Type]bool) (err error) {
+	var (
+		concreteTypeOf reflect.Type
+		field          xdrField
+		ok             bool
+		registration   *interfaceRegistration
+		structLayout   *xdrStructLayout
+	)
+
+	switch objTypeOf.Kind() {
+	case reflect.Ptr:
+		// Note: Like a struct type, a pointer type may reference itself (e.g. type P *P)
+		if typesChecked[objTypeOf] {
+			return
+		}
+		typesChecked[objTypeOf] = true
+		err = checkTypeRecursive(objTypeOf.Elem(), xdrTag{name: tag.name, maxSize: tag.maxSize}, typesChecked)
+	case reflect.Interface:
+		// Note: The XDR encoding of an Interface is determined by the concrete value it holds (of a registered type, if any)
+		registration, ok = lookupInterface(objTypeOf)
+		if ok {
+			for _, concreteTypeOf = range registration.concreteTypes {
+				err = checkTypeRecursive(concreteTypeOf, xdrTag{name: tag.name, maxSize: tag.maxSize}, typesChecked)
+				if nil != err {
+					return
+				}
+			}
+		}
+	case reflect.Bool, reflect.String:
+		// Nothing to check
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Nothing to check
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Nothing to check
+	case reflect.Array, reflect.Slice:
+		if reflect.Uint8 != objTypeOf.Elem().Kind() {
+			err = checkTypeRecursive(objTypeOf.Elem(), xdrTag{}, typesChecked)
+		}
+	case reflect.Struct:
+		if typesChecked[objTypeOf] {
+			return
+		}
+		typesChecked[objTypeOf] = true
+		structLayout, err = xdrStructLayoutOf(objTypeOf)
+		if nil != err {
+			return
+		}
+		for _, field = range structLayout.fields {
+			err = checkTypeRecursive(objTypeOf.FieldByIndex(field.index).Type, field.tag, typesChecked)
+			if nil != err {
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("%v has unsupported Kind() == %s", objTypeOf, reflectKindName(objTypeOf.Kind()))
+	}
+
+	return
+}
@@ -0,0 +1,507 @@
+package xdr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type CompactTaggedStruct struct {
+	Integer               int32    `xdr:"int"`
+	UnsignedInteger       uint32   `xdr:"uint"`
+	Boolean               bool     `xdr:"bool"`
+	HyperInteger          int64    `xdr:"hyper"`
+	UnsignedHyperInteger  uint64   `xdr:"uhyper"`
+	FixedLengthOpaqueData [3]byte  `xdr:"opaque"`
+	OpaqueData            []byte   `xdr:"opaque,max=6"`
+	StringAsString        string   `xdr:"string,max=255"`
+	FixedLengthArray      [2]int32 `xdr:"array"`
+	VariableLengthArray   []uint32 `xdr:"array,max=4"`
+	Inferred              uint32   `xdr:""`
+	Skipped               []int    `xdr:"-"`
+}
+
+type LongFormTaggedStruct struct {
+	Integer               int32    `XDR_Name:"Integer"`
+	UnsignedInteger       uint32   `XDR_Name:"Unsigned Integer"`
+	Boolean               bool     `XDR_Name:"Boolean"`
+	HyperInteger          int64    `XDR_Name:"Hyper Integer"`
+	UnsignedHyperInteger  uint64   `XDR_Name:"Unsigned Hyper Integer"`
+	FixedLengthOpaqueData [3]byte  `XDR_Name:"Fixed-Length Opaque Data"`
+	OpaqueData            []byte   `XDR_Name:"Variable-Length Opaque Data" XDR_MaxSize:"6"`
+	StringAsString        string   `XDR_Name:"String" XDR_MaxSize:"255"`
+	FixedLengthArray      [2]int32 `XDR_Name:"Fixed-Length Array"`
+	VariableLengthArray   []uint32 `XDR_Name:"Variable-Length Array" XDR_MaxSize:"4"`
+	Inferred              uint32   `xdr:""`
+	Skipped               []int    `XDR_Name:"-"`
+}
+
+type OptionalStruct struct {
+	Compact  *uint32 `xdr:"optional"`
+	LongForm *string `XDR_Name:"Optional-Data" XDR_MaxSize:"8"`
+}
+
+type UnionStruct struct {
+	Discriminant int32     `xdr:""`
+	Integer      *int32    `xdr:"case=1"`
+	String       *string   `xdr:"string,max=8,case=2|3"`
+	Void         *struct{} `XDR_Name:"Structure" XDR_Case:"4"`
+	Other        *uint64   `xdr:"default"`
+}
+
+type UnionWithoutDefaultStruct struct {
+	Discriminant uint32 `xdr:""`
+	Integer      *int32 `xdr:"case=1"`
+}
+
+type ExtensionStruct struct {
+	Type    int32   `xdr:""`
+	Name    *string `xdr:"string,max=16,case=1"`
+	Flags   *uint32 `xdr:"case=2"`
+	Unknown []byte  `xdr:"unknown,max=64"`
+}
+
+type ExtensionV1Struct struct {
+	Type    int32   `xdr:""`
+	Name    *string `xdr:"string,max=16,case=1"`
+	Unknown []byte  `XDR_Name:"Variable-Length Opaque Data" XDR_Case:"unknown"`
+}
+
+type EmptySliceOfBadlyTaggedStruct struct {
+	Elements []struct {
+		Bad uint32 `xdr:"unsigned"`
+	} `xdr:""`
+}
+
+func TestCompactTags(t *testing.T) {
+	var (
+		compactTaggedStruct         CompactTaggedStruct
+		compactTaggedStructPacked   []byte
+		compactTaggedStructReturned CompactTaggedStruct
+		err                         error
+		longFormTaggedStructPacked  []byte
+	)
+
+	compactTaggedStruct = CompactTaggedStruct{
+		Integer:               -1,
+		UnsignedInteger:       2,
+		Boolean:               true,
+		HyperInteger:          -3,
+		UnsignedHyperInteger:  4,
+		FixedLengthOpaqueData: [3]byte{0x05, 0x06, 0x07},
+		OpaqueData:            []byte{0x08, 0x09},
+		StringAsString:        "ten",
+		FixedLengthArray:      [2]int32{11, 12},
+		VariableLengthArray:   []uint32{13},
+		Inferred:              14,
+	}
+
+	compactTaggedStructPacked, err = Pack(compactTaggedStruct)
+	if nil != err {
+		t.Fatalf("Pack(compactTaggedStruct) received unexpected error: %v", err)
+	}
+
+	longFormTaggedStructPacked, err = Pack(LongFormTaggedStruct(compactTaggedStruct))
+	if nil != err {
+		t.Fatalf("Pack(LongFormTaggedStruct(compactTaggedStruct)) received unexpected error: %v", err)
+	}
+
+	if 0 != bytes.Compare(compactTaggedStructPacked, longFormTaggedStructPacked) {
+		t.Fatalf("Pack(compactTaggedStruct) returned 0x%X - should have been 0x%X", compactTaggedStructPacked, longFormTaggedStructPacked)
+	}
+
+	_, err = Unpack(compactTaggedStructPacked, &compactTaggedStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(compactTaggedStructPacked, &compactTaggedStructReturned) received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(compactTaggedStruct, compactTaggedStructReturned) {
+		t.Fatalf("Unpack(compactTaggedStructPacked, &compactTaggedStructReturned) received unexpected compactTaggedStructReturned: %+v", compactTaggedStructReturned)
+	}
+
+	_, err = Pack(struct {
+		OpaqueData []byte `xdr:"opaque,max=1"`
+	}{OpaqueData: []byte{0x01, 0x02}})
+	if nil == err {
+		t.Fatalf("Pack(<xdr:\"opaque,max=1\"> with 2 bytes) should have failed")
+	}
+}
+
+func TestCompactTagGrammar(t *testing.T) {
+	var (
+		badlyTaggedStruct  interface{}
+		badlyTaggedStructs []interface{}
+		err                error
+	)
+
+	badlyTaggedStructs = []interface{}{
+		struct {
+			F uint32 `xdr:"unsigned"`
+		}{},
+		struct {
+			F uint32 `xdr:"uint,int"`
+		}{},
+		struct {
+			F []byte `xdr:"opaque,max=6,max=7"`
+		}{},
+		struct {
+			F []byte `xdr:"opaque,max=-1"`
+		}{},
+		struct {
+			F []byte `xdr:"opaque,max=4294967296"`
+		}{},
+		struct {
+			F *uint32 `xdr:"optional,optional"`
+		}{},
+		struct {
+			F uint32 `xdr:"optional"`
+		}{},
+		struct {
+			F uint32 `xdr:"uint" XDR_Name:"Unsigned Integer"`
+		}{},
+		struct {
+			F uint32 `xdr:"string"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F *int32 `xdr:"case=1,default"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F *int32 `xdr:"case=one"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F *int32 `xdr:"case=1"`
+			G *int32 `xdr:"case=1"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F *int32 `xdr:"default"`
+			G *int32 `xdr:"default"`
+		}{},
+		struct {
+			D string `xdr:""`
+			F *int32 `xdr:"case=1"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F *int32 `xdr:"case=1"`
+			G *int32
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F []byte `xdr:"case=1,unknown"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F []byte `xdr:"unknown"`
+			G []byte `xdr:"unknown"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F *int32 `xdr:"default"`
+			G []byte `xdr:"unknown"`
+		}{},
+		struct {
+			D int32    `xdr:""`
+			F []uint32 `xdr:"unknown"`
+		}{},
+		struct {
+			D int32  `xdr:""`
+			F []byte `xdr:"string,unknown"`
+		}{},
+	}
+
+	for _, badlyTaggedStruct = range badlyTaggedStructs {
+		err = CheckTags(badlyTaggedStruct)
+		if nil == err {
+			t.Fatalf("CheckTags(%T) should have failed", badlyTaggedStruct)
+		}
+	}
+
+	err = CheckTags(CompactTaggedStruct{})
+	if nil != err {
+		t.Fatalf("CheckTags(CompactTaggedStruct{}) received unexpected error: %v", err)
+	}
+	err = CheckTags(&UnionStruct{})
+	if nil != err {
+		t.Fatalf("CheckTags(&UnionStruct{}) received unexpected error: %v", err)
+	}
+}
+
+func TestOptionalData(t *testing.T) {
+	var (
+		err                    error
+		optionalStruct         OptionalStruct
+		optionalStructPacked   []byte
+		optionalStructReturned OptionalStruct
+		s                      string
+		u32                    uint32
+	)
+
+	optionalStructPacked, err = Pack(optionalStruct)
+	if nil != err {
+		t.Fatalf("Pack(OptionalStruct{}) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(optionalStructPacked, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}) {
+		t.Fatalf("Pack(OptionalStruct{}) returned unexpected 0x%X", optionalStructPacked)
+	}
+
+	u32 = 0x01020304
+	s = "abc"
+	optionalStruct = OptionalStruct{Compact: &u32, LongForm: &s}
+
+	optionalStructPacked, err = Pack(optionalStruct)
+	if nil != err {
+		t.Fatalf("Pack(optionalStruct) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(optionalStructPacked, []byte{0x00, 0x00, 0x00, 0x01, 0x01, 0x02, 0x03, 0x04, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 'a', 'b', 'c', 0x00}) {
+		t.Fatalf("Pack(optionalStruct) returned unexpected 0x%X", optionalStructPacked)
+	}
+
+	_, err = Unpack(optionalStructPacked, &optionalStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(optionalStructPacked, &optionalStructReturned) received unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(optionalStruct, optionalStructReturned) {
+		t.Fatalf("Unpack(optionalStructPacked, &optionalStructReturned) received unexpected optionalStructReturned: %+v", optionalStructReturned)
+	}
+
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, &optionalStructReturned)
+	if nil != err {
+		t.Fatalf("Unpack(<absent Optional-Data>, &optionalStructReturned) received unexpected error: %v", err)
+	}
+	if (nil != optionalStructReturned.Compact) || (nil != optionalStructReturned.LongForm) {
+		t.Fatalf("Unpack(<absent Optional-Data>, &optionalStructReturned) should have set nil pointers: %+v", optionalStructReturned)
+	}
+
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00}, &optionalStructReturned)
+	if !errors.Is(err, ErrInvalidBoolean) {
+		t.Fatalf("Unpack(<invalid Optional-Data boolean>, &optionalStructReturned) returned unexpected error: %v", err)
+	}
+}
+
+func TestDiscriminatedUnion(t *testing.T) {
+	var (
+		err                 error
+		i32                 int32
+		s                   string
+		u64                 uint64
+		unionStruct         UnionStruct
+		unionStructPacked   []byte
+		unionStructReturned UnionStruct
+		unionStructs        []UnionStruct
+	)
+
+	i32 = -2
+	s = "xyz"
+	u64 = 0x0102030405060708
+
+	unionStructs = []UnionStruct{
+		{Discriminant: 1, Integer: &i32},
+		{Discriminant: 3, String: &s},
+		{Discriminant: 4, Void: &struct{}{}},
+		{Discriminant: 7, Other: &u64},
+	}
+
+	for _, unionStruct = range unionStructs {
+		unionStructPacked, err = Pack(unionStruct)
+		if nil != err {
+			t.Fatalf("Pack(%+v) received unexpected error: %v", unionStruct, err)
+		}
+		unionStructReturned = UnionStruct{Integer: &i32, String: &s, Other: &u64}
+		_, err = Unpack(unionStructPacked, &unionStructReturned)
+		if nil != err {
+			t.Fatalf("Unpack(0x%X, &unionStructReturned) received unexpected error: %v", unionStructPacked, err)
+		}
+		if !reflect.DeepEqual(unionStruct, unionStructReturned) {
+			t.Fatalf("Unpack(0x%X, &unionStructReturned) received unexpected unionStructReturned: %+v", unionStructPacked, unionStructReturned)
+		}
+	}
+
+	unionStructPacked, err = Pack(UnionStruct{Discriminant: 4, Void: &struct{}{}})
+	if nil != err {
+		t.Fatalf("Pack(<void arm>) received unexpected error: %v", err)
+	}
+	if 0 != bytes.Compare(unionStructPacked, []byte{0x00, 0x00, 0x00, 0x04}) {
+		t.Fatalf("Pack(<void arm>) returned unexpected 0x%X", unionStructPacked)
+	}
+
+	_, err = Pack(UnionWithoutDefaultStruct{Discriminant: 2})
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("Pack(<unselected discriminant>) returned unexpected error: %v", err)
+	}
+
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x02}, &UnionWithoutDefaultStruct{})
+	if !errors.Is(err, ErrInvalidDiscriminant) {
+		t.Fatalf("Unpack(<unselected discriminant>, &UnionWithoutDefaultStruct{}) returned unexpected error: %v", err)
+	}
+}
+
+func TestUnknownUnionArms(t *testing.T) {
+	var (
+		err                       error
+		extensionStruct           ExtensionStruct
+		extensionStructPacked     []byte
+		extensionStructReturned   ExtensionStruct
+		extensionV1StructPacked   []byte
+		extensionV1StructReturned ExtensionV1Struct
+		i                         int
+		jsonBytes                 []byte
+		s                         string
+		schema                    *Schema
+		size                      uint64
+		u32                       uint32
+		value                     Value
+		view                      View
+	)
+
+	s = "ab"
+	u32 = 7
+
+	// Verify each arm is wrapped in Variable-Length Opaque Data
+
+	extensionStruct = ExtensionStruct{Type: 1, Name: &s}
+
+	extensionStructPacked, err = Pack(extensionStruct)
+	if (nil != err) || !bytes.Equal(extensionStructPacked, []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x02, 'a', 'b', 0x00, 0x00}) {
+		t.Fatalf("Pack(<known arm>) returned 0x%X or unexpected error: %v", extensionStructPacked, err)
+	}
+	err = UnpackExact(extensionStructPacked, &extensionStructReturned)
+	if (nil != err) || !reflect.DeepEqual(extensionStruct, extensionStructReturned) {
+		t.Fatalf("UnpackExact(<known arm>) returned %+v or unexpected error: %v", extensionStructReturned, err)
+	}
+
+	// Verify an arm unknown to an older peer is preserved (and re-emitted unchanged) by it
+
+	extensionStructPacked, err = Pack(ExtensionStruct{Type: 2, Flags: &u32})
+	if (nil != err) || !bytes.Equal(extensionStructPacked, []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x07}) {
+		t.Fatalf("Pack(<newer arm>) returned 0x%X or unexpected error: %v", extensionStructPacked, err)
+	}
+	err = UnpackExact(extensionStructPacked, &extensionV1StructReturned)
+	if (nil != err) || (2 != extensionV1StructReturned.Type) || !bytes.Equal(extensionV1StructReturned.Unknown, []byte{0x00, 0x00, 0x00, 0x07}) {
+		t.Fatalf("UnpackExact(<newer arm>) returned %+v or unexpected error: %v", extensionV1StructReturned, err)
+	}
+	extensionV1StructPacked, err = Pack(extensionV1StructReturned)
+	if (nil != err) || !bytes.Equal(extensionStructPacked, extensionV1StructPacked) {
+		t.Fatalf("Pack(<preserved newer arm>) returned 0x%X or unexpected error: %v", extensionV1StructPacked, err)
+	}
+
+	extensionStructPacked = []byte{0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x03, 'x', 'y', 'z', 0x00}
+	err = UnpackExact(extensionStructPacked, &extensionStructReturned)
+	if (nil != err) || (nil != extensionStructReturned.Name) || !bytes.Equal(extensionStructReturned.Unknown, []byte("xyz")) {
+		t.Fatalf("UnpackExact(<unknown arm>) returned %+v or unexpected error: %v", extensionStructReturned, err)
+	}
+	extensionV1StructPacked, err = Pack(extensionStructReturned)
+	if (nil != err) || !bytes.Equal(extensionStructPacked, extensionV1StructPacked) {
+		t.Fatalf("Pack(<preserved unknown arm>) returned 0x%X or unexpected error: %v", extensionV1StructPacked, err)
+	}
+
+	_, err = Pack(ExtensionStruct{Type: 9, Unknown: make([]byte, 65)})
+	if nil == err {
+		t.Fatalf("Pack(<unknown arm exceeding XDR_MaxSize>) should have failed")
+	}
+
+	// Verify a known arm must exactly fill the Variable-Length Opaque Data wrapping it
+
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x00}, &extensionStructReturned)
+	if !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("Unpack(<arm shorter than its length>) returned unexpected error: %v", err)
+	}
+	_, err = Unpack([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x07}, &extensionStructReturned)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Unpack(<arm longer than its length>) returned unexpected error: %v", err)
+	}
+
+	// Verify Validate() agrees with Unpack() on every prefix & corruption
+
+	extensionStructPacked = mustPack(t, ExtensionStruct{Type: 1, Name: &s})
+	for i = 0; i < len(extensionStructPacked); i++ {
+		validateAgreesWithUnpack(t, extensionStructPacked[:i], reflect.TypeOf(ExtensionStruct{}))
+		extensionV1StructPacked = append([]byte{}, extensionStructPacked...)
+		extensionV1StructPacked[i] = 0x0C
+		validateAgreesWithUnpack(t, extensionV1StructPacked, reflect.TypeOf(ExtensionStruct{}))
+		validateAgreesWithUnpack(t, extensionV1StructPacked, reflect.TypeOf(ExtensionV1Struct{}))
+	}
+
+	// Verify the Schema describes each known arm by its own type (within its length) and only the unknown arm as opaque<>
+
+	schema, err = schemaOfType(ExtensionStruct{})
+	if (nil != err) || (3 != len(schema.Arms)) || (SchemaString != schema.Arms[0].Field.Type.Kind) || !schema.Arms[0].IsWrapped || (SchemaUint != schema.Arms[1].Field.Type.Kind) || !schema.Arms[1].IsWrapped {
+		t.Fatalf("schemaOfType(ExtensionStruct{}) returned unexpected known arms or error: %v", err)
+	}
+	if (SchemaOpaque != schema.Arms[2].Field.Type.Kind) || !schema.Arms[2].IsDefault || schema.Arms[2].IsWrapped || (64 != schema.Arms[2].Field.Type.Size) {
+		t.Fatalf("schemaOfType(ExtensionStruct{}) returned unexpected unknown arm")
+	}
+
+	// Verify the schema-driven APIs decode (and encode) a known arm by its own type
+
+	extensionStructPacked = mustPack(t, ExtensionStruct{Type: 1, Name: &s})
+	jsonBytes, err = ToJSON(extensionStructPacked, ExtensionStruct{})
+	if (nil != err) || (`{"tag":1,"value":"ab"}` != string(jsonBytes)) {
+		t.Fatalf("ToJSON(<known arm>) returned %s or unexpected error: %v", jsonBytes, err)
+	}
+	extensionV1StructPacked, err = FromJSON(jsonBytes, ExtensionStruct{})
+	if (nil != err) || !bytes.Equal(extensionStructPacked, extensionV1StructPacked) {
+		t.Fatalf("FromJSON(%s) returned 0x%X or unexpected error: %v", jsonBytes, extensionV1StructPacked, err)
+	}
+	value, _, err = DecodeDynamic(extensionStructPacked, schema)
+	if (nil != err) || (String("ab") != value.(Union).Value) {
+		t.Fatalf("DecodeDynamic(<known arm>) returned %#v or unexpected error: %v", value, err)
+	}
+	extensionV1StructPacked, err = EncodeDynamic(value, schema)
+	if (nil != err) || !bytes.Equal(extensionStructPacked, extensionV1StructPacked) {
+		t.Fatalf("EncodeDynamic(%#v) returned 0x%X or unexpected error: %v", value, extensionV1StructPacked, err)
+	}
+	view = NewView(extensionStructPacked, ExtensionStruct{})
+	s, err = view.Field("Name").String()
+	if (nil != err) || ("ab" != s) {
+		t.Fatalf("NewView(<known arm>).Field(\"Name\").String() returned %q or unexpected error: %v", s, err)
+	}
+	size, err = view.Size()
+	if (nil != err) || (uint64(len(extensionStructPacked)) != size) {
+		t.Fatalf("NewView(<known arm>).Size() returned %v or unexpected error: %v", size, err)
+	}
+	_, err = ToJSON([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x00}, ExtensionStruct{})
+	if !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("ToJSON(<arm shorter than its length>) returned unexpected error: %v", err)
+	}
+}
+
+func TestCheckTags(t *testing.T) {
+	var (
+		err        error
+		panicValue interface{}
+	)
+
+	_, err = Pack(EmptySliceOfBadlyTaggedStruct{})
+	if nil != err {
+		t.Fatalf("Pack(EmptySliceOfBadlyTaggedStruct{}) received unexpected error: %v", err)
+	}
+
+	err = CheckTags(EmptySliceOfBadlyTaggedStruct{})
+	if nil == err {
+		t.Fatalf("CheckTags(EmptySliceOfBadlyTaggedStruct{}) should have failed")
+	}
+
+	err = CheckTags(nil)
+	if nil == err {
+		t.Fatalf("CheckTags(nil) should have failed")
+	}
+
+	err = CheckTags(SelfPointer(nil))
+	if nil != err {
+		t.Fatalf("CheckTags(SelfPointer(nil)) received unexpected error: %v", err)
+	}
+
+	func() {
+		defer func() {
+			panicValue = recover()
+		}()
+		MustCheckTags(EmptySliceOfBadlyTaggedStruct{})
+	}()
+	if nil == panicValue {
+		t.Fatalf("MustCheckTags(EmptySliceOfBadlyTaggedStruct{}) should have panicked")
+	}
+}
@@ -0,0 +1,456 @@
+package xdr
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type validateTypeCacheEntry struct {
+	err error
+}
+
+var validateTypeCache sync.Map // map[reflect.Type]*validateTypeCacheEntry
+
+var elementMinimumSizeCache sync.Map // map[reflect.Type]uint64
+
+var validateDefaultOptions UnpackOptions // Applied by ValidateWithOptions() when passed nil options (and never modified)
+
+// Validate checks that src begins with a well-formed encoding of a value of type typ (or, if typ is a pointer
+// type, of the type it points to) without decoding it into a Go value (e.g. to reject a malformed message
+// before queuing it). The lengths, XDR_MaxSize bounds, Boolean words, pad bytes, Enumeration values, and union
+// discriminants encountered are checked exactly as by Unpack(), so Validate() fails only if Unpack() would.
+//
+// Once the tags of typ have been validated (by the first call for typ), Validate() performs no allocations
+// (other than of any error it returns).
+func Validate(src []byte, typ reflect.Type) (bytesConsumed uint64, err error) {
+	bytesConsumed, err = ValidateWithOptions(src, typ, nil)
+	return
+}
+
+// ValidateWithOptions is like Validate() but checks src as would UnpackWithOptions() (into a zero value of type
+// typ) as directed by options (which may be nil), so that options.Mode, options.Limits (and, in accounting for the
+// bytes Unpack() would allocate, options.AliasBytes) apply.
+func ValidateWithOptions(src []byte, typ reflect.Type, options *UnpackOptions) (bytesConsumed uint64, err error) {
+	var (
+		state validateState
+	)
+
+	defer recoverInternalError("Validate", &err)
+
+	if nil == typ {
+		err = fmt.Errorf("Validate() passed nil typ")
+		return
+	}
+
+	if nil == options {
+		state.options = &validateDefaultOptions
+	} else {
+		state.options = options
+	}
+
+	err = validateTypeTags(typ)
+	if nil != err {
+		return
+	}
+
+	bytesConsumed, err = validateRecursive(src, 0, xdrTag{}, typ, 0, &state)
+	if nil != err {
+		return
+	}
+
+	if (UnpackModeStrict == state.options.Mode) && (uint64(len(src)) > bytesConsumed) {
+		err = newUnpackError(bytesConsumed, ErrTrailingBytes, "0x%X bytes remain in src []byte", uint64(len(src))-bytesConsumed)
+		return
+	}
+
+	return
+}
+
+// validateState mirrors (without allocating) the unpackState of the UnpackWithOptions() call Validate() predicts.
+type validateState struct {
+	options   *UnpackOptions
+	allocated uint64 // Bytes UnpackWithOptions() would have allocated decoding the portion of src validated so far
+}
+
+// allocate accounts for bytesToAllocate as would unpackState.allocate().
+func (state *validateState) allocate(offset uint64, bytesToAllocate uint64) (err error) {
+	state.allocated += bytesToAllocate
+	if (0 != state.options.Limits.MaxAllocation) && (state.options.Limits.MaxAllocation < state.allocated) {
+		err = newUnpackError(offset, ErrLimitExceeded, "total allocation exceeds Limits.MaxAllocation (%v)", state.options.Limits.MaxAllocation)
+	}
+	return
+}
+
+// validateTypeTags returns the result of CheckTags() for typ (caching only success, as a failure such as an
+// unregistered interface may be remedied by a later call to RegisterInterface()).
+func validateTypeTags(typ reflect.Type) (err error) {
+	var (
+		cachedIF interface{}
+		ok       bool
+	)
+
+	cachedIF, ok = validateTypeCache.Load(typ)
+	if ok {
+		err = cachedIF.(*validateTypeCacheEntry).err
+		return
+	}
+
+	err = checkTypeRecursive(typ, xdrTag{}, make(map[reflect.Type]bool))
+	if nil != err {
+		return
+	}
+
+	validateTypeCache.Store(typ, &validateTypeCacheEntry{err: nil})
+
+	return
+}
+
+// elementMinimumSizeOf returns the (cached) minimumSizeRecursive() of the element type of a Variable-Length Array.
+func elementMinimumSizeOf(elemTypeOf reflect.Type) (minimumSize uint64) {
+	var (
+		cachedIF interface{}
+		ok       bool
+	)
+
+	cachedIF, ok = elementMinimumSizeCache.Load(elemTypeOf)
+	if ok {
+		minimumSize = cachedIF.(uint64)
+		return
+	}
+
+	minimumSize = minimumSizeRecursive(elemTypeOf, xdrTag{}, make(map[reflect.Type]bool))
+
+	elementMinimumSizeCache.Store(elemTypeOf, minimumSize)
+
+	return
+}
+
+// validateRecursive mirrors unpackRecursive() for a value of type objTypeOf, returning the offset following it.
+func validateRecursive(src []byte, oldOffset uint64, tag xdrTag, objTypeOf reflect.Type, depth uint64, state *validateState) (newOffset uint64, err error) {
+	var (
+		actualLength       uint64
+		armField           *xdrField
+		armOffset          uint64
+		concreteTypeOf     reflect.Type
+		discriminant       int64
+		elementMinimumSize uint64
+		enumValueOf        reflect.Value
+		field              xdrField
+		i                  uint64
+		i64                int64
+		ok                 bool
+		paddedLength       uint64
+		registration       *interfaceRegistration
+		structLayout       *xdrStructLayout
+		u64                uint64
+	)
+
+	// Enforce Limits.MaxDepth for "aggregate" & "encapsulating" objTypeOf.Kind()'s (as does unpackRecursive())
+
+	switch objTypeOf.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Struct, reflect.Ptr, reflect.Interface:
+		depth++
+		if state.options.Limits.maxDepth() < depth {
+			err = newUnpackError(oldOffset, ErrLimitExceeded, "nesting depth exceeds Limits.MaxDepth (%v)", state.options.Limits.maxDepth())
+			return
+		}
+	}
+
+	// Handle specific objTypeOf.Kind()
+
+	switch objTypeOf.Kind() {
+	case reflect.Interface:
+		registration, ok = lookupInterface(objTypeOf)
+		if !ok {
+			err = fmt.Errorf("Validate() cannot validate %v (the encoding of an unregistered interface depends on the value it holds)", objTypeOf)
+			return
+		}
+		u64, newOffset, err = validateWord(src, oldOffset, 4, objTypeOf)
+		if nil != err {
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.Interface discriminant in src []byte")
+			return
+		}
+		concreteTypeOf, ok = registration.concreteTypes[int32(uint32(u64))]
+		if !ok {
+			err = newUnpackError(oldOffset, ErrInvalidDiscriminant, "no concrete type of %v registered for discriminant %v", objTypeOf, int32(uint32(u64)))
+			return
+		}
+		err = state.allocate(oldOffset, uint64(concreteTypeOf.Size()))
+		if nil != err {
+			return
+		}
+		newOffset, err = validateRecursive(src, newOffset, xdrTag{name: tag.name, maxSize: tag.maxSize}, concreteTypeOf, depth, state)
+		if nil != err {
+			return
+		}
+	case reflect.Ptr:
+		if tag.optional {
+			if uint64(len(src)) < (oldOffset + 4) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for Optional-Data reflect.Ptr field in src []byte")
+				return
+			}
+			if (UnpackModeLenient != state.options.Mode) && ((0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (1 < src[oldOffset+3])) {
+				err = newUnpackError(oldOffset, ErrInvalidBoolean, "Invalid bytes for Optional-Data reflect.Ptr field in src []byte")
+				return
+			}
+			if (0 == src[oldOffset+0]) && (0 == src[oldOffset+1]) && (0 == src[oldOffset+2]) && (0 == src[oldOffset+3]) {
+				newOffset = oldOffset + 4
+				return
+			}
+			err = state.allocate(oldOffset, uint64(objTypeOf.Elem().Size()))
+			if nil != err {
+				return
+			}
+			newOffset, err = validateRecursive(src, oldOffset+4, xdrTag{name: tag.name, maxSize: tag.maxSize}, objTypeOf.Elem(), depth, state)
+		} else {
+			err = state.allocate(oldOffset, uint64(objTypeOf.Elem().Size()))
+			if nil != err {
+				return
+			}
+			newOffset, err = validateRecursive(src, oldOffset, tag, objTypeOf.Elem(), depth, state)
+		}
+		if nil != err {
+			return
+		}
+	case reflect.Bool:
+		if uint64(len(src)) < (oldOffset + 4) {
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for reflect.Bool field in src []byte")
+			return
+		}
+		if (UnpackModeLenient != state.options.Mode) && ((0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (1 < src[oldOffset+3])) {
+			err = newUnpackError(oldOffset, ErrInvalidBoolean, "Invalid bytes for reflect.Bool field in src []byte")
+			return
+		}
+		newOffset = oldOffset + 4
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		u64, newOffset, err = validateWord(src, oldOffset, xdrIntegerSize(objTypeOf.Kind(), tag), objTypeOf)
+		if nil != err {
+			return
+		}
+		if 4 == newOffset-oldOffset {
+			i64 = int64(int32(uint32(u64)))
+		} else {
+			i64 = int64(u64)
+		}
+		if (64 > objTypeOf.Bits()) && (i64 != ((i64 << (64 - objTypeOf.Bits())) >> (64 - objTypeOf.Bits()))) {
+			err = newUnpackError(oldOffset, ErrOverflow, "value %d overflows %v", i64, objTypeOf)
+			return
+		}
+		if (UnpackModeLenient != state.options.Mode) && !isDeclaredEnumerationValue(objTypeOf, int32(i64)) {
+			enumValueOf = reflect.New(objTypeOf).Elem()
+			enumValueOf.SetInt(i64)
+			err = newUnpackError(oldOffset, ErrInvalidEnumeration, "%s is not a declared Enumeration value", EnumerationString(enumValueOf.Interface()))
+			return
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u64, newOffset, err = validateWord(src, oldOffset, xdrIntegerSize(objTypeOf.Kind(), tag), objTypeOf)
+		if nil != err {
+			return
+		}
+		if (UnpackModeStrict == state.options.Mode) && (4 == newOffset-oldOffset) && ("Enumeration" == tag.name) && (0x7FFFFFFF < u64) {
+			err = newUnpackError(oldOffset, ErrInvalidEnumeration, "Enumeration value 0x%X exceeds the range of a signed 32-bit integer", u64)
+			return
+		}
+		if (64 > objTypeOf.Bits()) && (0 != (u64 >> objTypeOf.Bits())) {
+			err = newUnpackError(oldOffset, ErrOverflow, "value %d overflows %v", u64, objTypeOf)
+			return
+		}
+		if (UnpackModeLenient != state.options.Mode) && !isDeclaredEnumerationValue(objTypeOf, int32(uint32(u64))) {
+			enumValueOf = reflect.New(objTypeOf).Elem()
+			enumValueOf.SetUint(u64)
+			err = newUnpackError(oldOffset, ErrInvalidEnumeration, "%s is not a declared Enumeration value", EnumerationString(enumValueOf.Interface()))
+			return
+		}
+	case reflect.Array:
+		if reflect.Uint8 == objTypeOf.Elem().Kind() {
+			paddedLength = (uint64(objTypeOf.Len()) + 3) / 4 * 4
+			if uint64(len(src)) < (oldOffset + paddedLength) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for refelct.Array field in src []byte")
+				return
+			}
+			if UnpackModeLenient != state.options.Mode {
+				err = validatePadding(src, oldOffset+uint64(objTypeOf.Len()), oldOffset+paddedLength)
+				if nil != err {
+					return
+				}
+			}
+			newOffset = oldOffset + paddedLength
+		} else {
+			newOffset = oldOffset
+			for i = 0; i < uint64(objTypeOf.Len()); i++ {
+				newOffset, err = validateRecursive(src, newOffset, xdrTag{}, objTypeOf.Elem(), depth, state)
+				if nil != err {
+					return
+				}
+			}
+		}
+	case reflect.Slice, reflect.String:
+		actualLength, _, err = validateWord(src, oldOffset, 4, objTypeOf)
+		if nil != err {
+			err = newUnpackError(oldOffset, ErrTruncated, "No room for %s length field in src []byte", reflectKindName(objTypeOf.Kind()))
+			return
+		}
+		newOffset = oldOffset + 4
+		if 0 == actualLength {
+			return
+		}
+		if (0 != tag.maxSize) && (tag.maxSize < actualLength) {
+			err = newUnpackError(oldOffset, ErrMaxSizeExceeded, "dstObjValueOf %s exceeds XDR_MaxSize", reflectKindName(objTypeOf.Kind()))
+			return
+		}
+		if (reflect.String == objTypeOf.Kind()) || (reflect.Uint8 == objTypeOf.Elem().Kind()) {
+			if (0 != state.options.Limits.MaxStringLength) && (state.options.Limits.MaxStringLength < actualLength) {
+				err = newUnpackError(oldOffset, ErrLimitExceeded, "%s exceeds Limits.MaxStringLength (%v)", reflectKindName(objTypeOf.Kind()), state.options.Limits.MaxStringLength)
+				return
+			}
+			paddedLength = (actualLength + 3) / 4 * 4
+			if (oldOffset + 4 + paddedLength) > uint64(len(src)) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for %s padded length in src []byte", reflectKindName(objTypeOf.Kind()))
+				return
+			}
+			if (reflect.String == objTypeOf.Kind()) || !state.options.AliasBytes {
+				err = state.allocate(oldOffset, actualLength)
+				if nil != err {
+					return
+				}
+			}
+			if UnpackModeLenient != state.options.Mode {
+				err = validatePadding(src, oldOffset+4+actualLength, oldOffset+4+paddedLength)
+				if nil != err {
+					return
+				}
+			}
+			newOffset = oldOffset + 4 + paddedLength
+		} else {
+			if (0 != state.options.Limits.MaxArrayElements) && (state.options.Limits.MaxArrayElements < actualLength) {
+				err = newUnpackError(oldOffset, ErrLimitExceeded, "reflect.Slice exceeds Limits.MaxArrayElements (%v)", state.options.Limits.MaxArrayElements)
+				return
+			}
+			elementMinimumSize = elementMinimumSizeOf(objTypeOf.Elem())
+			if (0 != elementMinimumSize) && (((uint64(len(src)) - (oldOffset + 4)) / elementMinimumSize) < actualLength) {
+				err = newUnpackError(oldOffset, ErrTruncated, "No room for %v reflect.Slice elements in src []byte", actualLength)
+				return
+			}
+			if (0 == elementMinimumSize) && (state.options.Limits.maxZeroSizeElements() < actualLength) {
+				err = newUnpackError(oldOffset, ErrLimitExceeded, "reflect.Slice of elements possibly encoded in zero bytes exceeds %v elements", state.options.Limits.maxZeroSizeElements())
+				return
+			}
+			err = state.allocate(oldOffset, actualLength*uint64(objTypeOf.Elem().Size()))
+			if nil != err {
+				return
+			}
+			for i = 0; i < actualLength; i++ {
+				newOffset, err = validateRecursive(src, newOffset, xdrTag{}, objTypeOf.Elem(), depth, state)
+				if nil != err {
+					return
+				}
+			}
+		}
+	case reflect.Struct:
+		structLayout, err = xdrStructLayoutOf(objTypeOf)
+		if nil != err {
+			return
+		}
+		if structLayout.isUnion {
+			field = structLayout.fields[0]
+			newOffset, err = validateRecursive(src, oldOffset, field.tag, field.typeOf, depth, state)
+			if nil != err {
+				return
+			}
+			switch field.typeOf.Kind() {
+			case reflect.Bool:
+				// Note: As decoded by Unpack(), a Boolean discriminant is 0 or 1 (even if, in UnpackModeLenient, its word is neither)
+				if (0 != src[oldOffset+0]) || (0 != src[oldOffset+1]) || (0 != src[oldOffset+2]) || (0 != src[oldOffset+3]) {
+					discriminant = 1
+				} else {
+					discriminant = 0
+				}
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+				discriminant = int64(uint32(src[oldOffset+0])<<24 | uint32(src[oldOffset+1])<<16 | uint32(src[oldOffset+2])<<8 | uint32(src[oldOffset+3]))
+			default:
+				discriminant = int64(int32(uint32(src[oldOffset+0])<<24 | uint32(src[oldOffset+1])<<16 | uint32(src[oldOffset+2])<<8 | uint32(src[oldOffset+3])))
+			}
+			armField, ok = selectUnionArm(structLayout, discriminant)
+			if !ok {
+				err = newUnpackError(oldOffset, ErrInvalidDiscriminant, "no arm of %v selected by discriminant %v", objTypeOf, discriminant)
+				return
+			}
+			if !structLayout.isWrapped || armField.tag.isUnknown {
+				newOffset, err = validateRecursive(src, newOffset, armField.tag, armField.typeOf, depth, state)
+				if nil != err {
+					return
+				}
+				break
+			}
+			armOffset = newOffset
+			if uint64(len(src)) < (armOffset + 4) {
+				err = newUnpackError(armOffset, ErrTruncated, "No room for Discriminated Union arm length in src []byte")
+				return
+			}
+			actualLength, _, _ = validateWord(src, armOffset, 4, objTypeOf)
+			if uint64(len(src)) < (armOffset + 4 + actualLength) {
+				err = newUnpackError(armOffset, ErrTruncated, "No room for Discriminated Union arm in src []byte")
+				return
+			}
+			newOffset, err = validateRecursive(src[:armOffset+4+actualLength], armOffset+4, armField.tag, armField.typeOf, depth, state)
+			if nil != err {
+				return
+			}
+			if (armOffset + 4 + actualLength) != newOffset {
+				err = newUnpackError(newOffset, ErrTrailingBytes, "%v arm %s did not fill its length (%v)", objTypeOf, armField.name, actualLength)
+				return
+			}
+		} else {
+			newOffset = oldOffset
+			for _, field = range structLayout.fields {
+				newOffset, err = validateRecursive(src, newOffset, field.tag, field.typeOf, depth, state)
+				if nil != err {
+					return
+				}
+			}
+		}
+	default:
+		err = fmt.Errorf("%v has unsupported Kind() == %s", objTypeOf, reflectKindName(objTypeOf.Kind()))
+		return
+	}
+
+	err = nil
+	return
+}
+
+// validateWord returns the big-endian 4 or 8 byte word at oldOffset in src along with the offset following it.
+func validateWord(src []byte, oldOffset uint64, size uint64, objTypeOf reflect.Type) (u64 uint64, newOffset uint64, err error) {
+	var (
+		i uint64
+	)
+
+	if uint64(len(src)) < (oldOffset + size) {
+		err = newUnpackError(oldOffset, ErrTruncated, "No room for %s field in src []byte", reflectKindName(objTypeOf.Kind()))
+		return
+	}
+
+	for i = 0; i < size; i++ {
+		u64 = (u64 << 8) | uint64(src[oldOffset+i])
+	}
+
+	newOffset = oldOffset + size
+
+	return
+}
+
+// validatePadding checks that the pad bytes of src from startOffset up to endOffset are zero.
+func validatePadding(src []byte, startOffset uint64, endOffset uint64) (err error) {
+	var (
+		i uint64
+	)
+
+	for i = startOffset; i < endOffset; i++ {
+		if 0x00 != src[i] {
+			err = newUnpackError(i, ErrNonZeroPadding, "Non-zero pad bytes in src []byte")
+			return
+		}
+	}
+
+	return
+}
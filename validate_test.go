@@ -0,0 +1,199 @@
+package xdr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	var (
+		allocsPerRun    float64
+		bytesConsumed   uint64
+		corrupted       []byte
+		err             error
+		i               int
+		limits          Limits
+		mode            UnpackMode
+		ok              bool
+		packed          []byte
+		packedByType    map[reflect.Type][]byte
+		replacementByte byte
+		typ             reflect.Type
+		unionString     string
+	)
+
+	unionString = "abc"
+
+	packedByType = map[reflect.Type][]byte{
+		reflect.TypeOf(ParentStruct{}):        goodParentStructPacked,
+		reflect.TypeOf(ColorStruct{}):         mustPack(t, ColorStruct{Color: ColorGreen, Palette: []Color{ColorRed, ColorBlue}}),
+		reflect.TypeOf(UnionStruct{}):         mustPack(t, UnionStruct{Discriminant: 2, String: &unionString}),
+		reflect.TypeOf(OptionalStruct{}):      mustPack(t, OptionalStruct{LongForm: &unionString}),
+		reflect.TypeOf(NarrowIntegerStruct{}): mustPack(t, NarrowIntegerStruct{Int8: -1, Uint16: 0xFFFF}),
+		reflect.TypeOf(&FuzzStruct{}):         mustPack(t, FuzzStruct{Names: []string{"a"}, Blobs: [][]byte{{0x01}}, Nested: [2][]uint32{{1}, {}}}),
+	}
+
+	// Verify Validate() agrees with Unpack() on every prefix & every single byte corruption of each encoding
+
+	for typ, packed = range packedByType {
+		bytesConsumed, err = Validate(packed, typ)
+		if (nil != err) || (uint64(len(packed)) != bytesConsumed) {
+			t.Fatalf("Validate(<%v>) returned %d (expected %d) or unexpected error: %v", typ, bytesConsumed, len(packed), err)
+		}
+
+		for i = 0; i < len(packed); i++ {
+			validateAgreesWithUnpack(t, packed[:i], typ)
+
+			for _, replacementByte = range []byte{0x01, 0x02, 0x7F, 0xFF} {
+				corrupted = append([]byte{}, packed...)
+				corrupted[i] = replacementByte
+				validateAgreesWithUnpack(t, corrupted, typ)
+			}
+		}
+	}
+
+	_, err = Validate(badParentStructPacked, reflect.TypeOf(ParentStruct{}))
+	if !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Fatalf("Validate(badParentStructPacked) returned unexpected error: %v", err)
+	}
+
+	_, err = Validate(goodParentStructPacked, nil)
+	if nil == err {
+		t.Fatalf("Validate(<nil typ>) should have failed")
+	}
+	_, err = Validate(goodParentStructPacked, reflect.TypeOf(EmptySliceOfBadlyTaggedStruct{}))
+	if nil == err {
+		t.Fatalf("Validate(<badly tagged typ>) should have failed")
+	}
+	_, err = Validate(goodParentStructPacked, reflect.TypeOf(struct{ Any interface{} }{}))
+	if nil == err {
+		t.Fatalf("Validate(<interface field>) should have failed")
+	}
+
+	// Verify a tag error is not cached (as a later RegisterInterface() might remedy such an error)
+
+	_, err = Validate(goodParentStructPacked, reflect.TypeOf(EmptySliceOfBadlyTaggedStruct{}))
+	if nil == err {
+		t.Fatalf("Validate(<badly tagged typ>) should have failed")
+	}
+	_, ok = validateTypeCache.Load(reflect.TypeOf(EmptySliceOfBadlyTaggedStruct{}))
+	if ok {
+		t.Fatalf("Validate(<badly tagged typ>) should not have cached its failure")
+	}
+
+	// Verify hostile src is bounded in nesting depth & zero-size elements (rather than exhausting the stack or CPU)
+
+	_, err = Validate(deepNodeSrc, reflect.TypeOf(DeepNode{}))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Validate(deepNodeSrc) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+	_, err = Validate([]byte{0xFF, 0xFF, 0xFF, 0xFF}, reflect.TypeOf([]struct{}{}))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Validate(<0xFFFFFFFF zero-size elements>) should have failed with ErrLimitExceeded (got %v)", err)
+	}
+
+	// Verify ValidateWithOptions() applies Limits as does UnpackWithOptions()
+
+	for _, limits = range []Limits{{MaxDepth: 1}, {MaxDepth: 2}, {MaxAllocation: 16}, {MaxAllocation: 64}, {MaxArrayElements: 1}, {MaxStringLength: 2}} {
+		for typ, packed = range packedByType {
+			validateWithOptionsAgreesWithUnpack(t, packed, typ, &UnpackOptions{Limits: limits})
+		}
+	}
+
+	// Verify ValidateWithOptions() applies Mode as does UnpackWithOptions() (on every corruption & with trailing bytes)
+
+	for _, mode = range []UnpackMode{UnpackModeLenient, UnpackModeStrict} {
+		for typ, packed = range packedByType {
+			validateWithOptionsAgreesWithUnpack(t, append(append([]byte{}, packed...), 0x00, 0x00, 0x00, 0x00), typ, &UnpackOptions{Mode: mode})
+
+			for i = 0; i < len(packed); i++ {
+				for _, replacementByte = range []byte{0x01, 0x02, 0x7F, 0x80, 0xFF} {
+					corrupted = append([]byte{}, packed...)
+					corrupted[i] = replacementByte
+					validateWithOptionsAgreesWithUnpack(t, corrupted, typ, &UnpackOptions{Mode: mode})
+				}
+			}
+		}
+	}
+
+	corrupted = append([]byte{}, goodParentStructPacked...)
+	corrupted[0x13] = 0x02 // Note: The Boolean at offset 0x10
+
+	_, err = ValidateWithOptions(corrupted, reflect.TypeOf(ParentStruct{}), &UnpackOptions{Mode: UnpackModeLenient})
+	if nil != err {
+		t.Fatalf("ValidateWithOptions(<Boolean encoded as 2>, UnpackModeLenient) received unexpected error: %v", err)
+	}
+
+	// Verify Validate() does not allocate
+
+	allocsPerRun = testing.AllocsPerRun(100, func() {
+		_, _ = Validate(goodParentStructPacked, reflect.TypeOf(ParentStruct{}))
+	})
+	if 0 != allocsPerRun {
+		t.Fatalf("Validate(goodParentStructPacked) allocated %v times per run (expected 0)", allocsPerRun)
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	var (
+		err error
+		i   int
+		typ reflect.Type
+	)
+
+	typ = reflect.TypeOf(ParentStruct{})
+
+	b.ReportAllocs()
+
+	for i = 0; i < b.N; i++ {
+		_, err = Validate(goodParentStructPacked, typ)
+		if nil != err {
+			b.Fatalf("Validate(goodParentStructPacked) received unexpected error: %v", err)
+		}
+	}
+}
+
+func mustPack(t *testing.T, srcObjIF interface{}) (dst []byte) {
+	var (
+		err error
+	)
+
+	dst, err = Pack(srcObjIF)
+	if nil != err {
+		t.Fatalf("Pack(%#v) received unexpected error: %v", srcObjIF, err)
+	}
+
+	return
+}
+
+// validateAgreesWithUnpack asserts Validate() & Unpack() of src consume the same bytes or fail the same way.
+func validateAgreesWithUnpack(t *testing.T, src []byte, typ reflect.Type) {
+	validateWithOptionsAgreesWithUnpack(t, src, typ, nil)
+}
+
+func validateWithOptionsAgreesWithUnpack(t *testing.T, src []byte, typ reflect.Type, options *UnpackOptions) {
+	var (
+		unpackBytesConsumed   uint64
+		unpackErr             error
+		unpackUnpackError     *UnpackError
+		validateBytesConsumed uint64
+		validateErr           error
+		validateUnpackError   *UnpackError
+	)
+
+	if reflect.Ptr == typ.Kind() {
+		typ = typ.Elem()
+	}
+
+	unpackBytesConsumed, unpackErr = UnpackWithOptions(src, reflect.New(typ).Interface(), options)
+	validateBytesConsumed, validateErr = ValidateWithOptions(src, typ, options)
+
+	if (nil == unpackErr) && (nil == validateErr) && (unpackBytesConsumed == validateBytesConsumed) {
+		return
+	}
+
+	if !errors.As(unpackErr, &unpackUnpackError) || !errors.As(validateErr, &validateUnpackError) || (unpackUnpackError.Cause != validateUnpackError.Cause) || (unpackUnpackError.Offset != validateUnpackError.Offset) {
+		t.Fatalf("ValidateWithOptions(0x%X, %v, %+v) returned (%d, %v) but UnpackWithOptions() returned (%d, %v)", src, typ, options, validateBytesConsumed, validateErr, unpackBytesConsumed, unpackErr)
+	}
+}
@@ -0,0 +1,841 @@
+package xdr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// View provides random access to the parts of an encoded value (e.g. a large reply of which only one field is of
+// interest) without decoding the whole of it:
+//
+//	name, err := xdr.NewView(src, Reply{}).Field("Entries").Index(3).Field("Name").String()
+//
+// Navigating to a part computes its offset by skipping over the parts preceding it, reading (and so validating)
+// only the lengths, discriminants, and Booleans needed to do so. The part itself is fully validated only when
+// its value is retrieved (e.g. by String() or Decode()).
+//
+// Views are immutable (so may be shared and navigated concurrently) and navigation never fails outright: any
+// error is carried by the resulting View and reported by its accessors (or Err()).
+type View struct {
+	src        []byte
+	schema     *Schema
+	offset     uint64
+	parent     *View  // View this one was derived from (or nil if returned by NewView())
+	pathSuffix string // Appended to the path of parent to form that of this View (e.g. ".Name" in "$.Entries[3].Name")
+	depth      uint64 // Number of arrays, structs, unions, & Optional-Data enclosing the part (limited to DefaultMaxDepth)
+	err        error
+}
+
+// NewView returns a View of the value src holds as described by typ (which, as for Dump(), may be a *Schema,
+// a reflect.Type, or a value of the Go type src is expected to encode).
+func NewView(src []byte, typ interface{}) (view View) {
+	view = View{src: src, pathSuffix: "$"}
+
+	view.schema, view.err = schemaOfType(typ)
+
+	return
+}
+
+// Err returns the error (if any) encountered navigating to view.
+func (view View) Err() (err error) {
+	err = view.err
+	return
+}
+
+// Schema returns the Schema describing view (or nil if view could not be navigated to).
+func (view View) Schema() (schema *Schema) {
+	if nil == view.err {
+		schema = view.schema
+	}
+	return
+}
+
+// Offset returns the offset in src of the first byte encoding view.
+func (view View) Offset() (offset uint64) {
+	offset = view.offset
+	return
+}
+
+// Size returns the number of bytes encoding view (skipping over, rather than fully validating, them).
+func (view View) Size() (size uint64, err error) {
+	var (
+		endOffset uint64
+	)
+
+	if nil != view.err {
+		err = view.err
+		return
+	}
+
+	endOffset, err = view.skip()
+	if nil != err {
+		return
+	}
+
+	size = endOffset - view.offset
+
+	return
+}
+
+// Field returns a View of the field (of a struct) or arm or discriminant (of a union) named fieldName.
+//
+// Optional data is followed (as is the case for a Go pointer) if present.
+func (view View) Field(fieldName string) (fieldView View) {
+	var (
+		arm              *SchemaArm
+		discriminant     int64
+		discriminantView View
+		fieldIndex       int
+		offset           uint64
+		structView       View
+	)
+
+	structView = view.follow()
+	if nil != structView.err {
+		fieldView = structView
+		return
+	}
+
+	switch structView.schema.Kind {
+	case SchemaStruct:
+		offset = structView.offset
+		for fieldIndex = range structView.schema.Fields {
+			fieldView = structView.derive(structView.schema.Fields[fieldIndex].Type, offset, "."+structView.schema.Fields[fieldIndex].Name)
+			if fieldName == structView.schema.Fields[fieldIndex].Name {
+				return
+			}
+			offset, fieldView.err = fieldView.skip()
+			if nil != fieldView.err {
+				return
+			}
+		}
+		fieldView = structView.fail("%s has no field %q", structView.schema.TypeName(), fieldName)
+	case SchemaUnion:
+		discriminantView = structView.derive(structView.schema.Discriminant.Type, structView.offset, "."+structView.schema.Discriminant.Name)
+		if fieldName == structView.schema.Discriminant.Name {
+			fieldView = discriminantView
+			return
+		}
+		discriminant, offset, fieldView.err = structView.discriminant()
+		if nil != fieldView.err {
+			return
+		}
+		arm, _ = structView.schema.selectArm(discriminant)
+		if fieldName != arm.Field.Name {
+			fieldView = structView.fail("%s arm %q is not selected by discriminant %d (which selects %q)", structView.schema.TypeName(), fieldName, discriminant, arm.Field.Name)
+			return
+		}
+		fieldView = structView.derive(arm.Field.Type, offset, "."+fieldName)
+	default:
+		fieldView = structView.fail("%s is not a struct or union", structView.schema.TypeName())
+	}
+
+	return
+}
+
+// Index returns a View of element elementIndex of a fixed or variable length array.
+//
+// Optional data is followed (as is the case for a Go pointer) if present.
+func (view View) Index(elementIndex int) (elementView View) {
+	var (
+		arrayView   View
+		elementSize uint64
+		isFixed     bool
+		length      uint64
+		offset      uint64
+		skipped     int
+	)
+
+	arrayView = view.follow()
+	if nil != arrayView.err {
+		elementView = arrayView
+		return
+	}
+
+	if (SchemaFixedArray != arrayView.schema.Kind) && (SchemaArray != arrayView.schema.Kind) {
+		elementView = arrayView.fail("%s is not an array", arrayView.schema.TypeName())
+		return
+	}
+
+	length, offset, elementView.err = arrayView.length()
+	if nil != elementView.err {
+		return
+	}
+
+	if (0 > elementIndex) || (uint64(elementIndex) >= length) {
+		elementView = arrayView.fail("index %d out of range for %s of length %d", elementIndex, arrayView.schema.TypeName(), length)
+		return
+	}
+
+	elementSize, isFixed = arrayView.schema.Elem.fixedSize(make(map[*Schema]bool))
+	if isFixed {
+		elementView = arrayView.derive(arrayView.schema.Elem, offset+uint64(elementIndex)*elementSize, fmt.Sprintf("[%d]", elementIndex))
+		return
+	}
+
+	for skipped = 0; skipped < elementIndex; skipped++ {
+		elementView = arrayView.derive(arrayView.schema.Elem, offset, fmt.Sprintf("[%d]", skipped))
+		offset, elementView.err = elementView.skip()
+		if nil != elementView.err {
+			return
+		}
+	}
+
+	elementView = arrayView.derive(arrayView.schema.Elem, offset, fmt.Sprintf("[%d]", elementIndex))
+
+	return
+}
+
+// Elem returns a View of the referent of optional data (failing if it is absent).
+func (view View) Elem() (elemView View) {
+	var (
+		isPresent bool
+	)
+
+	isPresent, elemView.err = view.IsPresent()
+	if nil != elemView.err {
+		elemView = view.withErr(elemView.err)
+		return
+	}
+	if !isPresent {
+		elemView = view.fail("%s is absent", view.schema.TypeName())
+		return
+	}
+
+	elemView = view.derive(view.schema.Elem, view.offset+4, "*")
+
+	return
+}
+
+// Lookup returns a View of the part of view at path, a sequence of field names (as for Field()) each preceded by
+// a "." (optional for the first), array indices (as for Index()) in square brackets, and "*" (as for Elem()). For
+// example, "Entries[3].Name" (or, equivalently, "$.Entries[3].Name" as reported in errors) is the same as
+// Field("Entries").Index(3).Field("Name").
+func (view View) Lookup(path string) (partView View) {
+	var (
+		elementIndex int
+		end          int
+		err          error
+		start        int
+	)
+
+	partView = view
+
+	start = 0
+	if strings.HasPrefix(path, "$") {
+		start = 1
+	}
+
+	for (nil == partView.err) && (start < len(path)) {
+		switch path[start] {
+		case '*':
+			partView = partView.Elem()
+			start++
+		case '[':
+			end = strings.IndexByte(path[start:], ']')
+			if 0 > end {
+				partView = partView.fail("path %q is missing a \"]\"", path)
+				return
+			}
+			elementIndex, err = strconv.Atoi(path[start+1 : start+end])
+			if nil != err {
+				partView = partView.fail("path %q has invalid index %q", path, path[start+1:start+end])
+				return
+			}
+			partView = partView.Index(elementIndex)
+			start += end + 1
+		default:
+			if '.' == path[start] {
+				start++
+			}
+			end = start
+			for (end < len(path)) && (0 > strings.IndexByte(".[*", path[end])) {
+				end++
+			}
+			partView = partView.Field(path[start:end])
+			start = end
+		}
+	}
+
+	return
+}
+
+// IsPresent returns whether optional data is present.
+func (view View) IsPresent() (isPresent bool, err error) {
+	var (
+		u64 uint64
+	)
+
+	err = view.expectKind(SchemaOptional)
+	if nil != err {
+		return
+	}
+
+	u64, err = decodeSchemaWord(view.src, view.offset, 4)
+	if nil != err {
+		return
+	}
+	if 1 < u64 {
+		err = newUnpackError(view.offset, ErrInvalidBoolean, "%s: Optional-Data Boolean is 0x%08X", view.path(), u64)
+		return
+	}
+
+	isPresent = (1 == u64)
+
+	return
+}
+
+// Len returns the length of an array, opaque data, or a string.
+func (view View) Len() (length int, err error) {
+	var (
+		u64 uint64
+	)
+
+	err = view.expectKind(SchemaFixedArray, SchemaArray, SchemaFixedOpaque, SchemaOpaque, SchemaString)
+	if nil != err {
+		return
+	}
+
+	u64, _, err = view.length()
+	if nil != err {
+		return
+	}
+
+	length = int(u64)
+
+	return
+}
+
+// Discriminant returns the discriminant of a union.
+func (view View) Discriminant() (discriminant int64, err error) {
+	err = view.expectKind(SchemaUnion)
+	if nil != err {
+		return
+	}
+
+	discriminant, _, err = view.discriminant()
+
+	return
+}
+
+// Int returns the value of an int, enum, or hyper.
+func (view View) Int() (i64 int64, err error) {
+	var (
+		node *schemaNode
+	)
+
+	err = view.expectKind(SchemaInt, SchemaEnum, SchemaHyper)
+	if nil != err {
+		return
+	}
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	i64 = node.value.(int64)
+
+	return
+}
+
+// Uint returns the value of an unsigned int or unsigned hyper.
+func (view View) Uint() (u64 uint64, err error) {
+	var (
+		node *schemaNode
+	)
+
+	err = view.expectKind(SchemaUint, SchemaUhyper)
+	if nil != err {
+		return
+	}
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	u64 = node.value.(uint64)
+
+	return
+}
+
+// Bool returns the value of a bool.
+func (view View) Bool() (b bool, err error) {
+	var (
+		node *schemaNode
+	)
+
+	err = view.expectKind(SchemaBool)
+	if nil != err {
+		return
+	}
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	b = node.value.(bool)
+
+	return
+}
+
+// Float returns the value of a float or double.
+func (view View) Float() (f64 float64, err error) {
+	var (
+		node *schemaNode
+	)
+
+	err = view.expectKind(SchemaFloat, SchemaDouble)
+	if nil != err {
+		return
+	}
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	f64 = node.value.(float64)
+
+	return
+}
+
+// Bytes returns the value of opaque data or a string. The returned []byte references (rather than copies) src.
+func (view View) Bytes() (b []byte, err error) {
+	var (
+		node *schemaNode
+	)
+
+	err = view.expectKind(SchemaFixedOpaque, SchemaOpaque, SchemaString)
+	if nil != err {
+		return
+	}
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	b = view.src[node.offset+node.header : node.offset+node.header+node.data]
+
+	return
+}
+
+// String returns the value of a string.
+func (view View) String() (s string, err error) {
+	var (
+		node *schemaNode
+	)
+
+	err = view.expectKind(SchemaString)
+	if nil != err {
+		return
+	}
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	s = node.value.(string)
+
+	return
+}
+
+// Value returns the (fully validated) value of view as decoded by DecodeDynamic().
+func (view View) Value() (value Value, err error) {
+	var (
+		node *schemaNode
+	)
+
+	node, err = view.decode()
+	if nil != err {
+		return
+	}
+
+	value, err = dynamicValueOf(node)
+
+	return
+}
+
+// Decode unpacks view into the Go value dstObjIF (passed by reference) as by Unpack(), reporting any failure at
+// its offset in src (rather than relative to view).
+func (view View) Decode(dstObjIF interface{}) (err error) {
+	_, err = view.unpack(dstObjIF)
+	return
+}
+
+// unpack implements Decode(), also returning the offset in src following view.
+func (view View) unpack(dstObjIF interface{}) (endOffset uint64, err error) {
+	var (
+		bytesConsumed uint64
+		unpackError   *UnpackError
+	)
+
+	if nil != view.err {
+		err = view.err
+		return
+	}
+	if uint64(len(view.src)) < view.offset {
+		err = newUnpackError(view.offset, ErrTruncated, "%s: No room for %s in src []byte", view.path(), view.schema.TypeName())
+		return
+	}
+
+	bytesConsumed, err = Unpack(view.src[view.offset:], dstObjIF)
+	if nil != err {
+		if errors.As(err, &unpackError) {
+			unpackError.Offset += view.offset
+		}
+		return
+	}
+
+	endOffset = view.offset + bytesConsumed
+
+	return
+}
+
+// derive returns a View of a part (described by schema at offset, with pathSuffix appended to view's path) of view.
+//
+// Note: The path is only assembled (by path()) as needed, as doing so upon each derive() would be quadratic in depth.
+func (view View) derive(schema *Schema, offset uint64, pathSuffix string) (derivedView View) {
+	derivedView = View{src: view.src, schema: schema, offset: offset, parent: &view, pathSuffix: pathSuffix, depth: view.depth + 1}
+	if DefaultMaxDepth < derivedView.depth {
+		derivedView.err = newUnpackError(offset, ErrLimitExceeded, "nesting depth exceeds %d", DefaultMaxDepth)
+	}
+	return
+}
+
+// path returns the path of view (e.g. "$.Entries[3].Name") for use in an error.
+func (view View) path() (path string) {
+	var (
+		ancestor     *View
+		builder      strings.Builder
+		i            int
+		pathSuffixes []string
+	)
+
+	for ancestor = &view; nil != ancestor; ancestor = ancestor.parent {
+		pathSuffixes = append(pathSuffixes, ancestor.pathSuffix)
+	}
+
+	for i = len(pathSuffixes) - 1; i >= 0; i-- {
+		builder.WriteString(pathSuffixes[i])
+	}
+
+	path = builder.String()
+
+	return
+}
+
+// fail returns a copy of view carrying an error (identifying view's path) formatted as by fmt.Errorf().
+func (view View) fail(format string, args ...interface{}) (failedView View) {
+	failedView = view.withErr(fmt.Errorf("%s: "+format, append([]interface{}{view.path()}, args...)...))
+	return
+}
+
+// withErr returns a copy of view carrying err.
+func (view View) withErr(err error) (failedView View) {
+	failedView = view
+	failedView.err = err
+	return
+}
+
+// follow returns view or, for present optional data, a View of its referent (recursively).
+func (view View) follow() (followedView View) {
+	followedView = view
+
+	for (nil == followedView.err) && (SchemaOptional == followedView.schema.Kind) {
+		followedView = followedView.Elem()
+	}
+
+	return
+}
+
+// expectKind returns view.err or, failing that, an error unless view describes one of kinds.
+func (view View) expectKind(kinds ...SchemaKind) (err error) {
+	var (
+		kind SchemaKind
+	)
+
+	if nil != view.err {
+		err = view.err
+		return
+	}
+
+	for _, kind = range kinds {
+		if kind == view.schema.Kind {
+			return
+		}
+	}
+
+	err = fmt.Errorf("%s: %s is not %v", view.path(), view.schema.TypeName(), kinds)
+
+	return
+}
+
+// decode fully decodes (and so validates) view.
+func (view View) decode() (node *schemaNode, err error) {
+	if nil != view.err {
+		err = view.err
+		return
+	}
+
+	defer recoverInternalError("View", &err)
+
+	node, err = decodeSchemaNode(view.src, view.offset, view.schema, "", view.depth, newSchemaDecodeState(view.src, DefaultMaxDepth))
+	if nil != err {
+		return
+	}
+	if 0 < len(node.notes) {
+		err = node.notes[0]
+		return
+	}
+
+	return
+}
+
+// length returns the length of an array, opaque data, or string along with the offset of its first element or byte.
+func (view View) length() (length uint64, offset uint64, err error) {
+	switch view.schema.Kind {
+	case SchemaFixedArray, SchemaFixedOpaque:
+		length = uint64(view.schema.Size)
+		offset = view.offset
+	default:
+		length, err = decodeSchemaWord(view.src, view.offset, 4)
+		if nil != err {
+			return
+		}
+		if (0 != view.schema.Size) && (uint64(view.schema.Size) < length) {
+			err = newUnpackError(view.offset, ErrMaxSizeExceeded, "%s: length %d exceeds maximum %d", view.path(), length, view.schema.Size)
+			return
+		}
+		offset = view.offset + 4
+	}
+
+	return
+}
+
+// discriminant returns the discriminant of a union along with the offset of its selected arm (which must exist),
+// following the length preceding the arm if it IsWrapped.
+func (view View) discriminant() (discriminant int64, offset uint64, err error) {
+	var (
+		arm              *SchemaArm
+		discriminantNode *schemaNode
+		ok               bool
+	)
+
+	discriminantNode, err = view.derive(view.schema.Discriminant.Type, view.offset, "."+view.schema.Discriminant.Name).decode()
+	if nil != err {
+		return
+	}
+
+	switch value := discriminantNode.value.(type) {
+	case int64:
+		discriminant = value
+	case uint64:
+		discriminant = int64(value)
+	case bool:
+		if value {
+			discriminant = 1
+		}
+	default:
+		err = newUnpackError(view.offset, ErrInternal, "%s: discriminant is a %s (not an int, unsigned int, enum, or bool)", view.path(), view.schema.Discriminant.Type.TypeName())
+		return
+	}
+
+	arm, ok = view.schema.selectArm(discriminant)
+	if !ok {
+		err = newUnpackError(view.offset, ErrInvalidDiscriminant, "%s: no arm of %s selected by discriminant %d", view.path(), view.schema.TypeName(), discriminant)
+		return
+	}
+
+	offset = view.offset + discriminantNode.size
+	if arm.IsWrapped {
+		offset += 4
+	}
+
+	return
+}
+
+// skip returns the offset following view, reading only the lengths, discriminants, and Booleans needed to find it.
+func (view View) skip() (endOffset uint64, err error) {
+	var (
+		arm          *SchemaArm
+		discriminant int64
+		elementIndex uint64
+		elementSize  uint64
+		field        SchemaField
+		isFixed      bool
+		isPresent    bool
+		length       uint64
+		offset       uint64
+	)
+
+	defer recoverInternalError("View", &err)
+
+	if nil != view.err {
+		err = view.err
+		return
+	}
+
+	endOffset, isFixed = view.schema.fixedSize(make(map[*Schema]bool))
+	if isFixed {
+		if (uint64(len(view.src)) < view.offset) || (uint64(len(view.src))-view.offset < endOffset) {
+			err = newUnpackError(view.offset, ErrTruncated, "%s: No room for %d byte %s in src []byte", view.path(), endOffset, view.schema.TypeName())
+			return
+		}
+		endOffset += view.offset
+		return
+	}
+
+	switch view.schema.Kind {
+	case SchemaOpaque, SchemaString:
+		length, offset, err = view.length()
+		if nil != err {
+			return
+		}
+		endOffset = offset + (length+3)/4*4
+		if uint64(len(view.src)) < endOffset {
+			err = newUnpackError(offset, ErrTruncated, "%s: No room for %d bytes (plus padding) in src []byte", view.path(), length)
+			return
+		}
+	case SchemaFixedArray, SchemaArray:
+		length, endOffset, err = view.length()
+		if nil != err {
+			return
+		}
+		elementSize, isFixed = view.schema.Elem.fixedSize(make(map[*Schema]bool))
+		if isFixed {
+			if (uint64(len(view.src)) < endOffset) || ((0 != elementSize) && ((uint64(len(view.src))-endOffset)/elementSize < length)) {
+				err = newUnpackError(endOffset, ErrTruncated, "%s: No room for %d elements of %s", view.path(), length, view.schema.Elem.TypeName())
+				return
+			}
+			endOffset += length * elementSize
+			return
+		}
+		for elementIndex = 0; elementIndex < length; elementIndex++ {
+			endOffset, err = view.derive(view.schema.Elem, endOffset, fmt.Sprintf("[%d]", elementIndex)).skip()
+			if nil != err {
+				return
+			}
+		}
+	case SchemaStruct:
+		endOffset = view.offset
+		for _, field = range view.schema.Fields {
+			endOffset, err = view.derive(field.Type, endOffset, "."+field.Name).skip()
+			if nil != err {
+				return
+			}
+		}
+	case SchemaUnion:
+		discriminant, offset, err = view.discriminant()
+		if nil != err {
+			return
+		}
+		arm, _ = view.schema.selectArm(discriminant)
+		if !arm.IsWrapped {
+			endOffset, err = view.derive(arm.Field.Type, offset, "."+arm.Field.Name).skip()
+			break
+		}
+		length, err = decodeSchemaWord(view.src, offset-4, 4)
+		if nil != err {
+			err = newUnpackError(offset-4, ErrTruncated, "%s: No room for arm length in src []byte", view.path())
+			return
+		}
+		endOffset = offset + length
+		if uint64(len(view.src)) < endOffset {
+			err = newUnpackError(offset-4, ErrTruncated, "%s: No room for %d byte arm %s in src []byte", view.path(), length, arm.Field.Name)
+			return
+		}
+	case SchemaOptional:
+		isPresent, err = view.IsPresent()
+		if nil != err {
+			return
+		}
+		if isPresent {
+			endOffset, err = view.derive(view.schema.Elem, view.offset+4, "*").skip()
+		} else {
+			endOffset = view.offset + 4
+		}
+	default:
+		err = newUnpackError(view.offset, ErrInternal, "%s: unsupported %v", view.path(), view.schema.Kind)
+	}
+
+	return
+}
+
+// fixedSize returns the number of bytes encoding any value described by schema (if all such encodings are of the
+// same size).
+func (schema *Schema) fixedSize(inProgress map[*Schema]bool) (fixedSize uint64, isFixed bool) {
+	var (
+		arm          SchemaArm
+		armFixedSize uint64
+		armIndex     int
+		field        SchemaField
+		fieldSize    uint64
+	)
+
+	if inProgress[schema] {
+		isFixed = false // Note: A recursive reference implies an intervening variable length or optional type
+		return
+	}
+	inProgress[schema] = true
+	defer delete(inProgress, schema)
+
+	isFixed = true
+
+	switch schema.Kind {
+	case SchemaVoid:
+		fixedSize = 0
+	case SchemaInt, SchemaUint, SchemaEnum, SchemaBool, SchemaFloat:
+		fixedSize = 4
+	case SchemaHyper, SchemaUhyper, SchemaDouble:
+		fixedSize = 8
+	case SchemaFixedOpaque:
+		fixedSize = (uint64(schema.Size) + 3) / 4 * 4
+	case SchemaFixedArray:
+		fixedSize, isFixed = schema.Elem.fixedSize(inProgress)
+		if isFixed && (0 != fixedSize) && (math.MaxUint64/fixedSize < uint64(schema.Size)) {
+			isFixed = false
+		}
+		fixedSize *= uint64(schema.Size)
+	case SchemaStruct:
+		for _, field = range schema.Fields {
+			fieldSize, isFixed = field.Type.fixedSize(inProgress)
+			if !isFixed {
+				return
+			}
+			fixedSize += fieldSize
+		}
+	case SchemaUnion:
+		if 0 == len(schema.Arms) {
+			isFixed = false
+			return
+		}
+		for armIndex, arm = range schema.Arms {
+			armFixedSize, isFixed = arm.Field.Type.fixedSize(inProgress)
+			if arm.IsWrapped {
+				armFixedSize += 4
+			}
+			if !isFixed || ((0 < armIndex) && (armFixedSize != fixedSize)) {
+				isFixed = false
+				return
+			}
+			fixedSize = armFixedSize
+		}
+		fixedSize += 4
+	default:
+		isFixed = false
+	}
+
+	if !isFixed {
+		fixedSize = 0
+	}
+
+	return
+}
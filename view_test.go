@@ -0,0 +1,225 @@
+package xdr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ViewEntry struct {
+	Size uint64   `xdr:""`
+	Name string   `xdr:"string,max=16"`
+	Tags []uint32 `xdr:"array,max=4"`
+}
+
+type ViewListing struct {
+	Count   uint32      `xdr:""`
+	Entries []ViewEntry `xdr:"array,max=8"`
+	Trailer *uint32     `xdr:"optional"`
+}
+
+func TestView(t *testing.T) {
+	var (
+		entry            ViewEntry
+		err              error
+		handle           []byte
+		i64              int64
+		isPresent        bool
+		length           int
+		listing          ViewListing
+		listingPacked    []byte
+		name             string
+		schemas          map[string]*Schema
+		size             uint64
+		trailer          uint32
+		u64              uint64
+		unpackError      *UnpackError
+		value            Value
+		view             View
+		viewOfEntry      View
+		viewOfFirstEntry View
+		viewOfListings   View
+	)
+
+	trailer = 9
+	listing = ViewListing{
+		Count: 5,
+		Entries: []ViewEntry{
+			{Size: 10, Name: "zero", Tags: []uint32{1}},
+			{Size: 11, Name: "one", Tags: []uint32{}},
+			{Size: 12, Name: "two", Tags: []uint32{2, 3}},
+			{Size: 13, Name: "three", Tags: []uint32{4, 5, 6}},
+			{Size: 14, Name: "fours", Tags: []uint32{7}},
+		},
+		Trailer: &trailer,
+	}
+
+	listingPacked, err = Pack(listing)
+	if nil != err {
+		t.Fatalf("Pack(listing) received unexpected error: %v", err)
+	}
+
+	view = NewView(listingPacked, ViewListing{})
+
+	name, err = view.Field("Entries").Index(3).Field("Name").String()
+	if (nil != err) || ("three" != name) {
+		t.Fatalf("View.Field(\"Entries\").Index(3).Field(\"Name\").String() returned %q (expected \"three\") or unexpected error: %v", name, err)
+	}
+
+	name, err = view.Lookup("$.Entries[3].Name").String()
+	if (nil != err) || ("three" != name) {
+		t.Fatalf("View.Lookup(\"$.Entries[3].Name\").String() returned %q (expected \"three\") or unexpected error: %v", name, err)
+	}
+	u64, err = view.Lookup("Trailer*").Uint()
+	if (nil != err) || (9 != u64) {
+		t.Fatalf("View.Lookup(\"Trailer*\").Uint() returned %d (expected 9) or unexpected error: %v", u64, err)
+	}
+	_, err = view.Lookup("Entries[three].Name").String()
+	if (nil == err) || !strings.Contains(err.Error(), `$.Entries: path "Entries[three].Name" has invalid index "three"`) {
+		t.Fatalf("View.Lookup(\"Entries[three].Name\") returned unexpected error: %v", err)
+	}
+
+	u64, err = view.Field("Entries").Index(4).Field("Size").Uint()
+	if (nil != err) || (14 != u64) {
+		t.Fatalf("View.Field(\"Entries\").Index(4).Field(\"Size\").Uint() returned %d (expected 14) or unexpected error: %v", u64, err)
+	}
+
+	length, err = view.Field("Entries").Len()
+	if (nil != err) || (5 != length) {
+		t.Fatalf("View.Field(\"Entries\").Len() returned %d (expected 5) or unexpected error: %v", length, err)
+	}
+
+	u64, err = view.Field("Trailer").Uint()
+	if (nil == err) || !strings.Contains(err.Error(), "$.Trailer: ") {
+		t.Fatalf("View.Field(\"Trailer\").Uint() returned %d or unexpected error: %v", u64, err)
+	}
+	isPresent, err = view.Field("Trailer").IsPresent()
+	if (nil != err) || !isPresent {
+		t.Fatalf("View.Field(\"Trailer\").IsPresent() returned %v or unexpected error: %v", isPresent, err)
+	}
+	u64, err = view.Field("Trailer").Elem().Uint()
+	if (nil != err) || (9 != u64) {
+		t.Fatalf("View.Field(\"Trailer\").Elem().Uint() returned %d (expected 9) or unexpected error: %v", u64, err)
+	}
+
+	viewOfEntry = view.Field("Entries").Index(2)
+
+	size, err = viewOfEntry.Size()
+	if (nil != err) || (8+4+4+4+4+4 != size) {
+		t.Fatalf("View.Field(\"Entries\").Index(2).Size() returned %d or unexpected error: %v", size, err)
+	}
+
+	err = viewOfEntry.Decode(&entry)
+	if (nil != err) || !reflect.DeepEqual(listing.Entries[2], entry) {
+		t.Fatalf("View.Field(\"Entries\").Index(2).Decode() returned %#v or unexpected error: %v", entry, err)
+	}
+
+	value, err = viewOfEntry.Field("Tags").Value()
+	if (nil != err) || !reflect.DeepEqual(Array{Uint(2), Uint(3)}, value) {
+		t.Fatalf("View.Field(\"Entries\").Index(2).Field(\"Tags\").Value() returned %#v or unexpected error: %v", value, err)
+	}
+
+	// Verify navigation errors report the path at which they were detected
+
+	_, err = view.Field("Entries").Index(5).Field("Name").String()
+	if (nil == err) || !strings.Contains(err.Error(), "$.Entries: index 5 out of range") {
+		t.Fatalf("View.Field(\"Entries\").Index(5) returned unexpected error: %v", err)
+	}
+	_, err = view.Field("Entries").Index(1).Field("name").String()
+	if (nil == err) || !strings.Contains(err.Error(), `$.Entries[1]: ViewEntry has no field "name"`) {
+		t.Fatalf("View.Field(\"Entries\").Index(1).Field(\"name\") returned unexpected error: %v", err)
+	}
+	_, err = view.Field("Count").Index(0).Uint()
+	if (nil == err) || !strings.Contains(err.Error(), "$.Count: unsigned int is not an array") {
+		t.Fatalf("View.Field(\"Count\").Index(0) returned unexpected error: %v", err)
+	}
+	_, err = view.Field("Count").String()
+	if (nil == err) || !strings.Contains(err.Error(), "$.Count: ") {
+		t.Fatalf("View.Field(\"Count\").String() returned unexpected error: %v", err)
+	}
+
+	// Verify only the bytes traversed are validated (here, Entries[4].Name has non-zero padding)
+
+	listingPacked[len(listingPacked)-17] = 0xFF
+
+	name, err = view.Field("Entries").Index(3).Field("Name").String()
+	if (nil != err) || ("three" != name) {
+		t.Fatalf("View.Field(\"Entries\").Index(3).Field(\"Name\").String() returned %q or unexpected error: %v", name, err)
+	}
+	_, err = view.Field("Entries").Index(4).Field("Name").String()
+	if !errors.Is(err, ErrNonZeroPadding) {
+		t.Fatalf("View.Field(\"Entries\").Index(4).Field(\"Name\").String() returned unexpected error: %v", err)
+	}
+	err = view.Field("Entries").Index(4).Decode(&entry)
+	if !errors.As(err, &unpackError) || (uint64(len(listingPacked)-17) != unpackError.Offset) {
+		t.Fatalf("View.Field(\"Entries\").Index(4).Decode() returned unexpected error: %v", err)
+	}
+	u64, err = view.Field("Trailer").Elem().Uint()
+	if (nil != err) || (9 != u64) {
+		t.Fatalf("View.Field(\"Trailer\").Elem().Uint() returned %d (expected 9) or unexpected error: %v", u64, err)
+	}
+
+	// Verify lengths skipped over are validated
+
+	_, err = NewView(listingPacked[:40], ViewListing{}).Field("Trailer").IsPresent()
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("View(<truncated>).Field(\"Trailer\").IsPresent() returned unexpected error: %v", err)
+	}
+	listingPacked[7] = 9
+	_, err = view.Field("Trailer").IsPresent()
+	if !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Fatalf("View(<too many Entries>).Field(\"Trailer\").IsPresent() returned unexpected error: %v", err)
+	}
+
+	// Verify unions and optional data described by a parsed Schema
+
+	schemas, err = ParseSchema(testDynamicSchemaText)
+	if nil != err {
+		t.Fatalf("ParseSchema() received unexpected error: %v", err)
+	}
+
+	viewOfListings = NewView([]byte{
+		0x00, 0x00, 0x00, 0x01, //                         listing length 1
+		0x00, 0x00, 0x00, 0x07, //                         id: 7
+		0x00, 0x00, 0x00, 0x02, 'h', 'i', 0x00, 0x00, //   name: "hi"
+		0x00, 0x00, 0x00, 0x00, //                         hidden: FALSE
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, // size: 2
+		0x00, 0x00, 0x00, 0x01, 0x0A, 0x0B, 0x0C, 0x00, // n: KIND_FILE, handle
+		0x00, 0x00, 0x00, 0x01, //                         next: present
+		0x00, 0x00, 0x00, 0x08, //                         id: 8
+		0x00, 0x00, 0x00, 0x00, //                         name: ""
+		0x00, 0x00, 0x00, 0x01, //                         hidden: TRUE
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // size: 0
+		0x00, 0x00, 0x00, 0x02, //                         n: KIND_DIR
+		0x00, 0x00, 0x00, 0x00, //                         next: absent
+	}, schemas["listing"])
+
+	viewOfFirstEntry = viewOfListings.Index(0)
+
+	handle, err = viewOfFirstEntry.Field("n").Field("handle").Bytes()
+	if (nil != err) || ("\x0A\x0B\x0C" != string(handle)) {
+		t.Fatalf("View.Index(0).Field(\"n\").Field(\"handle\").Bytes() returned 0x%X or unexpected error: %v", handle, err)
+	}
+	i64, err = viewOfFirstEntry.Field("n").Field("k").Int()
+	if (nil != err) || (1 != i64) {
+		t.Fatalf("View.Index(0).Field(\"n\").Field(\"k\").Int() returned %d (expected 1) or unexpected error: %v", i64, err)
+	}
+	i64, err = viewOfFirstEntry.Field("next").Field("n").Discriminant()
+	if (nil != err) || (2 != i64) {
+		t.Fatalf("View.Index(0).Field(\"next\").Field(\"n\").Discriminant() returned %d (expected 2) or unexpected error: %v", i64, err)
+	}
+	_, err = viewOfFirstEntry.Field("next").Field("n").Field("handle").Bytes()
+	if (nil == err) || !strings.Contains(err.Error(), `$[0].next*.n: node arm "handle" is not selected by discriminant 2`) {
+		t.Fatalf("View.Index(0).Field(\"next\").Field(\"n\").Field(\"handle\") returned unexpected error: %v", err)
+	}
+	_, err = viewOfFirstEntry.Field("next").Field("next").Field("id").Uint()
+	if (nil == err) || !strings.Contains(err.Error(), "$[0].next*.next: entry* is absent") {
+		t.Fatalf("View.Index(0).Field(\"next\").Field(\"next\").Field(\"id\") returned unexpected error: %v", err)
+	}
+
+	_, err = NewView(listingPacked, nil).Field("Count").Uint()
+	if nil == err {
+		t.Fatalf("NewView(<nil *Schema>).Field(\"Count\").Uint() should have failed")
+	}
+}